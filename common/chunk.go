@@ -0,0 +1,89 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Headers used to split a record value that is too large for a single Kafka
+// message into multiple chunks. All chunks of a record share the same key and
+// chunk ID and are reassembled by the source in the order of the chunk index.
+const (
+	ChunkHeaderID    = "conduit.chunk.id"
+	ChunkHeaderIndex = "conduit.chunk.index"
+	ChunkHeaderCount = "conduit.chunk.count"
+)
+
+// Chunk describes a single chunk of a chunked record.
+type Chunk struct {
+	ID    string
+	Index int
+	Count int
+}
+
+// Headers returns the Kafka headers describing the chunk.
+func (c Chunk) Headers() []kgo.RecordHeader {
+	return []kgo.RecordHeader{
+		{Key: ChunkHeaderID, Value: []byte(c.ID)},
+		{Key: ChunkHeaderIndex, Value: []byte(strconv.Itoa(c.Index))},
+		{Key: ChunkHeaderCount, Value: []byte(strconv.Itoa(c.Count))},
+	}
+}
+
+// ParseChunk extracts the chunk information from the record headers. It
+// returns false if the record is not a chunk.
+func ParseChunk(headers []kgo.RecordHeader) (Chunk, bool, error) {
+	var (
+		c     Chunk
+		found int
+		err   error
+	)
+	for _, h := range headers {
+		switch h.Key {
+		case ChunkHeaderID:
+			c.ID = string(h.Value)
+		case ChunkHeaderIndex:
+			c.Index, err = strconv.Atoi(string(h.Value))
+		case ChunkHeaderCount:
+			c.Count, err = strconv.Atoi(string(h.Value))
+		default:
+			continue
+		}
+		if err != nil {
+			return Chunk{}, true, fmt.Errorf("invalid chunk header %q: %w", h.Key, err)
+		}
+		found++
+	}
+
+	switch {
+	case found == 0:
+		return Chunk{}, false, nil
+	case found != 3 || c.ID == "":
+		return Chunk{}, true, errors.New("incomplete chunk headers")
+	case c.Count < 1 || c.Index < 0 || c.Index >= c.Count:
+		return Chunk{}, true, fmt.Errorf("invalid chunk index %d of %d", c.Index, c.Count)
+	}
+	return c, true, nil
+}
+
+// IsChunkHeader returns true if the header key is used to describe a chunk.
+func IsChunkHeader(key string) bool {
+	return key == ChunkHeaderID || key == ChunkHeaderIndex || key == ChunkHeaderCount
+}
@@ -0,0 +1,310 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// ClientType is the type of Kafka client a client option applies to.
+type ClientType int
+
+const (
+	// ClientAny is the type of options that apply to all clients.
+	ClientAny ClientType = iota
+	// ClientProducer is the type of options that only apply to producers,
+	// i.e. the destination.
+	ClientProducer
+	// ClientConsumer is the type of options that only apply to consumers,
+	// i.e. the source.
+	ClientConsumer
+)
+
+func (t ClientType) String() string {
+	switch t {
+	case ClientProducer:
+		return "producer"
+	case ClientConsumer:
+		return "consumer"
+	default:
+		return "any"
+	}
+}
+
+// Defaults and limits of franz-go used to validate client options that depend
+// on each other.
+const (
+	defaultFetchMaxBytes      = 50 << 20
+	defaultBrokerMaxBytes     = 100 << 20
+	defaultMetadataMaxAge     = 5 * time.Minute
+	defaultSessionTimeout     = 45 * time.Second
+	defaultHeartbeatInterval  = 3 * time.Second
+	defaultProducerBatchBytes = 1000012
+	minBrokerReadWriteBytes   = 1 << 10
+	maxBrokerReadWriteBytes   = 1 << 30
+)
+
+// noLimit is used as the upper bound of client options without a limit.
+const noLimit = -1
+
+// clientOption is an option that can be supplied in Config.ClientOptions.
+type clientOption struct {
+	client ClientType
+	parse  clientOptionParser
+}
+
+// clientOptionParser parses the value of a client option and returns the
+// corresponding franz-go option.
+type clientOptionParser func(string) (kgo.Opt, error)
+
+// knownClientOptions contains all options that can be supplied in
+// Config.ClientOptions. The bounds of the values match the bounds enforced by
+// franz-go when the client is created.
+var knownClientOptions = map[string]clientOption{
+	// general
+	"conn.idle.timeout":      {ClientAny, durationOpt(kgo.ConnIdleTimeout, time.Second, 15*time.Minute)},
+	"request.retries":        {ClientAny, intOpt(kgo.RequestRetries, 0)},
+	"retry.timeout":          {ClientAny, durationOpt(kgo.RetryTimeout, 0, noLimit)},
+	"metadata.min.age":       {ClientAny, durationOpt(kgo.MetadataMinAge, 10*time.Millisecond, time.Hour)},
+	"broker.max.read.bytes":  {ClientAny, int32Opt(kgo.BrokerMaxReadBytes, minBrokerReadWriteBytes, maxBrokerReadWriteBytes)},
+	"broker.max.write.bytes": {ClientAny, int32Opt(kgo.BrokerMaxWriteBytes, minBrokerReadWriteBytes, maxBrokerReadWriteBytes)},
+
+	// producer
+	"linger":                  {ClientProducer, durationOpt(kgo.ProducerLinger, 0, time.Minute)},
+	"max.buffered.records":    {ClientProducer, intOpt(kgo.MaxBufferedRecords, 1)},
+	"max.buffered.bytes":      {ClientProducer, intOpt(kgo.MaxBufferedBytes, 0)},
+	"max.inflight.per.broker": {ClientProducer, intOpt(kgo.MaxProduceRequestsInflightPerBroker, 1)},
+	"produce.request.timeout": {ClientProducer, durationOpt(kgo.ProduceRequestTimeout, 100*time.Millisecond, noLimit)},
+	"record.retries":          {ClientProducer, intOpt(kgo.RecordRetries, 0)},
+
+	// consumer
+	"fetch.max.bytes":           {ClientConsumer, int32Opt(kgo.FetchMaxBytes, 1, noLimit)},
+	"fetch.min.bytes":           {ClientConsumer, int32Opt(kgo.FetchMinBytes, 1, noLimit)},
+	"fetch.max.wait":            {ClientConsumer, durationOpt(kgo.FetchMaxWait, 10*time.Millisecond, noLimit)},
+	"fetch.max.partition.bytes": {ClientConsumer, int32Opt(kgo.FetchMaxPartitionBytes, 1, noLimit)},
+	"fetch.isolation.level":     {ClientConsumer, isolationLevelOpt},
+	"max.concurrent.fetches":    {ClientConsumer, intOpt(kgo.MaxConcurrentFetches, 0)},
+	"rack":                      {ClientConsumer, stringOpt(kgo.Rack, 512)},
+
+	// consumer group
+	"session.timeout":    {ClientConsumer, durationOpt(kgo.SessionTimeout, 100*time.Millisecond, noLimit)},
+	"rebalance.timeout":  {ClientConsumer, durationOpt(kgo.RebalanceTimeout, 100*time.Millisecond, noLimit)},
+	"heartbeat.interval": {ClientConsumer, durationOpt(kgo.HeartbeatInterval, time.Millisecond, noLimit)},
+	"instance.id":        {ClientConsumer, stringOpt(kgo.InstanceID, noLimit)},
+}
+
+// validateClientOptions returns an error if any of the client options is
+// unknown, has an invalid value or conflicts with another option.
+func (c Config) validateClientOptions() error {
+	var multierr []error
+	if _, err := c.clientOptions(ClientAny); err != nil {
+		multierr = append(multierr, err)
+	}
+	// parse errors are already reported above
+	readBytes, errRead := c.ClientOptionInt("broker.max.read.bytes", defaultBrokerMaxBytes)
+	fetchBytes, errFetch := c.ClientOptionInt("fetch.max.bytes", defaultFetchMaxBytes)
+	if errRead == nil && errFetch == nil && readBytes < fetchBytes {
+		multierr = append(multierr, fmt.Errorf(`client option "broker.max.read.bytes" (%d) can't be lower than "fetch.max.bytes" (%d)`, readBytes, fetchBytes))
+	}
+	if minAge, ok := c.ClientOptions["metadata.min.age"]; ok {
+		maxAge := c.MetadataMaxAge
+		if maxAge == 0 {
+			maxAge = defaultMetadataMaxAge
+		}
+		if d, err := time.ParseDuration(minAge); err == nil && d > maxAge {
+			multierr = append(multierr, fmt.Errorf(`client option "metadata.min.age" (%v) can't be greater than "metadataMaxAge" (%v)`, d, maxAge))
+		}
+	}
+	return errors.Join(multierr...)
+}
+
+// ValidateClientOptionsFor returns an error if any of the client options
+// doesn't apply to the client type, e.g. consumer options in the destination.
+// It also checks options that depend on the client type.
+func (c Config) ValidateClientOptionsFor(t ClientType) error {
+	var multierr []error
+	for _, k := range slices.Sorted(maps.Keys(c.ClientOptions)) {
+		o, ok := knownClientOptions[k]
+		if ok && o.client != ClientAny && o.client != t {
+			multierr = append(multierr, fmt.Errorf("client option %q only applies to the %v, it can't be used in the %v", k, o.client, t))
+		}
+	}
+	if t == ClientConsumer {
+		// consumers use the default batch size of franz-go
+		if err := c.ValidateBrokerMaxWriteBytes(defaultProducerBatchBytes); err != nil {
+			multierr = append(multierr, err)
+		}
+		heartbeat, okHeartbeat := c.ClientOptions["heartbeat.interval"]
+		session, okSession := c.ClientOptions["session.timeout"]
+		if okHeartbeat || okSession {
+			h, errH := time.ParseDuration(cmp.Or(heartbeat, defaultHeartbeatInterval.String()))
+			s, errS := time.ParseDuration(cmp.Or(session, defaultSessionTimeout.String()))
+			if errH == nil && errS == nil && h >= s {
+				multierr = append(multierr, fmt.Errorf(`client option "heartbeat.interval" (%v) needs to be lower than "session.timeout" (%v)`, h, s))
+			}
+		}
+	}
+	return errors.Join(multierr...)
+}
+
+// ValidateBrokerMaxWriteBytes returns an error if the client option
+// "broker.max.write.bytes" is lower than the maximum size of a record batch.
+func (c Config) ValidateBrokerMaxWriteBytes(batchBytes int) error {
+	writeBytes, err := c.ClientOptionInt("broker.max.write.bytes", defaultBrokerMaxBytes)
+	if err == nil && writeBytes < batchBytes {
+		return fmt.Errorf(`client option "broker.max.write.bytes" (%d) can't be lower than the maximum record batch size (%d)`, writeBytes, batchBytes)
+	}
+	return nil
+}
+
+// ClientOptionInt returns the integer value of the client option, or def if
+// the option is not set.
+func (c Config) ClientOptionInt(key string, def int) (int, error) {
+	v, ok := c.ClientOptions[key]
+	if !ok {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
+
+// ClientOpts returns the franz-go options derived from ClientOptions that only
+// apply to the client type. Options that apply to all clients are returned by
+// FranzClientOpts.
+func (c Config) ClientOpts(t ClientType) []kgo.Opt {
+	opts, err := c.clientOptions(t)
+	if err != nil {
+		return nil // errors are reported by Validate
+	}
+	return opts
+}
+
+// clientOptions returns the franz-go options derived from ClientOptions that
+// apply to the client type, sorted by key so the result is deterministic.
+// ClientAny returns the options that apply to all clients, but validates all
+// options.
+func (c Config) clientOptions(t ClientType) ([]kgo.Opt, error) {
+	var multierr []error
+	var opts []kgo.Opt
+	for _, k := range slices.Sorted(maps.Keys(c.ClientOptions)) {
+		o, ok := knownClientOptions[k]
+		if !ok {
+			multierr = append(multierr, fmt.Errorf("unknown client option %q", k))
+			continue
+		}
+		opt, err := o.parse(c.ClientOptions[k])
+		if err != nil {
+			multierr = append(multierr, fmt.Errorf("invalid value for client option %q: %w", k, err))
+			continue
+		}
+		if o.client == t {
+			opts = append(opts, opt)
+		}
+	}
+	if len(multierr) > 0 {
+		return nil, errors.Join(multierr...)
+	}
+	return opts, nil
+}
+
+func intOpt[O kgo.Opt](fn func(int) O, lowest int) clientOptionParser {
+	return func(v string) (kgo.Opt, error) {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkBounds(i, lowest, noLimit); err != nil {
+			return nil, err
+		}
+		return fn(i), nil
+	}
+}
+
+func int32Opt[O kgo.Opt](fn func(int32) O, lowest, highest int) clientOptionParser {
+	return func(v string) (kgo.Opt, error) {
+		i, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkBounds(int(i), lowest, highest); err != nil {
+			return nil, err
+		}
+		return fn(int32(i)), nil
+	}
+}
+
+func durationOpt[O kgo.Opt](fn func(time.Duration) O, lowest, highest time.Duration) clientOptionParser {
+	return func(v string) (kgo.Opt, error) {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		if d < lowest {
+			if lowest == 0 {
+				return nil, errors.New("value can't be negative")
+			}
+			return nil, fmt.Errorf("value can't be lower than %v", lowest)
+		}
+		if highest != noLimit && d > highest {
+			return nil, fmt.Errorf("value can't be greater than %v", highest)
+		}
+		return fn(d), nil
+	}
+}
+
+// checkBounds returns an error if i is lower than lowest or greater than
+// highest, highest is ignored if it is noLimit.
+func checkBounds(i, lowest, highest int) error {
+	if i < lowest {
+		if lowest == 0 {
+			return errors.New("value can't be negative")
+		}
+		return fmt.Errorf("value can't be lower than %d", lowest)
+	}
+	if highest != noLimit && i > highest {
+		return fmt.Errorf("value can't be greater than %d", highest)
+	}
+	return nil
+}
+
+func stringOpt[O kgo.Opt](fn func(string) O, maxLen int) clientOptionParser {
+	return func(v string) (kgo.Opt, error) {
+		if v == "" {
+			return nil, errors.New("value can't be empty")
+		}
+		if maxLen != noLimit && len(v) > maxLen {
+			return nil, fmt.Errorf("value can't be longer than %d characters", maxLen)
+		}
+		return fn(v), nil
+	}
+}
+
+func isolationLevelOpt(v string) (kgo.Opt, error) {
+	switch v {
+	case "read_uncommitted":
+		return kgo.FetchIsolationLevel(kgo.ReadUncommitted()), nil
+	case "read_committed":
+		return kgo.FetchIsolationLevel(kgo.ReadCommitted()), nil
+	default:
+		return nil, fmt.Errorf("expected read_uncommitted or read_committed, got %q", v)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"cmp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/rs/zerolog"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestConfig_FranzClientOpts_ClientOptions(t *testing.T) {
+	is := is.New(t)
+
+	cfg := Config{
+		Servers: []string{"test-host:9092"},
+		ClientOptions: map[string]string{
+			"fetch.max.bytes":       "1024",
+			"fetch.max.wait":        "250ms",
+			"fetch.isolation.level": "read_committed",
+			"metadata.min.age":      "1s",
+			"rack":                  "eu-west-1a",
+			"session.timeout":       "30s",
+		},
+	}
+	is.NoErr(cfg.Validate())
+	is.NoErr(cfg.ValidateClientOptionsFor(ClientConsumer))
+
+	logger := zerolog.Nop()
+	cl, err := kgo.NewClient(append(cfg.FranzClientOpts(&logger), cfg.ClientOpts(ClientConsumer)...)...)
+	is.NoErr(err)
+	defer cl.Close()
+
+	is.Equal(cl.OptValue(kgo.FetchMaxBytes), int32(1024))
+	is.Equal(cl.OptValue(kgo.FetchMaxWait), 250*time.Millisecond)
+	is.Equal(cl.OptValue(kgo.MetadataMinAge), time.Second)
+	is.Equal(cl.OptValue(kgo.Rack), "eu-west-1a")
+	is.Equal(cl.OptValue(kgo.SessionTimeout), 30*time.Second)
+}
+
+func TestConfig_FranzClientOpts_ClientType(t *testing.T) {
+	is := is.New(t)
+
+	cfg := Config{
+		Servers: []string{"test-host:9092"},
+		ClientOptions: map[string]string{
+			"linger":          "20ms",
+			"fetch.max.bytes": "1024",
+		},
+	}
+	is.NoErr(cfg.Validate())
+
+	// only options that apply to all clients are added by FranzClientOpts
+	logger := zerolog.Nop()
+	cl, err := kgo.NewClient(cfg.FranzClientOpts(&logger)...)
+	is.NoErr(err)
+	defer cl.Close()
+	is.True(cl.OptValue(kgo.ProducerLinger) != 20*time.Millisecond)
+	is.True(cl.OptValue(kgo.FetchMaxBytes) != int32(1024))
+
+	is.Equal(len(cfg.ClientOpts(ClientProducer)), 1)
+	is.Equal(len(cfg.ClientOpts(ClientConsumer)), 1)
+
+	err = cfg.ValidateClientOptionsFor(ClientProducer)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `client option "fetch.max.bytes" only applies to the consumer`))
+	err = cfg.ValidateClientOptionsFor(ClientConsumer)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `client option "linger" only applies to the producer`))
+}
+
+// lowestClientOptions contains the lowest valid value of each client option.
+var lowestClientOptions = map[string]string{
+	"conn.idle.timeout":      "1s",
+	"request.retries":        "0",
+	"retry.timeout":          "0s",
+	"metadata.min.age":       "10ms",
+	"broker.max.read.bytes":  "1024",
+	"broker.max.write.bytes": "1024",
+
+	"linger":                  "0s",
+	"max.buffered.records":    "1",
+	"max.buffered.bytes":      "0",
+	"max.inflight.per.broker": "1",
+	"produce.request.timeout": "100ms",
+	"record.retries":          "0",
+
+	"fetch.max.bytes":           "1",
+	"fetch.min.bytes":           "1",
+	"fetch.max.wait":            "10ms",
+	"fetch.max.partition.bytes": "1",
+	"fetch.isolation.level":     "read_uncommitted",
+	"max.concurrent.fetches":    "0",
+	"rack":                      "rack-1",
+
+	"session.timeout":    "100ms",
+	"rebalance.timeout":  "100ms",
+	"heartbeat.interval": "1ms",
+	"instance.id":        "instance-1",
+}
+
+func TestKnownClientOptions(t *testing.T) {
+	is := is.New(t)
+	is.Equal(len(lowestClientOptions), len(knownClientOptions))
+
+	// make sure the lowest valid value of each option is accepted by franz-go
+	for key, o := range knownClientOptions {
+		t.Run(key, func(t *testing.T) {
+			is := is.New(t)
+
+			v, ok := lowestClientOptions[key]
+			is.True(ok)
+			cfg := Config{
+				Servers:       []string{"test-host:9092"},
+				ClientOptions: map[string]string{key: v},
+			}
+			switch key {
+			case "broker.max.write.bytes":
+				// franz-go checks it against the default batch size, this is
+				// covered by TestConfig_ValidateClientOptionsFor_Consumer
+				_, err := o.parse(v)
+				is.NoErr(err)
+				return
+			case "broker.max.read.bytes":
+				cfg.ClientOptions["fetch.max.bytes"] = v
+			case "session.timeout":
+				cfg.ClientOptions["heartbeat.interval"] = "1ms"
+			}
+			client := cmp.Or(o.client, ClientConsumer)
+			is.NoErr(cfg.Validate())
+			is.NoErr(cfg.ValidateClientOptionsFor(client))
+
+			logger := zerolog.Nop()
+			cl, err := kgo.NewClient(append(cfg.FranzClientOpts(&logger), cfg.ClientOpts(client)...)...)
+			is.NoErr(err)
+			cl.Close()
+		})
+	}
+}
+
+func TestConfig_Validate_ClientOptionBounds(t *testing.T) {
+	testCases := []struct {
+		key     string
+		value   string
+		wantErr string
+	}{
+		{key: "max.buffered.records", value: "0", wantErr: "value can't be lower than 1"},
+		{key: "max.inflight.per.broker", value: "0", wantErr: "value can't be lower than 1"},
+		{key: "fetch.max.bytes", value: "0", wantErr: "value can't be lower than 1"},
+		{key: "max.buffered.bytes", value: "-1", wantErr: "value can't be negative"},
+		{key: "broker.max.read.bytes", value: "1023", wantErr: "value can't be lower than 1024"},
+		{key: "broker.max.write.bytes", value: "2147483647", wantErr: "value can't be greater than 1073741824"},
+		{key: "conn.idle.timeout", value: "500ms", wantErr: "value can't be lower than 1s"},
+		{key: "linger", value: "2m", wantErr: "value can't be greater than 1m0s"},
+		{key: "rack", value: strings.Repeat("x", 513), wantErr: "value can't be longer than 512 characters"},
+		{key: "broker.max.read.bytes", value: "1048576", wantErr: `"broker.max.read.bytes" (1048576) can't be lower than "fetch.max.bytes" (52428800)`},
+		{key: "metadata.min.age", value: "10m", wantErr: `"metadata.min.age" (10m0s) can't be greater than "metadataMaxAge" (5m0s)`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.key+"="+tc.value, func(t *testing.T) {
+			is := is.New(t)
+			cfg := Config{ClientOptions: map[string]string{tc.key: tc.value}}
+			err := cfg.Validate()
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tc.wantErr))
+		})
+	}
+}
+
+func TestConfig_ValidateClientOptionsFor_Consumer(t *testing.T) {
+	is := is.New(t)
+
+	cfg := Config{ClientOptions: map[string]string{"heartbeat.interval": "10s", "session.timeout": "5s"}}
+	err := cfg.ValidateClientOptionsFor(ClientConsumer)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `"heartbeat.interval" (10s) needs to be lower than "session.timeout" (5s)`))
+
+	cfg = Config{ClientOptions: map[string]string{"broker.max.write.bytes": "1024"}}
+	err = cfg.ValidateClientOptionsFor(ClientConsumer)
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), `"broker.max.write.bytes" (1024) can't be lower than the maximum record batch size (1000012)`))
+}
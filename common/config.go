@@ -17,14 +17,23 @@ package common
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	sdk "github.com/conduitio/conduit-connector-sdk"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
-// TODO make the timeout configurable
-var connectionTimeout = time.Second * 10
+const (
+	// connectionRetryInterval is the time TryDial waits between pings.
+	connectionRetryInterval = time.Second
+
+	minRequestTimeout = time.Second
+	maxRequestTimeout = time.Minute * 15
+	maxMetadataMaxAge = time.Hour
+)
 
 // Config contains common configuration parameters.
 type Config struct {
@@ -36,8 +45,53 @@ type Config struct {
 	// this connector.
 	ClientID string `json:"clientID" default:"conduit-connector-kafka"`
 
+	// ConnectionTimeout is the total time the connector waits for a broker to
+	// become reachable when it is started before giving up. Defaults to 10s.
+	ConnectionTimeout time.Duration `json:"connectionTimeout" default:"10s"`
+	// DialTimeout is the timeout for establishing a single connection to a
+	// broker. Defaults to 10s.
+	DialTimeout time.Duration `json:"dialTimeout" default:"10s"`
+	// RequestTimeout is the time allowed for a request to a broker on top of
+	// any timeout that is part of the request itself (e.g. the produce
+	// timeout). It must be between 1s and 15m. Defaults to 10s.
+	RequestTimeout time.Duration `json:"requestTimeout" default:"10s"`
+	// RetryBackoff is the fixed time to wait between retries of failed
+	// requests. If empty, an exponential backoff with jitter starting at 250ms
+	// and capped at 2.5s is used.
+	RetryBackoff time.Duration `json:"retryBackoff"`
+	// MetadataMaxAge is the maximum age of the cluster metadata before it is
+	// refreshed, at most 1h. Defaults to 5m.
+	MetadataMaxAge time.Duration `json:"metadataMaxAge" default:"5m"`
+
+	// BrokerAddressMap rewrites broker addresses before connecting to them.
+	// Keys are addresses advertised by the brokers (either "host:port" or just
+	// "host"), values are the addresses that should be dialed instead. This
+	// makes it possible to reach a cluster through SSH tunnels, port-forwards
+	// or NAT, when the advertised addresses are not reachable.
+	BrokerAddressMap map[string]string `json:"brokerAddressMap"`
+
+	// ClientOptions contains additional options used to tune the Kafka client.
+	// Supported options are:
+	//   - general: conn.idle.timeout, request.retries, retry.timeout,
+	//     metadata.min.age, broker.max.read.bytes, broker.max.write.bytes
+	//   - producer (destination only): linger, max.buffered.records,
+	//     max.buffered.bytes, max.inflight.per.broker,
+	//     produce.request.timeout, record.retries
+	//   - consumer (source only): fetch.max.bytes, fetch.min.bytes,
+	//     fetch.max.wait, fetch.max.partition.bytes, fetch.isolation.level,
+	//     max.concurrent.fetches, rack
+	//   - consumer group (source only): session.timeout, rebalance.timeout,
+	//     heartbeat.interval, instance.id
+	// Durations are specified as Go durations (e.g. "500ms"). Values are
+	// validated against the limits of the Kafka client, e.g.
+	// max.inflight.per.broker greater than 1 requires acks other than "all".
+	// Options that can be configured with a dedicated parameter are
+	// overridden by it.
+	ClientOptions map[string]string `json:"clientOptions"`
+
 	ConfigSASL
 	ConfigTLS
+	ConfigProxy
 
 	franzClientOpts []kgo.Opt
 }
@@ -52,6 +106,79 @@ func (c Config) Validate() error {
 	if err := c.ConfigTLS.Validate(); err != nil {
 		multierr = append(multierr, err)
 	}
+	if err := c.ConfigProxy.Validate(); err != nil {
+		multierr = append(multierr, err)
+	}
+	if err := c.validateTimeouts(); err != nil {
+		multierr = append(multierr, err)
+	}
+	if err := c.validateBrokerAddressMap(); err != nil {
+		multierr = append(multierr, err)
+	}
+	if err := c.validateClientOptions(); err != nil {
+		multierr = append(multierr, err)
+	}
+
+	return errors.Join(multierr...)
+}
+
+func (c Config) validateTimeouts() error {
+	var multierr []error
+
+	durations := []struct {
+		name string
+		val  time.Duration
+	}{
+		{"connectionTimeout", c.ConnectionTimeout},
+		{"dialTimeout", c.DialTimeout},
+		{"requestTimeout", c.RequestTimeout},
+		{"retryBackoff", c.RetryBackoff},
+		{"metadataMaxAge", c.MetadataMaxAge},
+	}
+	for _, d := range durations {
+		if d.val < 0 {
+			multierr = append(multierr, fmt.Errorf("%q can't be negative", d.name))
+		}
+	}
+
+	if c.RequestTimeout != 0 && (c.RequestTimeout < minRequestTimeout || c.RequestTimeout > maxRequestTimeout) {
+		multierr = append(multierr, fmt.Errorf("%q must be between %v and %v", "requestTimeout", minRequestTimeout, maxRequestTimeout))
+	}
+	if c.MetadataMaxAge > maxMetadataMaxAge {
+		multierr = append(multierr, fmt.Errorf("%q can't be greater than %v", "metadataMaxAge", maxMetadataMaxAge))
+	}
+
+	return errors.Join(multierr...)
+}
+
+func (c Config) validateBrokerAddressMap() error {
+	var multierr []error
+
+	validate := func(addr string) error {
+		if addr == "" {
+			return errors.New("address can't be empty")
+		}
+		if !strings.Contains(addr, ":") {
+			return nil // only host
+		}
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return err
+		}
+		if host == "" {
+			return errors.New("missing host")
+		}
+		return nil
+	}
+
+	for from, to := range c.BrokerAddressMap {
+		if err := validate(from); err != nil {
+			multierr = append(multierr, fmt.Errorf("invalid broker address %q in %q: %w", from, "brokerAddressMap", err))
+		}
+		if err := validate(to); err != nil {
+			multierr = append(multierr, fmt.Errorf("invalid address %q mapped to broker %q in %q: %w", to, from, "brokerAddressMap", err))
+		}
+	}
 
 	return errors.Join(multierr...)
 }
@@ -67,18 +194,17 @@ func (c Config) TryDial(ctx context.Context) error {
 	defer cl.Close()
 
 	start := time.Now()
-	for time.Since(start) < connectionTimeout {
+	for {
 		err = cl.Ping(ctx)
-		if err == nil {
-			break
+		if err == nil || time.Since(start) >= c.ConnectionTimeout {
+			return err
 		}
 		select {
 		case <-ctx.Done():
 			return err
-		case <-time.After(time.Second):
+		case <-time.After(connectionRetryInterval):
 			sdk.Logger(ctx).Warn().Msg("failed to dial broker, trying again...")
 			// ping again
 		}
 	}
-	return err
 }
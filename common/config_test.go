@@ -71,6 +71,95 @@ func TestConfig_Validate(t *testing.T) {
 			},
 		},
 		wantErr: "tls: failed to find any PEM data in certificate input",
+	}, {
+		name: "invalid TLS min version",
+		cfg: Config{
+			ConfigTLS: ConfigTLS{
+				TLSEnabled:    true,
+				TLSMinVersion: "1.4",
+			},
+		},
+		wantErr: `invalid TLS min version "1.4"`,
+	}, {
+		name: "unknown TLS cipher suite",
+		cfg: Config{
+			ConfigTLS: ConfigTLS{
+				TLSEnabled:      true,
+				TLSCipherSuites: []string{"TLS_FOO"},
+			},
+		},
+		wantErr: `unknown TLS cipher suite "TLS_FOO"`,
+	}, {
+		name: "insecure TLS cipher suite",
+		cfg: Config{
+			ConfigTLS: ConfigTLS{
+				TLSEnabled:      true,
+				TLSCipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"},
+			},
+		},
+		wantErr: `TLS cipher suite "TLS_RSA_WITH_RC4_128_SHA" is insecure and not allowed`,
+	}, {
+		name: "invalid TLS pinned public key encoding",
+		cfg: Config{
+			ConfigTLS: ConfigTLS{
+				TLSEnabled:          true,
+				TLSPinnedPublicKeys: []string{"not base64!"},
+			},
+		},
+		wantErr: `invalid pinned public key "not base64!"`,
+	}, {
+		name: "invalid TLS pinned public key length",
+		cfg: Config{
+			ConfigTLS: ConfigTLS{
+				TLSEnabled:          true,
+				TLSPinnedPublicKeys: []string{"sha256/Zm9v"},
+			},
+		},
+		wantErr: "expected a SHA-256 hash (32 bytes), got 3 bytes",
+	}, {
+		name: "negative dial timeout",
+		cfg: Config{
+			DialTimeout: -time.Second,
+		},
+		wantErr: `"dialTimeout" can't be negative`,
+	}, {
+		name: "request timeout too short",
+		cfg: Config{
+			RequestTimeout: time.Millisecond,
+		},
+		wantErr: `"requestTimeout" must be between 1s and 15m0s`,
+	}, {
+		name: "metadata max age too long",
+		cfg: Config{
+			MetadataMaxAge: 2 * time.Hour,
+		},
+		wantErr: `"metadataMaxAge" can't be greater than 1h0m0s`,
+	}, {
+		name: "invalid broker address map",
+		cfg: Config{
+			BrokerAddressMap: map[string]string{
+				"kafka-0.internal:9092": "localhost:19092:1",
+			},
+		},
+		wantErr: `invalid address "localhost:19092:1" mapped to broker "kafka-0.internal:9092"`,
+	}, {
+		name: "unknown client option",
+		cfg: Config{
+			ClientOptions: map[string]string{"foo.bar": "1"},
+		},
+		wantErr: `unknown client option "foo.bar"`,
+	}, {
+		name: "invalid client option value",
+		cfg: Config{
+			ClientOptions: map[string]string{"fetch.max.wait": "soon"},
+		},
+		wantErr: `invalid value for client option "fetch.max.wait"`,
+	}, {
+		name: "negative client option value",
+		cfg: Config{
+			ClientOptions: map[string]string{"max.buffered.records": "-1"},
+		},
+		wantErr: `invalid value for client option "max.buffered.records": value can't be lower than 1`,
 	}}
 
 	for _, tc := range testCases {
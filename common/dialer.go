@@ -0,0 +1,127 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+)
+
+// defaultDialTimeout mirrors the default dial timeout used by franz-go.
+const defaultDialTimeout = time.Second * 10
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// dialer returns a custom dial function if connections to the brokers can't
+// be established by the default franz-go dialer (e.g. when a proxy is
+// configured), otherwise it returns nil.
+func (c Config) dialer() dialFunc {
+	timeout := c.DialTimeout
+	if timeout == 0 {
+		timeout = defaultDialTimeout
+	}
+	netDialer := &net.Dialer{Timeout: timeout}
+
+	proxyDial, err := c.ConfigProxy.proxyDialer(netDialer)
+	if err != nil {
+		// Errors are reported by Validate.
+		return nil
+	}
+	if proxyDial == nil && len(c.BrokerAddressMap) == 0 {
+		// The default dialer is sufficient.
+		return nil
+	}
+
+	dial := dialFunc(netDialer.DialContext)
+	if proxyDial != nil {
+		dial = proxyDial
+	}
+	if len(c.BrokerAddressMap) > 0 {
+		// TLS wraps this dialer, so the server name is still derived from
+		// the advertised address, not the rewritten one.
+		dial = withAddressMap(dial, c.BrokerAddressMap)
+	}
+	if tlsCfg := c.TLS(); tlsCfg != nil {
+		dial = withTLS(dial, tlsCfg)
+	}
+	return withTimeout(dial, timeout)
+}
+
+// withTLS returns a dial function that establishes a TLS session on top of
+// the connection returned by dial.
+func withTLS(dial dialFunc, cfg *tls.Config) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		c := cfg.Clone()
+		if c.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, fmt.Errorf("unable to split host:port for dialing: %w", err)
+			}
+			c.ServerName = host
+		}
+
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, c)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake with %s failed: %w", addr, err)
+		}
+		return tlsConn, nil
+	}
+}
+
+// withAddressMap returns a dial function that rewrites the dialed address
+// based on the address map before dialing. An address is first looked up as
+// "host:port", then by host only, in which case the port is retained unless
+// the mapped address specifies one.
+func withAddressMap(dial dialFunc, addressMap map[string]string) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dial(ctx, network, rewriteAddress(addr, addressMap))
+	}
+}
+
+func rewriteAddress(addr string, addressMap map[string]string) string {
+	if mapped, ok := addressMap[addr]; ok {
+		return mapped
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	mapped, ok := addressMap[host]
+	if !ok {
+		return addr
+	}
+	if _, _, err := net.SplitHostPort(mapped); err == nil {
+		return mapped // mapped address contains a port
+	}
+	return net.JoinHostPort(mapped, port)
+}
+
+// withTimeout returns a dial function that fails if the connection (including
+// any proxy and TLS handshakes) can't be established within the timeout.
+func withTimeout(dial dialFunc, timeout time.Duration) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return dial(ctx, network, addr)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/rs/zerolog"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestRewriteAddress(t *testing.T) {
+	addressMap := map[string]string{
+		"kafka-0.internal:9092": "localhost:19092",
+		"kafka-1.internal":      "localhost",
+		"kafka-2.internal":      "localhost:29092",
+	}
+
+	testCases := []struct {
+		addr string
+		want string
+	}{
+		{addr: "kafka-0.internal:9092", want: "localhost:19092"},
+		{addr: "kafka-0.internal:9093", want: "kafka-0.internal:9093"},
+		{addr: "kafka-1.internal:9092", want: "localhost:9092"},
+		{addr: "kafka-2.internal:9092", want: "localhost:29092"},
+		{addr: "kafka-3.internal:9092", want: "kafka-3.internal:9092"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.addr, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(rewriteAddress(tc.addr, addressMap), tc.want)
+		})
+	}
+}
+
+func TestConfig_BrokerAddressMap(t *testing.T) {
+	is := is.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(1, "test-topic"),
+	)
+	is.NoErr(err)
+	t.Cleanup(cluster.Close)
+	brokerAddr := cluster.ListenAddrs()[0]
+
+	// The forwarder simulates a port-forward, the advertised broker address
+	// is rewritten to the address of the forwarder.
+	fwdAddr, conns := startForwarder(t, brokerAddr)
+
+	cfg := Config{
+		Servers: []string{"kafka.internal:9092"}, // not resolvable
+		BrokerAddressMap: map[string]string{
+			"kafka.internal:9092": fwdAddr,
+			brokerAddr:            fwdAddr,
+		},
+	}
+	is.NoErr(cfg.Validate())
+
+	logger := zerolog.Nop()
+	cl, err := kgo.NewClient(append(cfg.FranzClientOpts(&logger), kgo.DefaultProduceTopic("test-topic"))...)
+	is.NoErr(err)
+	defer cl.Close()
+
+	err = cl.ProduceSync(ctx, &kgo.Record{Value: []byte("foo")}).FirstErr()
+	is.NoErr(err)
+
+	// one connection to the seed broker and at least one to the discovered broker
+	is.True(conns.Load() >= 2)
+}
+
+// startForwarder listens on a random local port and forwards all connections
+// to target.
+func startForwarder(t *testing.T, target string) (string, *atomic.Int32) {
+	var conns atomic.Int32
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	is.New(t).NoErr(err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			client, err := l.Accept()
+			if err != nil {
+				return
+			}
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				client.Close()
+				continue
+			}
+			conns.Add(1)
+			pipe(client, upstream)
+		}
+	}()
+
+	return l.Addr().String(), &conns
+}
@@ -16,6 +16,7 @@ package common
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/twmb/franz-go/pkg/kgo"
@@ -34,6 +35,24 @@ func (c Config) FranzClientOpts(logger *zerolog.Logger) []kgo.Opt {
 		kgo.SeedBrokers(c.Servers...),
 		kgo.ClientID(c.ClientID),
 	}
+	if c.DialTimeout > 0 {
+		opts = append(opts, kgo.DialTimeout(c.DialTimeout))
+	}
+	if c.RequestTimeout > 0 {
+		opts = append(opts, kgo.RequestTimeoutOverhead(c.RequestTimeout))
+	}
+	if c.RetryBackoff > 0 {
+		backoff := c.RetryBackoff
+		opts = append(opts, kgo.RetryBackoffFn(func(int) time.Duration { return backoff }))
+	}
+	if c.MetadataMaxAge > 0 {
+		opts = append(opts, kgo.MetadataMaxAge(c.MetadataMaxAge))
+	}
+	if clientOpts, err := c.clientOptions(ClientAny); err == nil {
+		// errors are reported by Validate, options of specific clients are
+		// added by the source and destination (see ClientOpts)
+		opts = append(opts, clientOpts...)
+	}
 	opts = append(opts, c.franzClientOpts...)
 	if logger.GetLevel() != zerolog.Disabled {
 		opts = append(opts, kgo.WithLogger(franzLogger{logger: logger}))
@@ -41,7 +60,10 @@ func (c Config) FranzClientOpts(logger *zerolog.Logger) []kgo.Opt {
 	if sasl := c.SASL(); sasl != nil {
 		opts = append(opts, kgo.SASL(sasl))
 	}
-	if tls := c.TLS(); tls != nil {
+	if dial := c.dialer(); dial != nil {
+		// TLS is handled by the custom dialer.
+		opts = append(opts, kgo.Dialer(dial))
+	} else if tls := c.TLS(); tls != nil {
 		opts = append(opts, kgo.DialTLSConfig(tls))
 	}
 	return opts
@@ -15,11 +15,18 @@
 package common
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 )
 
+var ErrTLSPinMismatch = errors.New("none of the broker's certificates matches a pinned public key")
+
 type ConfigTLS struct {
 	// TLSEnabled defines whether TLS is needed to communicate with the Kafka cluster.
 	TLSEnabled bool `json:"tls.enabled"`
@@ -33,6 +40,24 @@ type ConfigTLS struct {
 	// chain and host name. If 'true', accepts any certificate presented by the
 	// server and any host name in that certificate.
 	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+	// TLSMinVersion is the minimum TLS version accepted when connecting to
+	// the Kafka cluster.
+	TLSMinVersion string `json:"tls.minVersion" default:"1.2" validate:"inclusion=1.0|1.1|1.2|1.3"`
+	// TLSCipherSuites is a list of cipher suites (e.g.
+	// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256") allowed for TLS 1.0-1.2
+	// connections. If empty, a safe default list is used. Cipher suites are
+	// not configurable in TLS 1.3.
+	TLSCipherSuites []string `json:"tls.cipherSuites"`
+	// TLSServerName is the server name used for SNI and to verify the
+	// hostname on the broker's certificate. Useful when brokers are reached
+	// through a load balancer or a proxy. If empty, the host name of the
+	// dialed address is used.
+	TLSServerName string `json:"tls.serverName"`
+	// TLSPinnedPublicKeys is a list of base64 encoded SHA-256 hashes of the
+	// Subject Public Key Info of certificates that are trusted (optionally
+	// prefixed with "sha256/"). If set, the connection is only established if
+	// at least one certificate presented by the broker matches a pinned hash.
+	TLSPinnedPublicKeys []string `json:"tls.pinnedPublicKeys"`
 }
 
 // Validate executes manual validations beyond what is defined in struct tags.
@@ -71,9 +96,122 @@ func (c ConfigTLS) tls() (*tls.Config, error) {
 		rootCAs.AppendCertsFromPEM([]byte(c.CACert))
 	}
 
+	minVersion, err := c.minVersion()
+	if err != nil {
+		return nil, err
+	}
+	cipherSuites, err := c.cipherSuites()
+	if err != nil {
+		return nil, err
+	}
+	pins, err := c.pinnedPublicKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	var verifyConnection func(tls.ConnectionState) error
+	if len(pins) > 0 {
+		verifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPinnedPublicKeys(cs.PeerCertificates, pins)
+		}
+	}
+
 	return &tls.Config{
 		Certificates:       certificates,
 		RootCAs:            rootCAs,
 		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec // it's the users decision to turn this on
+		MinVersion:         minVersion,
+		CipherSuites:       cipherSuites,
+		ServerName:         c.TLSServerName,
+		VerifyConnection:   verifyConnection,
 	}, nil
 }
+
+func (c ConfigTLS) minVersion() (uint16, error) {
+	switch c.TLSMinVersion {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2", "":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("invalid TLS min version %q", c.TLSMinVersion)
+	}
+}
+
+func (c ConfigTLS) cipherSuites() ([]uint16, error) {
+	if len(c.TLSCipherSuites) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, cs := range tls.CipherSuites() {
+		known[cs.Name] = cs.ID
+	}
+	insecure := make(map[string]bool)
+	for _, cs := range tls.InsecureCipherSuites() {
+		insecure[cs.Name] = true
+	}
+
+	var multierr []error
+	ids := make([]uint16, 0, len(c.TLSCipherSuites))
+	for _, name := range c.TLSCipherSuites {
+		name = strings.TrimSpace(name)
+		id, ok := known[name]
+		switch {
+		case ok:
+			ids = append(ids, id)
+		case insecure[name]:
+			multierr = append(multierr, fmt.Errorf("TLS cipher suite %q is insecure and not allowed", name))
+		default:
+			multierr = append(multierr, fmt.Errorf("unknown TLS cipher suite %q", name))
+		}
+	}
+	if len(multierr) > 0 {
+		return nil, errors.Join(multierr...)
+	}
+	return ids, nil
+}
+
+func (c ConfigTLS) pinnedPublicKeys() ([][]byte, error) {
+	if len(c.TLSPinnedPublicKeys) == 0 {
+		return nil, nil
+	}
+
+	var multierr []error
+	pins := make([][]byte, 0, len(c.TLSPinnedPublicKeys))
+	for _, pin := range c.TLSPinnedPublicKeys {
+		encoded := strings.TrimPrefix(strings.TrimSpace(pin), "sha256/")
+		hash, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			multierr = append(multierr, fmt.Errorf("invalid pinned public key %q: %w", pin, err))
+			continue
+		}
+		if len(hash) != sha256.Size {
+			multierr = append(multierr, fmt.Errorf("invalid pinned public key %q: expected a SHA-256 hash (%d bytes), got %d bytes", pin, sha256.Size, len(hash)))
+			continue
+		}
+		pins = append(pins, hash)
+	}
+	if len(multierr) > 0 {
+		return nil, errors.Join(multierr...)
+	}
+	return pins, nil
+}
+
+// verifyPinnedPublicKeys returns nil if the SPKI hash of any of the supplied
+// certificates matches one of the pinned hashes.
+func verifyPinnedPublicKeys(certs []*x509.Certificate, pins [][]byte) error {
+	for _, cert := range certs {
+		hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		for _, pin := range pins {
+			if bytes.Equal(hash[:], pin) {
+				return nil
+			}
+		}
+	}
+	return ErrTLSPinMismatch
+}
@@ -0,0 +1,127 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestConfigTLS_TLS(t *testing.T) {
+	is := is.New(t)
+
+	cfg := ConfigTLS{
+		TLSEnabled:      true,
+		TLSMinVersion:   "1.3",
+		TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		TLSServerName:   "kafka.internal",
+	}
+
+	got := cfg.TLS()
+	is.True(got != nil)
+	is.Equal(got.MinVersion, uint16(tls.VersionTLS13))
+	is.Equal(got.CipherSuites, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256})
+	is.Equal(got.ServerName, "kafka.internal")
+	is.True(got.VerifyConnection == nil) // no pins configured
+}
+
+func TestConfigTLS_TLS_DefaultMinVersion(t *testing.T) {
+	is := is.New(t)
+
+	got := ConfigTLS{TLSEnabled: true}.TLS()
+	is.True(got != nil)
+	is.Equal(got.MinVersion, uint16(tls.VersionTLS12))
+}
+
+func TestConfigTLS_TLS_PinnedPublicKeys(t *testing.T) {
+	cert := newTestCertificate(t)
+	other := newTestCertificate(t)
+
+	pin := func(c *x509.Certificate) string {
+		hash := sha256.Sum256(c.RawSubjectPublicKeyInfo)
+		return base64.StdEncoding.EncodeToString(hash[:])
+	}
+
+	testCases := []struct {
+		name    string
+		pins    []string
+		wantErr error
+	}{{
+		name: "matching pin",
+		pins: []string{pin(cert)},
+	}, {
+		name: "matching pin with prefix",
+		pins: []string{"sha256/" + pin(cert)},
+	}, {
+		name: "one of multiple pins matches",
+		pins: []string{pin(other), pin(cert)},
+	}, {
+		name:    "no pin matches",
+		pins:    []string{pin(other)},
+		wantErr: ErrTLSPinMismatch,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+
+			cfg := ConfigTLS{
+				TLSEnabled:          true,
+				TLSPinnedPublicKeys: tc.pins,
+			}
+			tlsCfg := cfg.TLS()
+			is.True(tlsCfg != nil)
+			is.True(tlsCfg.VerifyConnection != nil)
+
+			err := tlsCfg.VerifyConnection(tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{cert},
+			})
+			is.True(errors.Is(err, tc.wantErr))
+		})
+	}
+}
+
+func newTestCertificate(t *testing.T) *x509.Certificate {
+	is := is.New(t)
+	is.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	is.NoErr(err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	is.NoErr(err)
+
+	cert, err := x509.ParseCertificate(der)
+	is.NoErr(err)
+	return cert
+}
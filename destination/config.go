@@ -19,6 +19,7 @@ package destination
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"text/template"
@@ -27,12 +28,17 @@ import (
 	"github.com/Masterminds/sprig/v3"
 	"github.com/conduitio/conduit-commons/opencdc"
 	"github.com/conduitio/conduit-connector-kafka/common"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
 var (
-	topicRegex     = regexp.MustCompile(`^[a-zA-Z0-9._\-]+$`)
-	maxTopicLength = 249
+	topicRegex            = regexp.MustCompile(`^[a-zA-Z0-9._\-]+$`)
+	topicIllegalCharRegex = regexp.MustCompile(`[^a-zA-Z0-9._\-]`)
+	maxTopicLength        = 249
+	// topicCacheSize is the number of rendered topics for which the
+	// validation result is cached.
+	topicCacheSize = 1000
 )
 
 type Config struct {
@@ -42,6 +48,11 @@ type Config struct {
 	// that will be executed for each record to determine the topic. By default,
 	// the topic is the value of the `opencdc.collection` metadata field.
 	Topic string `json:"topic" default:"{{ index .Metadata \"opencdc.collection\" }}"`
+	// TopicCharReplacement is used to replace characters that are not allowed
+	// in Kafka topic names (anything other than ASCII alphanumerics, '.', '_'
+	// and '-') in topics rendered using a template. If empty, records with an
+	// invalid rendered topic fail.
+	TopicCharReplacement string `json:"topicCharReplacement"`
 	// Acks defines the number of acknowledges from partition replicas required
 	// before receiving a response to a produce request.
 	// None = fire and forget, one = wait for the leader to acknowledge the
@@ -54,6 +65,71 @@ type Config struct {
 	// BatchBytes limits the maximum size of a request in bytes before being
 	// sent to a partition. This mirrors Kafka's max.message.bytes.
 	BatchBytes int32 `json:"batchBytes" default:"1000012"`
+	// Ordering defines the ordering guarantees of produced records.
+	// None = no additional guarantees, if idempotent writes are disabled (i.e.
+	// acks is not "all") retries can reorder records within a partition.
+	// Per-partition = records are written in order within a partition even
+	// when produce requests are retried. Strict = like per-partition, but
+	// records are additionally produced one by one and no record is written
+	// after a record that failed, which preserves the order across retries of
+	// the whole batch at the cost of throughput.
+	Ordering string `json:"ordering" default:"none" validate:"inclusion=none|per-partition|strict"`
+	// Linger is how long a partition waits for more records before a produce
+	// request is sent. Lingering can produce larger batches at the cost of
+	// latency. By default, requests are sent as soon as possible.
+	Linger time.Duration `json:"linger"`
+	// MaxBufferedRecords is the maximum number of records buffered by the
+	// producer before producing blocks. If 0, the default of 10000 is used.
+	MaxBufferedRecords int `json:"maxBufferedRecords"`
+	// MaxBufferedBytes is the maximum number of bytes buffered by the producer
+	// before producing blocks. Records larger than this limit fail
+	// immediately. If 0, the number of buffered bytes is not limited.
+	MaxBufferedBytes int `json:"maxBufferedBytes"`
+	// MaxInflightRequests is the maximum number of produce requests in flight
+	// per broker. Values greater than 1 are only allowed if idempotent writes
+	// are disabled (i.e. acks is not "all") and ordering is "none", as retries
+	// can reorder records. If 0, the default of 1 is used.
+	MaxInflightRequests int `json:"maxInflightRequests"`
+	// PrepareConcurrency is the number of goroutines used to prepare records
+	// (render the topic, encode the key and value) of large batches. If 0,
+	// the number of CPUs is used, 1 disables concurrent preparation.
+	PrepareConcurrency int `json:"prepareConcurrency"`
+	// OversizedRecordPolicy defines how records are handled that exceed
+	// batchBytes. Fail = the whole batch is rejected before any record is
+	// written. Skip = the record is dropped and a warning is logged. DLQ =
+	// records preceding the oversized record are written and the oversized
+	// record is nacked, so Conduit can route it to the dead-letter queue (note
+	// that records following it in the same batch are nacked as well). Chunk =
+	// the value is split into ordered chunks with reassembly headers, which
+	// are reassembled by the source connector.
+	OversizedRecordPolicy string `json:"oversizedRecordPolicy" default:"fail" validate:"inclusion=fail|skip|dlq|chunk"`
+	// TopicOverrides contains settings overriding the global settings for
+	// specific topics. The key is a topic name or a glob pattern (e.g.
+	// "logs.*"), the value is a comma separated list of settings, e.g.
+	// "compression=zstd,acks=one". Supported settings are compression, acks,
+	// batchBytes, partitioner (default, sticky-key, round-robin, least-backup)
+	// and keyFormat (raw, kafka-connect). Topic names take precedence over
+	// patterns, longer patterns take precedence over shorter ones.
+	TopicOverrides map[string]string `json:"topicOverrides"`
+	// KafkaConnectEncoding defines the encoding of keys in the Kafka Connect
+	// format (used with the Debezium record format or keyFormat=kafka-connect)
+	// and of values in the Debezium record format. JSON = JSON with an
+	// embedded schema. Avro = Avro with the schema derived from the Kafka
+	// Connect schema. Protobuf = the Protobuf message derived from the Kafka
+	// Connect schema. Avro and Protobuf schemas are registered in the schema
+	// registry under the subjects <topic>-key and <topic>-value, the data is
+	// written in the Confluent wire format.
+	KafkaConnectEncoding string `json:"kafkaConnectEncoding" default:"json" validate:"inclusion=json|avro|protobuf"`
+	// SchemaRegistryURL is the URL of a Confluent compatible schema registry,
+	// used to register the schemas of keys and values encoded as Avro or
+	// Protobuf. Required if kafkaConnectEncoding is avro or protobuf.
+	SchemaRegistryURL string `json:"schemaRegistry.url"`
+	// SchemaRegistryUsername is the username used to authenticate to the
+	// schema registry with basic authentication.
+	SchemaRegistryUsername string `json:"schemaRegistry.username"`
+	// SchemaRegistryPassword is the password used to authenticate to the
+	// schema registry with basic authentication.
+	SchemaRegistryPassword string `json:"schemaRegistry.password"`
 
 	// useKafkaConnectKeyFormat defines if the produced key in a kafka message
 	// should be in the kafka connect format (i.e. JSON with schema).
@@ -81,6 +157,17 @@ func (c Config) RequiredAcks() kgo.Acks {
 	}
 }
 
+// PerPartitionOrdering returns true if records need to be written in order
+// within a partition.
+func (c Config) PerPartitionOrdering() bool {
+	return c.Ordering == "per-partition" || c.Ordering == "strict"
+}
+
+// StrictOrdering returns true if records need to be produced one by one.
+func (c Config) StrictOrdering() bool {
+	return c.Ordering == "strict"
+}
+
 func (c Config) CompressionCodecs() []kgo.CompressionCodec {
 	switch c.Compression {
 	case "none":
@@ -107,12 +194,112 @@ func (c Config) Validate() error {
 	if err != nil {
 		multierr = append(multierr, err)
 	}
+	err = c.ValidateClientOptionsFor(common.ClientProducer)
+	if err != nil {
+		multierr = append(multierr, err)
+	}
 
 	_, _, err = c.ParseTopic()
 	if err != nil {
 		multierr = append(multierr, err)
 	}
 
+	if c.TopicCharReplacement != "" && !topicRegex.MatchString(c.TopicCharReplacement) {
+		multierr = append(multierr, fmt.Errorf("topicCharReplacement %q contains characters not allowed in topic names", c.TopicCharReplacement))
+	}
+
+	err = c.validateProducerLimits()
+	if err != nil {
+		multierr = append(multierr, err)
+	}
+
+	err = c.validateTopicOverrides()
+	if err != nil {
+		multierr = append(multierr, err)
+	}
+
+	err = c.validateSchemaRegistry()
+	if err != nil {
+		multierr = append(multierr, err)
+	}
+
+	return errors.Join(multierr...)
+}
+
+func (c Config) validateSchemaRegistry() error {
+	var multierr []error
+	if c.SchemaRegistryURL != "" {
+		u, err := url.Parse(c.SchemaRegistryURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			multierr = append(multierr, fmt.Errorf("invalid schema registry URL %q, expected an http or https URL", c.SchemaRegistryURL))
+		}
+	}
+	if c.SchemaRegistryURL == "" && c.SchemaRegistryUsername != "" {
+		multierr = append(multierr, errors.New(`"schemaRegistry.username" requires "schemaRegistry.url" to be set`))
+	}
+	if c.SchemaRegistryURL == "" && (c.KafkaConnectEncoding == "avro" || c.KafkaConnectEncoding == "protobuf") {
+		multierr = append(multierr, fmt.Errorf(`kafkaConnectEncoding %q requires "schemaRegistry.url" to be set`, c.KafkaConnectEncoding))
+	}
+	return errors.Join(multierr...)
+}
+
+func (c Config) validateTopicOverrides() error {
+	overrides, err := c.parseTopicOverrides()
+	if err != nil {
+		return err
+	}
+
+	var multierr []error
+	for _, o := range overrides {
+		if o.acks == "" && o.batchBytes == 0 {
+			continue // the global settings are already validated
+		}
+		err := o.applyTo(c).validateProducerLimits()
+		if err != nil {
+			multierr = append(multierr, fmt.Errorf("invalid topic override %q: %w", o.pattern, err))
+		}
+	}
+	return errors.Join(multierr...)
+}
+
+func (c Config) validateProducerLimits() error {
+	var multierr []error
+
+	if c.Linger < 0 {
+		multierr = append(multierr, errors.New("linger can't be negative"))
+	}
+	if c.MaxBufferedRecords < 0 {
+		multierr = append(multierr, errors.New("maxBufferedRecords can't be negative"))
+	}
+	if c.MaxBufferedBytes < 0 {
+		multierr = append(multierr, errors.New("maxBufferedBytes can't be negative"))
+	}
+	if c.PrepareConcurrency < 0 {
+		multierr = append(multierr, errors.New("prepareConcurrency can't be negative"))
+	}
+	if c.MaxInflightRequests < 0 {
+		multierr = append(multierr, errors.New("maxInflightRequests can't be negative"))
+	}
+	if c.MaxInflightRequests == 0 && c.RequiredAcks() == kgo.AllISRAcks() {
+		// the client option is overridden by maxInflightRequests, with other
+		// acks it is overridden if the ordering requires it
+		inflight, err := c.ClientOptionInt("max.inflight.per.broker", 1)
+		if err == nil && inflight > 1 {
+			multierr = append(multierr, errors.New(`client option "max.inflight.per.broker" greater than 1 requires idempotent writes to be disabled, set "acks" to "none" or "one"`))
+		}
+	}
+	if err := c.ValidateBrokerMaxWriteBytes(int(c.BatchBytes)); err != nil {
+		multierr = append(multierr, err)
+	}
+	if c.MaxInflightRequests > 1 {
+		if c.RequiredAcks() == kgo.AllISRAcks() {
+			multierr = append(multierr, errors.New(`maxInflightRequests greater than 1 requires idempotent writes to be disabled, set "acks" to "none" or "one"`))
+		}
+		if c.PerPartitionOrdering() {
+			multierr = append(multierr, fmt.Errorf(`maxInflightRequests greater than 1 can't be used with ordering %q, retries could reorder records`, c.Ordering))
+		}
+	}
+
 	return errors.Join(multierr...)
 }
 
@@ -141,21 +328,54 @@ func (c Config) ParseTopic() (topic string, f TopicFn, err error) {
 		return "", nil, fmt.Errorf("topic is neither a valid static Kafka topic nor a valid Go template: %w", err)
 	}
 
-	// The topic is a valid template, return TopicFn.
-	var sb strings.Builder
+	// The topic is a valid template, return TopicFn. The validation result of
+	// rendered topics is cached, as most records are routed to a small set of
+	// topics.
+	topics, err := lru.New[string, topicResult](topicCacheSize)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create topic cache: %w", err)
+	}
 	return "", func(r opencdc.Record) (string, error) {
-		sb.Reset()
+		var sb strings.Builder
 		if err := t.Execute(&sb, r); err != nil {
 			return "", fmt.Errorf("failed to execute topic template: %w", err)
 		}
-		topic := sb.String()
-		if topic == "" {
+		rendered := sb.String()
+		if rendered == "" {
 			return "", fmt.Errorf(
 				"topic not found on record %s using template %s",
 				string(r.Key.Bytes()), c.Topic,
 			)
 		}
 
-		return topic, nil
+		if res, ok := topics.Get(rendered); ok {
+			return res.topic, res.err
+		}
+		topic, err := c.sanitizeTopic(rendered)
+		topics.Add(rendered, topicResult{topic: topic, err: err})
+		return topic, err
 	}, nil
 }
+
+type topicResult struct {
+	topic string
+	err   error
+}
+
+// sanitizeTopic replaces illegal characters in a rendered topic (if
+// configured) and validates the result.
+func (c Config) sanitizeTopic(topic string) (string, error) {
+	if c.TopicCharReplacement != "" {
+		topic = topicIllegalCharRegex.ReplaceAllLiteralString(topic, c.TopicCharReplacement)
+	}
+
+	switch {
+	case !topicRegex.MatchString(topic):
+		return "", fmt.Errorf("rendered topic %q contains characters not allowed in topic names, consider setting topicCharReplacement", topic)
+	case len(topic) > maxTopicLength:
+		return "", fmt.Errorf("rendered topic %q is too long, maximum length is %d", topic, maxTopicLength)
+	case topic == "." || topic == "..":
+		return "", fmt.Errorf("rendered topic %q is not a valid topic name", topic)
+	}
+	return topic, nil
+}
@@ -15,10 +15,15 @@
 package destination
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-kafka/common"
 	"github.com/matryer/is"
 )
 
@@ -67,6 +72,144 @@ func TestConfig_Validate(t *testing.T) {
 		config: Config{
 			Topic: "{{ .Metadata.foo }}",
 		},
+	}, {
+		name: "valid producer limits",
+		config: Config{
+			Topic:               "foo",
+			Acks:                "one",
+			Linger:              5 * time.Millisecond,
+			MaxBufferedRecords:  100,
+			MaxBufferedBytes:    1 << 20,
+			MaxInflightRequests: 5,
+		},
+	}, {
+		name: "negative linger",
+		config: Config{
+			Topic:  "foo",
+			Linger: -time.Millisecond,
+		},
+		wantErr: "linger can't be negative",
+	}, {
+		name: "negative maxBufferedRecords",
+		config: Config{
+			Topic:              "foo",
+			MaxBufferedRecords: -1,
+		},
+		wantErr: "maxBufferedRecords can't be negative",
+	}, {
+		name: "maxInflightRequests with idempotent writes",
+		config: Config{
+			Topic:               "foo",
+			Acks:                "all",
+			MaxInflightRequests: 2,
+		},
+		wantErr: "maxInflightRequests greater than 1 requires idempotent writes to be disabled",
+	}, {
+		name: "maxInflightRequests with ordering",
+		config: Config{
+			Topic:               "foo",
+			Acks:                "one",
+			Ordering:            "per-partition",
+			MaxInflightRequests: 2,
+		},
+		wantErr: `maxInflightRequests greater than 1 can't be used with ordering "per-partition"`,
+	}, {
+		name: "valid topic overrides",
+		config: Config{
+			Topic:          "foo",
+			TopicOverrides: map[string]string{"logs.*": "compression=zstd,acks=one"},
+		},
+	}, {
+		name: "invalid topic override",
+		config: Config{
+			Topic:          "foo",
+			TopicOverrides: map[string]string{"logs.*": "compression=brotli"},
+		},
+		wantErr: `invalid topic override "logs.*"`,
+	}, {
+		name: "topic override with idempotent writes and maxInflightRequests",
+		config: Config{
+			Topic:               "foo",
+			Acks:                "one",
+			MaxInflightRequests: 2,
+			TopicOverrides:      map[string]string{"logs.*": "acks=all"},
+		},
+		wantErr: `invalid topic override "logs.*": maxInflightRequests greater than 1 requires idempotent writes to be disabled`,
+	}, {
+		name: "client option max.inflight.per.broker with idempotent writes",
+		config: Config{
+			Config: common.Config{ClientOptions: map[string]string{"max.inflight.per.broker": "5"}},
+			Topic:  "foo",
+			Acks:   "all",
+		},
+		wantErr: `client option "max.inflight.per.broker" greater than 1 requires idempotent writes to be disabled`,
+	}, {
+		name: "client option max.inflight.per.broker without idempotent writes",
+		config: Config{
+			Config: common.Config{ClientOptions: map[string]string{"max.inflight.per.broker": "5"}},
+			Topic:  "foo",
+			Acks:   "one",
+		},
+	}, {
+		name: "client option max.inflight.per.broker overridden by maxInflightRequests",
+		config: Config{
+			Config:              common.Config{ClientOptions: map[string]string{"max.inflight.per.broker": "5"}},
+			Topic:               "foo",
+			Acks:                "all",
+			MaxInflightRequests: 1,
+		},
+	}, {
+		name: "consumer client option",
+		config: Config{
+			Config: common.Config{ClientOptions: map[string]string{"fetch.max.wait": "1s"}},
+			Topic:  "foo",
+		},
+		wantErr: `client option "fetch.max.wait" only applies to the consumer, it can't be used in the producer`,
+	}, {
+		name: "client option broker.max.write.bytes lower than batchBytes",
+		config: Config{
+			Config:     common.Config{ClientOptions: map[string]string{"broker.max.write.bytes": "1024"}},
+			Topic:      "foo",
+			BatchBytes: 2048,
+		},
+		wantErr: `client option "broker.max.write.bytes" (1024) can't be lower than the maximum record batch size (2048)`,
+	}, {
+		name: "client option broker.max.write.bytes lower than batchBytes of topic override",
+		config: Config{
+			Config:         common.Config{ClientOptions: map[string]string{"broker.max.write.bytes": "1024"}},
+			Topic:          "foo",
+			BatchBytes:     512,
+			TopicOverrides: map[string]string{"logs.*": "batchBytes=2048"},
+		},
+		wantErr: `invalid topic override "logs.*": client option "broker.max.write.bytes" (1024) can't be lower than the maximum record batch size (2048)`,
+	}, {
+		name: "invalid topic char replacement",
+		config: Config{
+			Topic:                "{{ .Metadata.foo }}",
+			TopicCharReplacement: "/",
+		},
+		wantErr: `topicCharReplacement "/" contains characters not allowed in topic names`,
+	}, {
+		name: "avro with schema registry",
+		config: Config{
+			Topic:                "foo",
+			KafkaConnectEncoding: "avro",
+			SchemaRegistryURL:    "http://localhost:8081",
+		},
+	}, {
+		name: "protobuf without schema registry",
+		config: Config{
+			Topic:                "foo",
+			KafkaConnectEncoding: "protobuf",
+		},
+		wantErr: `kafkaConnectEncoding "protobuf" requires "schemaRegistry.url" to be set`,
+	}, {
+		name: "invalid schema registry URL",
+		config: Config{
+			Topic:             "foo",
+			SchemaRegistryURL: "localhost:8081",
+		},
+		wantErr: `invalid schema registry URL "localhost:8081"`,
 	}}
 
 	for _, tc := range testCases {
@@ -109,3 +252,94 @@ func TestConfig_ParseTopic_DoesErrorOnTopicNotFound(t *testing.T) {
 
 	is.Equal(topic, "")
 }
+
+func TestConfig_ParseTopic_ValidatesRenderedTopic(t *testing.T) {
+	testCases := []struct {
+		name        string
+		topic       string
+		replacement string
+		want        string
+		wantErr     string
+	}{{
+		name:  "valid topic",
+		topic: "orders.eu-1_a",
+		want:  "orders.eu-1_a",
+	}, {
+		name:    "illegal characters",
+		topic:   "orders/eu west",
+		wantErr: `rendered topic "orders/eu west" contains characters not allowed in topic names`,
+	}, {
+		name:        "illegal characters replaced",
+		topic:       "orders/eu west",
+		replacement: "_",
+		want:        "orders_eu_west",
+	}, {
+		name:        "non-ASCII characters replaced",
+		topic:       "bestellungen-größe",
+		replacement: "-",
+		want:        "bestellungen-gr--e",
+	}, {
+		name:    "too long",
+		topic:   strings.Repeat("a", 250),
+		wantErr: "is too long, maximum length is 249",
+	}, {
+		name:    "dot dot",
+		topic:   "..",
+		wantErr: `rendered topic ".." is not a valid topic name`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+
+			cfg := Config{
+				Topic:                `{{ index .Metadata "topic" }}`,
+				TopicCharReplacement: tc.replacement,
+			}
+			_, getTopic, err := cfg.ParseTopic()
+			is.NoErr(err)
+
+			rec := opencdc.Record{Metadata: map[string]string{"topic": tc.topic}}
+			// the second call returns the cached result
+			for range 2 {
+				got, err := getTopic(rec)
+				if tc.wantErr != "" {
+					is.True(err != nil)
+					is.True(strings.Contains(err.Error(), tc.wantErr))
+					continue
+				}
+				is.NoErr(err)
+				is.Equal(got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfig_ParseTopic_Concurrent(t *testing.T) {
+	is := is.New(t)
+
+	cfg := Config{Topic: `topic-{{ index .Metadata "n" }}`}
+	_, getTopic, err := cfg.ParseTopic()
+	is.NoErr(err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := strconv.Itoa(i % 10)
+			got, err := getTopic(opencdc.Record{Metadata: map[string]string{"n": n}})
+			if err == nil && got != "topic-"+n {
+				err = fmt.Errorf("expected topic-%s, got %s", n, got)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		is.NoErr(err)
+	}
+}
@@ -16,24 +16,93 @@ package destination
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
 
 	"github.com/conduitio/conduit-commons/csync"
 	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-kafka/common"
 	sdk "github.com/conduitio/conduit-connector-sdk"
 	"github.com/conduitio/conduit-connector-sdk/kafkaconnect"
 	"github.com/goccy/go-json"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
 type FranzProducer struct {
+	// client is the client used for topics without a topic override that
+	// requires different client settings.
 	client     *kgo.Client
 	keyEncoder dataEncoder
+	// valueEncoder re-encodes the serialized record, if nil the serialized
+	// record is used as the value.
+	valueEncoder dataEncoder
+	// schemas is used to look up schemas attached to records, which are used
+	// by encoders implementing schemaEncoder. If nil, the schema is always
+	// inferred from the data.
+	schemas *connectSchemas
+	// registry registers the schemas of keys and values encoded as Avro or
+	// Protobuf. It is nil if no schema registry is configured.
+	registry *registryClient
 
 	// getTopic is a function that returns the topic for a record. If nil, the
-	// producer will use the default topic. This function is not safe for
+	// producer will use the default topic. This function is safe for
 	// concurrent use.
 	getTopic func(opencdc.Record) (string, error)
+	// topic is the default topic.
+	topic string
+
+	// strictOrdering makes the producer produce records one by one and stop
+	// at the first failure.
+	strictOrdering bool
+	// prepareConcurrency is the maximum number of goroutines preparing
+	// records of a single batch.
+	prepareConcurrency int
+
+	// maxRecordBytes is the maximum size of a record, records exceeding it
+	// are handled according to oversizedRecordPolicy.
+	maxRecordBytes        int
+	oversizedRecordPolicy string
+
+	// overrides contains the settings of topic overrides, sorted by
+	// precedence.
+	overrides []topicOverrideSettings
+	// settingsCache contains the resolved settings of recently seen topics.
+	settingsCache *lru.Cache[string, topicSettings]
+	// overrideClients contains the clients created for topic overrides.
+	overrideClients []*kgo.Client
+}
+
+// topicSettings contains the settings used to produce records to a topic.
+type topicSettings struct {
+	client         *kgo.Client
+	keyEncoder     dataEncoder
+	maxRecordBytes int
+}
+
+type topicOverrideSettings struct {
+	topicOverride
+	topicSettings
+}
+
+// clientSettings contains the settings that require a separate client when
+// overridden for a topic.
+type clientSettings struct {
+	compression string
+	acks        string
+	batchBytes  int32
+}
+
+func clientSettingsOf(cfg Config) clientSettings {
+	return clientSettings{
+		compression: cfg.Compression,
+		acks:        cfg.Acks,
+		batchBytes:  cfg.BatchBytes,
+	}
 }
 
 var _ Producer = (*FranzProducer)(nil)
@@ -44,8 +113,110 @@ func NewFranzProducer(ctx context.Context, cfg Config) (*FranzProducer, error) {
 		// Unlikely to happen, as the topic is validated in the config.
 		return nil, fmt.Errorf("failed to parse topic: %w", err)
 	}
+	overrides, err := cfg.parseTopicOverrides()
+	if err != nil {
+		// Unlikely to happen, as the overrides are validated in the config.
+		return nil, fmt.Errorf("failed to parse topic overrides: %w", err)
+	}
+
+	settingsCache, err := lru.New[string, topicSettings](topicCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create topic settings cache: %w", err)
+	}
+
+	kcEncoder, err := newKafkaConnectEncoder(cfg.KafkaConnectEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka connect encoder: %w", err)
+	}
+
+	cl, err := newProducerClient(ctx, cfg, topic, overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyEncoder dataEncoder = bytesEncoder{}
+	var valueEncoder dataEncoder
+	if cfg.useKafkaConnectKeyFormat {
+		keyEncoder = kcEncoder
+		// records are serialized as kafka connect envelopes in JSON
+		valueEncoder = kafkaConnectValueEncoder{kcEncoder}
+	}
+
+	schemas, err := newConnectSchemas()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema cache: %w", err)
+	}
+
+	var registry *registryClient
+	if cfg.SchemaRegistryURL != "" {
+		registry, err = newRegistryClient(cfg.SchemaRegistryURL, cfg.SchemaRegistryUsername, cfg.SchemaRegistryPassword, cfg.RequestTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create schema registry client: %w", err)
+		}
+	}
+
+	if cfg.PrepareConcurrency == 0 {
+		cfg.PrepareConcurrency = runtime.GOMAXPROCS(0)
+	}
+
+	p := &FranzProducer{
+		client:         cl,
+		keyEncoder:     keyEncoder,
+		valueEncoder:   valueEncoder,
+		schemas:        schemas,
+		registry:       registry,
+		getTopic:       topicFn,
+		topic:          topic,
+		strictOrdering: cfg.StrictOrdering(),
+
+		prepareConcurrency: cfg.PrepareConcurrency,
+
+		maxRecordBytes:        int(cfg.BatchBytes),
+		oversizedRecordPolicy: cfg.OversizedRecordPolicy,
+
+		settingsCache: settingsCache,
+	}
+
+	// Overrides of client settings require a separate client, topics with the
+	// same client settings share a client.
+	clients := map[clientSettings]*kgo.Client{clientSettingsOf(cfg): cl}
+	for _, o := range overrides {
+		ocfg := o.applyTo(cfg)
+		ocl, ok := clients[clientSettingsOf(ocfg)]
+		if !ok {
+			ocl, err = newProducerClient(ctx, ocfg, topic, overrides)
+			if err != nil {
+				_ = p.Close(ctx)
+				return nil, fmt.Errorf("topic override %q: %w", o.pattern, err)
+			}
+			clients[clientSettingsOf(ocfg)] = ocl
+			p.overrideClients = append(p.overrideClients, ocl)
+		}
+
+		okeyEncoder := keyEncoder
+		switch o.keyFormat {
+		case "raw":
+			okeyEncoder = bytesEncoder{}
+		case "kafka-connect":
+			okeyEncoder = kcEncoder
+		}
 
+		p.overrides = append(p.overrides, topicOverrideSettings{
+			topicOverride: o,
+			topicSettings: topicSettings{
+				client:         ocl,
+				keyEncoder:     okeyEncoder,
+				maxRecordBytes: int(ocfg.BatchBytes),
+			},
+		})
+	}
+
+	return p, nil
+}
+
+func newProducerClient(ctx context.Context, cfg Config, topic string, overrides []topicOverride) (*kgo.Client, error) {
 	opts := cfg.FranzClientOpts(sdk.Logger(ctx))
+	opts = append(opts, cfg.ClientOpts(common.ClientProducer)...)
 	opts = append(opts, []kgo.Opt{
 		kgo.AllowAutoTopicCreation(),
 		kgo.RecordDeliveryTimeout(cfg.DeliveryTimeout),
@@ -55,135 +226,451 @@ func NewFranzProducer(ctx context.Context, cfg Config) (*FranzProducer, error) {
 		kgo.DefaultProduceTopic(topic),
 	}...)
 
+	// Only override the franz-go defaults (and client options) if the
+	// parameters are set explicitly.
+	if cfg.Linger > 0 {
+		opts = append(opts, kgo.ProducerLinger(cfg.Linger))
+	}
+	if cfg.MaxBufferedRecords > 0 {
+		opts = append(opts, kgo.MaxBufferedRecords(cfg.MaxBufferedRecords))
+	}
+	if cfg.MaxBufferedBytes > 0 {
+		opts = append(opts, kgo.MaxBufferedBytes(cfg.MaxBufferedBytes))
+	}
+	if cfg.MaxInflightRequests > 0 {
+		opts = append(opts, kgo.MaxProduceRequestsInflightPerBroker(cfg.MaxInflightRequests))
+	}
+	switch {
+	case cfg.OversizedRecordPolicy == "chunk":
+		opts = append(opts, kgo.RecordPartitioner(chunkPartitioner{partitioner: topicPartitioner{overrides: overrides}}))
+	case slices.ContainsFunc(overrides, func(o topicOverride) bool { return o.partitioner != "" }):
+		opts = append(opts, kgo.RecordPartitioner(topicPartitioner{overrides: overrides}))
+	}
+
 	if cfg.RequiredAcks() != kgo.AllISRAcks() {
 		sdk.Logger(ctx).Warn().Msgf("disabling idempotent writes because \"acks\" is set to %v", cfg.Acks)
 		opts = append(opts, kgo.DisableIdempotentWrite())
+		if cfg.PerPartitionOrdering() {
+			// Without idempotent writes only a single in-flight request per
+			// broker guarantees that retries don't reorder records. This
+			// overrides any value set in the client options.
+			opts = append(opts, kgo.MaxProduceRequestsInflightPerBroker(1))
+		}
 	}
 
 	cl, err := kgo.NewClient(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kafka client: %w", err)
 	}
-
-	var keyEncoder dataEncoder = bytesEncoder{}
-	if cfg.useKafkaConnectKeyFormat {
-		keyEncoder = kafkaConnectEncoder{}
-	}
-
-	return &FranzProducer{
-		client:     cl,
-		keyEncoder: keyEncoder,
-		getTopic:   topicFn,
-	}, nil
+	return cl, nil
 }
 
 func (p *FranzProducer) Produce(ctx context.Context, records []opencdc.Record) (int, error) {
 	if len(records) == 1 {
 		// Fast path for a single record.
-		rec, err := p.prepareRecord(records[0])
+		pr, err := p.prepareRecords(ctx, records[0])
 		if err != nil {
 			return 0, fmt.Errorf("failed to prepare record: %w", err)
 		}
-		_, err = p.client.ProduceSync(ctx, rec).First()
+		err = pr.handleProduceError(ctx, pr.client.ProduceSync(ctx, pr.records...).FirstErr())
 		if err != nil {
 			return 0, fmt.Errorf("failed to produce record: %w", err)
 		}
 		return 1, nil
 	}
 
+	batch, prepareErr := p.prepareBatch(ctx, records)
+	if prepareErr != nil && p.oversizedRecordPolicy == "fail" && errors.Is(prepareErr, ErrRecordTooLarge) {
+		// Reject the whole batch before anything is written.
+		return 0, prepareErr
+	}
+
+	if p.strictOrdering {
+		return p.produceSequentially(ctx, batch, prepareErr)
+	}
+
 	var (
-		wg              csync.WaitGroup
-		results         = make([]error, 0, len(records))
-		rec             *kgo.Record
-		prepareErr      error
-		prepareErrIndex = -1
+		wg      csync.WaitGroup
+		results = newProduceResults(len(batch))
 	)
 
-	for i, r := range records {
-		rec, prepareErr = p.prepareRecord(r)
-		if prepareErr != nil {
-			prepareErrIndex = i
-			prepareErr = fmt.Errorf("failed to prepare record: %w", prepareErr)
-			break
+	for i, pr := range batch {
+		results.SetParts(i, len(pr.records))
+		for _, rec := range pr.records {
+			wg.Add(1)
+			pr.client.Produce(
+				ctx,
+				rec,
+				func(_ *kgo.Record, err error) {
+					results.Set(i, pr.handleProduceError(ctx, err))
+					wg.Done()
+				},
+			)
 		}
-
-		wg.Add(1)
-		p.client.Produce(
-			ctx,
-			rec,
-			func(_ *kgo.Record, err error) {
-				results = append(results, err)
-				wg.Done()
-			},
-		)
 	}
 
 	err := wg.Wait(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to wait for all records to be produced: %w", err)
+		// Some records might still be in flight, report only the records that
+		// were acknowledged so far.
+		n, _ := results.AckedPrefix(len(batch))
+		return n, fmt.Errorf("failed to wait for all records to be produced: %w", err)
+	}
+
+	n, err := results.AckedPrefix(len(batch))
+	if err != nil {
+		return n, fmt.Errorf("failed to produce record %d: %w", n, err)
 	}
 
-	for i, err := range results {
+	if prepareErr != nil {
+		// We failed to prepare a record, all records before it were produced.
+		return len(batch), prepareErr
+	}
+
+	return len(batch), nil
+}
+
+// produceSequentially produces records one by one, each record is only sent
+// after the previous one was acknowledged. It stops at the first failure.
+func (p *FranzProducer) produceSequentially(ctx context.Context, batch []preparedRecord, prepareErr error) (int, error) {
+	for i, pr := range batch {
+		err := pr.handleProduceError(ctx, pr.client.ProduceSync(ctx, pr.records...).FirstErr())
 		if err != nil {
-			return i, fmt.Errorf("failed to produce record %v: %w", i, err)
+			return i, fmt.Errorf("failed to produce record %d: %w", i, err)
 		}
 	}
+	return len(batch), prepareErr
+}
 
-	if prepareErr != nil {
-		// We failed to prepare a record, return the error and the index of the
-		// record that failed.
-		return prepareErrIndex, prepareErr
+// preparedRecord contains the Kafka records that need to be produced for a
+// single OpenCDC record, together with the settings of the topic.
+type preparedRecord struct {
+	topicSettings
+	records []*kgo.Record
+	policy  string
+}
+
+// minRecordsPerPrepareWorker is the minimum number of records in a batch per
+// goroutine preparing records, so small batches are prepared without the
+// overhead of starting goroutines.
+const minRecordsPerPrepareWorker = 32
+
+// prepareBatch prepares the Kafka records for all records. It stops at the
+// first record that can't be prepared and returns the records prepared so far
+// together with the error. Large batches are prepared concurrently.
+func (p *FranzProducer) prepareBatch(ctx context.Context, records []opencdc.Record) ([]preparedRecord, error) {
+	workers := min(p.prepareConcurrency, len(records)/minRecordsPerPrepareWorker)
+	if workers <= 1 {
+		batch := make([]preparedRecord, 0, len(records))
+		for i, r := range records {
+			pr, err := p.prepareRecords(ctx, r)
+			if err != nil {
+				return batch, fmt.Errorf("failed to prepare record %d: %w", i, err)
+			}
+			batch = append(batch, pr)
+		}
+		return batch, nil
+	}
+
+	var (
+		wg    sync.WaitGroup
+		batch = make([]preparedRecord, len(records))
+		errs  = make([]error, len(records))
+		// next is the index of the next record to prepare, records are taken
+		// in order, so all records before a failed record are prepared.
+		next atomic.Int64
+		// failed is the lowest index of a record that failed, no records after
+		// it need to be prepared.
+		failed atomic.Int64
+	)
+	failed.Store(int64(len(records)))
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := next.Add(1) - 1
+				if i >= failed.Load() {
+					return
+				}
+				batch[i], errs[i] = p.prepareRecords(ctx, records[i])
+				if errs[i] != nil {
+					storeMin(&failed, i)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if f := int(failed.Load()); f < len(records) {
+		return batch[:f], fmt.Errorf("failed to prepare record %d: %w", f, errs[f])
 	}
+	return batch, nil
+}
 
-	return len(results), nil
+// storeMin stores v in a if it is lower than the current value.
+func storeMin(a *atomic.Int64, v int64) {
+	for {
+		cur := a.Load()
+		if v >= cur || a.CompareAndSwap(cur, v) {
+			return
+		}
+	}
 }
 
-func (p *FranzProducer) prepareRecord(r opencdc.Record) (*kgo.Record, error) {
-	encodedKey, err := p.keyEncoder.Encode(r.Key)
+// prepareRecords returns the Kafka records that need to be produced for the
+// record, taking into account the oversized record policy.
+func (p *FranzProducer) prepareRecords(ctx context.Context, r opencdc.Record) (preparedRecord, error) {
+	rec, settings, err := p.prepareRecord(ctx, r)
 	if err != nil {
-		return nil, fmt.Errorf("could not encode key: %w", err)
+		return preparedRecord{}, err
 	}
+	pr := preparedRecord{
+		topicSettings: settings,
+		policy:        p.oversizedRecordPolicy,
+	}
+	pr.records, err = pr.applyOversizedPolicy(ctx, r, rec)
+	if err != nil {
+		return preparedRecord{}, err
+	}
+	return pr, nil
+}
 
-	var topic string
+func (p *FranzProducer) prepareRecord(ctx context.Context, r opencdc.Record) (*kgo.Record, topicSettings, error) {
+	var (
+		topic = p.topic
+		err   error
+	)
 	if p.getTopic != nil {
 		topic, err = p.getTopic(r)
 		if err != nil {
-			return nil, fmt.Errorf("could not get topic: %w", err)
+			return nil, topicSettings{}, fmt.Errorf("could not get topic: %w", err)
 		}
 	}
-	return &kgo.Record{
-		Key:   encodedKey,
-		Value: r.Bytes(),
-		Topic: topic,
-	}, nil
+	settings := p.settingsFor(topic)
+
+	key, err := p.encode(ctx, settings.keyEncoder, r.Key, r, p.schemas.keySchema)
+	if err != nil {
+		return nil, topicSettings{}, fmt.Errorf("could not encode key: %w", err)
+	}
+
+	value := encodedData{bytes: r.Bytes()}
+	if p.valueEncoder != nil {
+		value, err = p.encode(ctx, p.valueEncoder, opencdc.RawData(value.bytes), r, p.schemas.payloadSchema)
+		if err != nil {
+			return nil, topicSettings{}, fmt.Errorf("could not encode value: %w", err)
+		}
+	}
+
+	// schemas are registered using the topic name strategy
+	keyBytes, err := p.wireFormat(ctx, topic+"-key", key)
+	if err != nil {
+		return nil, topicSettings{}, fmt.Errorf("could not encode key: %w", err)
+	}
+	valueBytes, err := p.wireFormat(ctx, topic+"-value", value)
+	if err != nil {
+		return nil, topicSettings{}, fmt.Errorf("could not encode value: %w", err)
+	}
+
+	rec := &kgo.Record{
+		Key:   keyBytes,
+		Value: valueBytes,
+	}
+	if p.getTopic != nil {
+		rec.Topic = topic
+	}
+	return rec, settings, nil
+}
+
+// encode encodes the data using the encoder. If the encoder supports schemas,
+// the schema attached to the record is looked up using getSchema.
+func (p *FranzProducer) encode(
+	ctx context.Context,
+	enc dataEncoder,
+	data opencdc.Data,
+	r opencdc.Record,
+	getSchema func(context.Context, opencdc.Record) (*kafkaconnect.Schema, error),
+) (encodedData, error) {
+	se, ok := enc.(schemaEncoder)
+	if !ok {
+		return enc.Encode(data)
+	}
+	schema, err := getSchema(ctx, r)
+	if err != nil {
+		return encodedData{}, err
+	}
+	return se.EncodeWithSchema(data, schema)
+}
+
+// wireFormat returns the bytes of the encoded data. If the data has a schema,
+// the schema is registered under the subject and the bytes are prefixed with
+// its ID in the Confluent wire format.
+func (p *FranzProducer) wireFormat(ctx context.Context, subject string, data encodedData) ([]byte, error) {
+	if data.schema == nil {
+		return data.bytes, nil
+	}
+	if p.registry == nil {
+		return nil, errors.New("data has a schema but no schema registry is configured")
+	}
+	id, err := p.registry.Register(ctx, subject, *data.schema)
+	if err != nil {
+		return nil, err
+	}
+	return registryWireFormat(id, data.schema.Type, data.bytes), nil
+}
+
+// settingsFor returns the settings of the topic override matching the topic,
+// or the default settings if no override matches. It is safe for concurrent
+// use.
+func (p *FranzProducer) settingsFor(topic string) topicSettings {
+	if p.settingsCache != nil {
+		if s, ok := p.settingsCache.Get(topic); ok {
+			return s
+		}
+	}
+
+	s := topicSettings{
+		client:         p.client,
+		keyEncoder:     p.keyEncoder,
+		maxRecordBytes: p.maxRecordBytes,
+	}
+	for _, o := range p.overrides {
+		if o.matches(topic) {
+			s = o.topicSettings
+			break
+		}
+	}
+
+	if p.settingsCache != nil {
+		p.settingsCache.Add(topic, s)
+	}
+	return s
 }
 
 func (p *FranzProducer) Close(_ context.Context) error {
 	if p.client != nil {
 		p.client.Close()
 	}
+	for _, cl := range p.overrideClients {
+		cl.Close()
+	}
 	return nil
 }
 
+// produceResults collects the outcomes of records produced asynchronously. It
+// is safe for concurrent use.
+type produceResults struct {
+	m     sync.Mutex
+	parts []int
+	done  []bool
+	errs  []error
+}
+
+func newProduceResults(n int) *produceResults {
+	parts := make([]int, n)
+	for i := range parts {
+		parts[i] = 1
+	}
+	return &produceResults{
+		parts: parts,
+		done:  make([]bool, n),
+		errs:  make([]error, n),
+	}
+}
+
+// SetParts sets the number of Kafka records the record at index i was split
+// into. The record is done once the outcome of all parts is stored. A record
+// without parts (i.e. a skipped record) is done immediately.
+func (r *produceResults) SetParts(i int, parts int) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.parts[i] = parts
+	r.done[i] = parts == 0
+}
+
+// Set stores the outcome of a part of the record at index i. The first error
+// of any part is retained.
+func (r *produceResults) Set(i int, err error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	r.parts[i]--
+	r.done[i] = r.parts[i] <= 0
+	if r.errs[i] == nil {
+		r.errs[i] = err
+	}
+}
+
+// AckedPrefix returns the number of records at the start of the first n
+// records that were successfully acknowledged. If the record following the
+// prefix failed, its error is returned as well.
+func (r *produceResults) AckedPrefix(n int) (int, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for i := 0; i < n; i++ {
+		if !r.done[i] {
+			return i, nil
+		}
+		if r.errs[i] != nil {
+			return i, r.errs[i]
+		}
+	}
+	return n, nil
+}
+
 // dataEncoder is similar to a sdk.Encoder, which takes data and encodes it in
 // a certain format. The producer uses this to encode the key of the kafka
 // message.
 type dataEncoder interface {
-	Encode(opencdc.Data) ([]byte, error)
+	Encode(opencdc.Data) (encodedData, error)
+}
+
+// encodedData is the data encoded by a dataEncoder.
+type encodedData struct {
+	bytes []byte
+	// schema is the schema consumers need to decode the bytes, the producer
+	// registers it in the schema registry and prefixes the bytes with its ID.
+	// It is nil if the encoding doesn't need a separate schema.
+	schema *registrySchema
 }
 
 // bytesEncoder is a dataEncoder that simply calls data.Bytes().
 type bytesEncoder struct{}
 
-func (bytesEncoder) Encode(data opencdc.Data) ([]byte, error) {
-	return data.Bytes(), nil
+func (bytesEncoder) Encode(data opencdc.Data) (encodedData, error) {
+	return encodedData{bytes: data.Bytes()}, nil
+}
+
+// kafkaConnectEncoder encodes the data into a kafka connect envelope with
+// schema. By default, the envelope is encoded as JSON (NB: this is not the
+// same as JSONSchema), alternatively it can be encoded as Avro or Protobuf.
+// The Avro schema or Protobuf file is returned as the schema of the encoded
+// data.
+type kafkaConnectEncoder struct {
+	// encoding is the encoding of the envelope, one of json (default), avro
+	// or protobuf.
+	encoding string
+	// avroEncodings and protobufEncodings cache the Avro schemas and Protobuf
+	// descriptors derived from kafka connect schemas. If nil, they are
+	// derived for every encoded value.
+	avroEncodings     *lru.Cache[string, avroEncoding]
+	protobufEncodings *lru.Cache[string, protobufEncoding]
 }
 
-// kafkaConnectEncoder encodes the data into a kafka connect JSON with schema
-// (NB: this is not the same as JSONSchema).
-type kafkaConnectEncoder struct{}
+func newKafkaConnectEncoder(encoding string) (kafkaConnectEncoder, error) {
+	e := kafkaConnectEncoder{encoding: encoding}
+	var err error
+	switch encoding {
+	case "avro":
+		e.avroEncodings, err = lru.New[string, avroEncoding](topicCacheSize)
+	case "protobuf":
+		e.protobufEncodings, err = lru.New[string, protobufEncoding](topicCacheSize)
+	}
+	return e, err
+}
 
-func (e kafkaConnectEncoder) Encode(data opencdc.Data) ([]byte, error) {
+func (e kafkaConnectEncoder) Encode(data opencdc.Data) (encodedData, error) {
 	sd := e.toStructuredData(data)
 	schema := kafkaconnect.Reflect(sd)
 	if schema == nil {
@@ -194,12 +681,50 @@ func (e kafkaConnectEncoder) Encode(data opencdc.Data) ([]byte, error) {
 		}
 	}
 
+	if e.encoding == "avro" || e.encoding == "protobuf" {
+		// Fields of structs reflected from maps are in random order, sort
+		// them so the same data always results in the same schema.
+		kafkaconnect.SortFields(schema)
+	}
+
 	env := kafkaconnect.Envelope{
 		Schema:  *schema,
 		Payload: sd,
 	}
-	// TODO add support for other encodings than JSON
-	return json.Marshal(env)
+	return e.encodeEnvelope(env)
+}
+
+// EncodeWithSchema encodes the data using the schema attached to the record
+// instead of inferring it from the data.
+func (e kafkaConnectEncoder) EncodeWithSchema(data opencdc.Data, schema *kafkaconnect.Schema) (encodedData, error) {
+	if schema == nil {
+		return e.Encode(data)
+	}
+
+	sd := e.toStructuredData(data)
+	if _, ok := sd.(opencdc.RawData); ok {
+		return encodedData{}, errors.New("data has an attached schema but is not structured, make sure schema extraction is enabled")
+	}
+	payload, err := connectPayload(schema, sd)
+	if err != nil {
+		return encodedData{}, err
+	}
+	return e.encodeEnvelope(kafkaconnect.Envelope{
+		Schema:  *schema,
+		Payload: payload,
+	})
+}
+
+func (e kafkaConnectEncoder) encodeEnvelope(env kafkaconnect.Envelope) (encodedData, error) {
+	switch e.encoding {
+	case "avro":
+		return encodeAvro(env, e.avroEncodings)
+	case "protobuf":
+		return encodeProtobuf(env, e.protobufEncodings)
+	default:
+		b, err := json.Marshal(env)
+		return encodedData{bytes: b}, err
+	}
 }
 
 // toStructuredData tries its best to return StructuredData.
@@ -16,11 +16,21 @@ package destination
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-kafka/common"
 	"github.com/conduitio/conduit-connector-kafka/test"
 	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 )
 
 func TestFranzProducer_Produce(t *testing.T) {
@@ -53,3 +63,119 @@ func TestFranzProducer_Produce(t *testing.T) {
 		is.Equal(got.Value, wantRecords[i].Bytes())
 	}
 }
+
+// TestFranzProducer_Produce_Ordering uses an in-process fake cluster which
+// fails some produce requests with retriable errors and checks that records
+// with the same key are written in order despite the retries.
+func TestFranzProducer_Produce_Ordering(t *testing.T) {
+	const (
+		topic       = "ordering"
+		keys        = 5
+		recordCount = 60
+		batchSize   = 20
+	)
+
+	for _, ordering := range []string{"per-partition", "strict"} {
+		t.Run(ordering, func(t *testing.T) {
+			is := is.New(t)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			cluster, err := kfake.NewCluster(
+				kfake.NumBrokers(1),
+				kfake.SeedTopics(3, topic),
+			)
+			is.NoErr(err)
+			t.Cleanup(cluster.Close)
+
+			// fail every third produce request with a retriable error
+			var produceRequests atomic.Int32
+			cluster.ControlKey(kmsg.Produce.Int16(), func(kreq kmsg.Request) (kmsg.Response, error, bool) {
+				cluster.KeepControl()
+				if produceRequests.Add(1)%3 != 0 {
+					return nil, nil, false
+				}
+				return produceErrorResponse(kreq.(*kmsg.ProduceRequest), kerr.RequestTimedOut), nil, true
+			})
+
+			cfg := Config{
+				Config: common.Config{
+					Servers: cluster.ListenAddrs(),
+					// allow more in-flight requests, the ordering option needs
+					// to override this
+					ClientOptions: map[string]string{"max.inflight.per.broker": "5"},
+				},
+				Topic:           topic,
+				BatchBytes:      1000012,
+				DeliveryTimeout: 5 * time.Second,
+				Acks:            "one", // disables idempotent writes
+				Ordering:        ordering,
+			}
+			// retries trigger a metadata refresh, make sure it happens quickly
+			cfg.Config = test.ConfigWithIntegrationTestOptions(cfg.Config)
+
+			p, err := NewFranzProducer(ctx, cfg)
+			is.NoErr(err)
+			defer p.Close(ctx)
+
+			records := make([]opencdc.Record, recordCount)
+			for i := range records {
+				records[i] = opencdc.Record{
+					Key:     opencdc.RawData(fmt.Sprintf("key-%d", i%keys)),
+					Payload: opencdc.Change{After: opencdc.RawData(strconv.Itoa(i))},
+				}
+			}
+			for i := 0; i < len(records); i += batchSize {
+				n, err := p.Produce(ctx, records[i:i+batchSize])
+				is.NoErr(err)
+				is.Equal(n, batchSize)
+			}
+			is.True(produceRequests.Load() >= 3) // expected some requests to fail
+
+			cl, err := kgo.NewClient(
+				kgo.SeedBrokers(cluster.ListenAddrs()...),
+				kgo.ConsumeTopics(topic),
+				kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+			)
+			is.NoErr(err)
+			defer cl.Close()
+
+			lastByKey := make(map[string]int)
+			for consumed := 0; consumed < recordCount; {
+				fetches := cl.PollFetches(ctx)
+				is.NoErr(fetches.Err())
+				fetches.EachRecord(func(r *kgo.Record) {
+					consumed++
+					var rec struct {
+						Payload struct {
+							After []byte `json:"after"`
+						} `json:"payload"`
+					}
+					is.NoErr(json.Unmarshal(r.Value, &rec))
+					v, err := strconv.Atoi(string(rec.Payload.After))
+					is.NoErr(err)
+					last, ok := lastByKey[string(r.Key)]
+					is.True(!ok || v > last) // records with the same key must be in order
+					lastByKey[string(r.Key)] = v
+				})
+			}
+			is.Equal(len(lastByKey), keys)
+		})
+	}
+}
+
+func produceErrorResponse(req *kmsg.ProduceRequest, err *kerr.Error) *kmsg.ProduceResponse {
+	resp := req.ResponseKind().(*kmsg.ProduceResponse)
+	for _, rt := range req.Topics {
+		st := kmsg.NewProduceResponseTopic()
+		st.Topic = rt.Topic
+		for _, rp := range rt.Partitions {
+			sp := kmsg.NewProduceResponseTopicPartition()
+			sp.Partition = rp.Partition
+			sp.ErrorCode = err.Code
+			st.Partitions = append(st.Partitions, sp)
+		}
+		resp.Topics = append(resp.Topics, st)
+	}
+	return resp
+}
@@ -17,6 +17,10 @@ package destination
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,6 +30,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kfake"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/sasl"
 )
@@ -40,6 +45,10 @@ func TestFranzProducer_Opts(t *testing.T) {
 			Servers:  []string{"test-host:9092"},
 			ClientID: "test-client-id",
 
+			DialTimeout:    3 * time.Second,
+			RequestTimeout: 20 * time.Second,
+			MetadataMaxAge: time.Minute,
+
 			ConfigSASL: common.ConfigSASL{
 				Mechanism: "PLAIN",
 				Username:  "user",
@@ -69,6 +78,8 @@ func TestFranzProducer_Opts(t *testing.T) {
 	is.Equal(p.client.OptValue(kgo.ProducerBatchMaxBytes), cfg.BatchBytes)
 
 	is.Equal(p.client.OptValue(kgo.ClientID), cfg.ClientID)
+	is.Equal(p.client.OptValue(kgo.RequestTimeoutOverhead), cfg.RequestTimeout)
+	is.Equal(p.client.OptValue(kgo.MetadataMaxAge), cfg.MetadataMaxAge)
 	is.Equal(cmp.Diff(p.client.OptValue(kgo.DialTLSConfig), cfg.TLS(), cmpopts.IgnoreUnexported(tls.Config{})), "")
 	is.Equal(p.client.OptValue(kgo.SASL).([]sasl.Mechanism)[0].Name(), cfg.SASL().Name())
 }
@@ -143,3 +154,358 @@ func TestFranzProducer_Opts_Topic(t *testing.T) {
 		is.Equal(topic, "bar")
 	})
 }
+
+func TestFranzProducer_Produce_PartialFailure(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(1, "existing"),
+	)
+	is.NoErr(err)
+	t.Cleanup(cluster.Close)
+
+	cfg := Config{
+		Config:          common.Config{Servers: cluster.ListenAddrs()},
+		Topic:           `{{ index .Metadata "topic" }}`,
+		BatchBytes:      1000012,
+		DeliveryTimeout: time.Second,
+		Acks:            "all",
+	}
+
+	p, err := NewFranzProducer(ctx, cfg)
+	is.NoErr(err)
+	defer p.Close(ctx)
+
+	newRecord := func(topic string) opencdc.Record {
+		return opencdc.Record{
+			Key:      opencdc.RawData("key"),
+			Metadata: map[string]string{"topic": topic},
+			Payload:  opencdc.Change{After: opencdc.RawData("foo")},
+		}
+	}
+
+	t.Run("produce error", func(t *testing.T) {
+		is := is.New(t)
+		records := []opencdc.Record{
+			newRecord("existing"),
+			newRecord("existing"),
+			newRecord("missing"), // kfake does not create topics automatically
+			newRecord("existing"),
+			newRecord("existing"),
+		}
+
+		n, err := p.Produce(ctx, records)
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "failed to produce record 2"))
+		is.Equal(n, 2)
+	})
+
+	t.Run("prepare error", func(t *testing.T) {
+		is := is.New(t)
+		records := []opencdc.Record{
+			newRecord("existing"),
+			newRecord("existing"),
+			newRecord("existing"),
+			{Key: opencdc.RawData("key")}, // no topic in metadata
+			newRecord("existing"),
+		}
+
+		n, err := p.Produce(ctx, records)
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "failed to prepare record 3"))
+		is.Equal(n, 3)
+	})
+}
+
+func TestProduceResults_AckedPrefix(t *testing.T) {
+	is := is.New(t)
+	wantErr := errors.New("test error")
+
+	r := newProduceResults(5)
+	n, err := r.AckedPrefix(5)
+	is.Equal(n, 0)
+	is.NoErr(err)
+
+	// acks arrive out of order
+	r.Set(1, nil)
+	r.Set(3, wantErr)
+	n, err = r.AckedPrefix(5)
+	is.Equal(n, 0)
+	is.NoErr(err)
+
+	r.Set(0, nil)
+	n, err = r.AckedPrefix(5)
+	is.Equal(n, 2)
+	is.NoErr(err)
+
+	r.Set(2, nil)
+	n, err = r.AckedPrefix(5)
+	is.Equal(n, 3)
+	is.Equal(err, wantErr)
+
+	// only consider the first n records
+	n, err = r.AckedPrefix(2)
+	is.Equal(n, 2)
+	is.NoErr(err)
+}
+
+func TestFranzProducer_Opts_Ordering(t *testing.T) {
+	// minimal valid config, with an increased number of in-flight requests
+	cfg := Config{
+		Config: common.Config{
+			Servers:       []string{"test-host:9092"},
+			ClientOptions: map[string]string{"max.inflight.per.broker": "5"},
+		},
+		Topic:      "foo",
+		BatchBytes: 512,
+		Acks:       "one",
+	}
+
+	testCases := []struct {
+		ordering        string
+		wantMaxInflight int
+		wantStrict      bool
+	}{{
+		ordering:        "none",
+		wantMaxInflight: 5,
+	}, {
+		ordering:        "per-partition",
+		wantMaxInflight: 1,
+	}, {
+		ordering:        "strict",
+		wantMaxInflight: 1,
+		wantStrict:      true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.ordering, func(t *testing.T) {
+			is := is.New(t)
+			cfg.Ordering = tc.ordering
+			p, err := NewFranzProducer(context.Background(), cfg)
+			is.NoErr(err)
+
+			is.Equal(p.client.OptValue(kgo.MaxProduceRequestsInflightPerBroker), tc.wantMaxInflight)
+			is.Equal(p.strictOrdering, tc.wantStrict)
+		})
+	}
+}
+
+func TestFranzProducer_Opts_ProducerLimits(t *testing.T) {
+	is := is.New(t)
+
+	cfg := Config{
+		Config: common.Config{
+			Servers: []string{"test-host:9092"},
+			// dedicated parameters take precedence over client options
+			ClientOptions: map[string]string{"linger": "1s"},
+		},
+		Topic:               "foo",
+		BatchBytes:          512,
+		Acks:                "one",
+		Linger:              5 * time.Millisecond,
+		MaxBufferedRecords:  100,
+		MaxBufferedBytes:    1 << 20,
+		MaxInflightRequests: 5,
+	}
+
+	p, err := NewFranzProducer(context.Background(), cfg)
+	is.NoErr(err)
+
+	is.Equal(p.client.OptValue(kgo.ProducerLinger), cfg.Linger)
+	is.Equal(p.client.OptValue(kgo.MaxBufferedRecords), int64(cfg.MaxBufferedRecords))
+	is.Equal(p.client.OptValue(kgo.MaxBufferedBytes), int64(cfg.MaxBufferedBytes))
+	is.Equal(p.client.OptValue(kgo.MaxProduceRequestsInflightPerBroker), cfg.MaxInflightRequests)
+}
+
+func BenchmarkFranzProducer_Produce(b *testing.B) {
+	const (
+		batchSize  = 1000
+		valueBytes = 512
+	)
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(3),
+		kfake.SeedTopics(12, "bench"),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(cluster.Close)
+
+	records := make([]opencdc.Record, batchSize)
+	for i := range records {
+		records[i] = opencdc.Record{
+			Key:     opencdc.RawData(fmt.Sprintf("key-%d", i)),
+			Payload: opencdc.Change{After: opencdc.RawData(strings.Repeat("x", valueBytes))},
+		}
+	}
+
+	benchmarks := []struct {
+		name string
+		cfg  Config
+	}{{
+		name: "defaults",
+		cfg:  Config{Acks: "all"},
+	}, {
+		name: "linger 5ms",
+		cfg:  Config{Acks: "all", Linger: 5 * time.Millisecond},
+	}, {
+		name: "small buffer",
+		cfg:  Config{Acks: "all", MaxBufferedRecords: 100},
+	}, {
+		name: "acks one",
+		cfg:  Config{Acks: "one"},
+	}, {
+		name: "acks one 5 inflight",
+		cfg:  Config{Acks: "one", MaxInflightRequests: 5},
+	}}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			ctx := context.Background()
+
+			cfg := bm.cfg
+			cfg.Servers = cluster.ListenAddrs()
+			cfg.Topic = "bench"
+			cfg.BatchBytes = 1000012
+			cfg.Compression = "none"
+
+			p, err := NewFranzProducer(ctx, cfg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer p.Close(ctx)
+
+			b.SetBytes(batchSize * valueBytes)
+			b.ResetTimer()
+			for range b.N {
+				if _, err := p.Produce(ctx, records); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N*batchSize)/b.Elapsed().Seconds(), "records/s")
+		})
+	}
+}
+
+func TestFranzProducer_PrepareBatch(t *testing.T) {
+	ctx := context.Background()
+
+	_, getTopic, err := Config{Topic: `{{ index .Metadata "topic" }}`}.ParseTopic()
+	is.New(t).NoErr(err)
+
+	newRecords := func(n int, failAt ...int) []opencdc.Record {
+		records := make([]opencdc.Record, n)
+		for i := range records {
+			records[i] = opencdc.Record{
+				Key:      opencdc.RawData(strconv.Itoa(i)),
+				Metadata: map[string]string{"topic": "test"},
+				Payload:  opencdc.Change{After: opencdc.RawData("foo")},
+			}
+		}
+		for _, i := range failAt {
+			records[i].Metadata = nil // no topic in metadata
+		}
+		return records
+	}
+
+	testCases := []struct {
+		name        string
+		concurrency int
+		records     []opencdc.Record
+		wantN       int
+		wantErr     string
+	}{{
+		name:        "sequential",
+		concurrency: 1,
+		records:     newRecords(200),
+		wantN:       200,
+	}, {
+		name:        "concurrent",
+		concurrency: 4,
+		records:     newRecords(200),
+		wantN:       200,
+	}, {
+		name:        "sequential with failures",
+		concurrency: 1,
+		records:     newRecords(200, 150, 70),
+		wantN:       70,
+		wantErr:     "failed to prepare record 70",
+	}, {
+		name:        "concurrent with failures",
+		concurrency: 4,
+		records:     newRecords(200, 150, 70),
+		wantN:       70,
+		wantErr:     "failed to prepare record 70",
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			p := &FranzProducer{
+				keyEncoder:         bytesEncoder{},
+				getTopic:           getTopic,
+				prepareConcurrency: tc.concurrency,
+				maxRecordBytes:     1000012,
+			}
+
+			got, err := p.prepareBatch(ctx, tc.records)
+			if tc.wantErr != "" {
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tc.wantErr))
+			} else {
+				is.NoErr(err)
+			}
+			is.Equal(len(got), tc.wantN)
+			for i, pr := range got {
+				is.Equal(len(pr.records), 1)
+				is.Equal(string(pr.records[0].Key), strconv.Itoa(i)) // order is preserved
+			}
+		})
+	}
+}
+
+func BenchmarkFranzProducer_PrepareBatch(b *testing.B) {
+	const batchSize = 1000
+	ctx := context.Background()
+
+	_, getTopic, err := Config{Topic: `{{ index .Metadata "table" | lower }}.events`}.ParseTopic()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	records := make([]opencdc.Record, batchSize)
+	for i := range records {
+		records[i] = opencdc.Record{
+			Position:  opencdc.Position(strconv.Itoa(i)),
+			Operation: opencdc.OperationUpdate,
+			Metadata:  map[string]string{"table": "Orders"},
+			Key:       opencdc.StructuredData{"id": i, "region": "eu-west-1"},
+			Payload: opencdc.Change{
+				Before: opencdc.StructuredData{"id": i, "status": "pending", "items": []any{"a", "b", "c"}},
+				After:  opencdc.StructuredData{"id": i, "status": "shipped", "items": []any{"a", "b", "c"}},
+			},
+		}
+	}
+
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency %d", concurrency), func(b *testing.B) {
+			p := &FranzProducer{
+				keyEncoder:         kafkaConnectEncoder{},
+				getTopic:           getTopic,
+				prepareConcurrency: concurrency,
+				maxRecordBytes:     1000012,
+			}
+
+			b.ResetTimer()
+			for range b.N {
+				if _, err := p.prepareBatch(ctx, records); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N*batchSize)/b.Elapsed().Seconds(), "records/s")
+		})
+	}
+}
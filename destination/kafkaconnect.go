@@ -0,0 +1,252 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-sdk/kafkaconnect"
+	"github.com/goccy/go-json"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// kafkaConnectValueEncoder is a dataEncoder that takes a kafka connect
+// envelope serialized as JSON (e.g. a record serialized in the Debezium
+// format) and encodes it using the encoding of the kafkaConnectEncoder.
+type kafkaConnectValueEncoder struct {
+	kafkaConnectEncoder
+}
+
+func (e kafkaConnectValueEncoder) Encode(data opencdc.Data) (encodedData, error) {
+	return e.EncodeWithSchema(data, nil)
+}
+
+// EncodeWithSchema replaces the inferred schemas of the before and after
+// fields of the Debezium envelope with the schema attached to the record
+// payload. If the payload schema is nil, the inferred schemas are kept.
+func (e kafkaConnectValueEncoder) EncodeWithSchema(data opencdc.Data, payloadSchema *kafkaconnect.Schema) (encodedData, error) {
+	if payloadSchema == nil && (e.encoding == "" || e.encoding == "json") {
+		return encodedData{bytes: data.Bytes()}, nil // nothing to do
+	}
+
+	var env kafkaconnect.Envelope
+	dec := json.NewDecoder(bytes.NewReader(data.Bytes()))
+	dec.UseNumber() // don't lose precision of large integers
+	if err := dec.Decode(&env); err != nil {
+		return encodedData{}, fmt.Errorf("value is not a kafka connect envelope: %w", err)
+	}
+
+	payload, _ := env.Payload.(map[string]any)
+	for i := range env.Schema.Fields {
+		f := &env.Schema.Fields[i]
+		if payloadSchema != nil && payload != nil && (f.Field == "before" || f.Field == "after") {
+			fv, err := connectPayload(payloadSchema, payload[f.Field])
+			if err != nil {
+				return encodedData{}, fmt.Errorf("field %q: %w", f.Field, err)
+			}
+			payload[f.Field] = fv
+
+			name := f.Field
+			*f = *payloadSchema
+			f.Field = name
+			f.Optional = true
+			continue
+		}
+		// Fields of structs reflected from maps are in random order, sort
+		// them so the same data always results in the same schema.
+		kafkaconnect.SortFields(f)
+	}
+	return e.encodeEnvelope(env)
+}
+
+// cachedEncoding returns the encoding derived from the kafka connect schema by
+// newEncoding. Encodings are cached by the JSON representation of the schema,
+// if the cache is nil the encoding is derived on every call.
+func cachedEncoding[T any](
+	cache *lru.Cache[string, T],
+	s *kafkaconnect.Schema,
+	newEncoding func(*kafkaconnect.Schema) (T, error),
+) (T, error) {
+	if cache == nil {
+		return newEncoding(s)
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if enc, ok := cache.Get(string(b)); ok {
+		return enc, nil
+	}
+	enc, err := newEncoding(s)
+	if err != nil {
+		return enc, err
+	}
+	cache.Add(string(b), enc)
+	return enc, nil
+}
+
+// normalizePayload converts the payload into the types produced by decoding
+// JSON (map[string]any, []any, string, json.Number, bool and nil), so the
+// encoders only need to handle those. Byte slices are converted into base64
+// encoded strings, same as in the JSON encoding of an envelope.
+func normalizePayload(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// connectFieldNames returns the names of the struct fields converted into
+// valid Avro and Protobuf identifiers. Invalid characters are replaced with
+// underscores, names colliding after the replacement get a numeric suffix.
+func connectFieldNames(fields []kafkaconnect.Schema) []string {
+	names := make([]string, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for i, f := range fields {
+		name := connectIdentifier(f.Field)
+		for n := 2; seen[name]; n++ {
+			name = connectIdentifier(f.Field) + "_" + strconv.Itoa(n)
+		}
+		seen[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+func connectIdentifier(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			sb.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+	if sb.Len() == 0 {
+		return "_"
+	}
+	return sb.String()
+}
+
+// mapKey converts a key of a normalized map (which is always a string) into
+// a value matching the key schema.
+func mapKey(s *kafkaconnect.Schema, key string) any {
+	switch s.Type {
+	case kafkaconnect.TypeBoolean:
+		if b, err := strconv.ParseBool(key); err == nil {
+			return b
+		}
+	case kafkaconnect.TypeInt8, kafkaconnect.TypeInt16, kafkaconnect.TypeInt32, kafkaconnect.TypeInt64,
+		kafkaconnect.TypeFloat, kafkaconnect.TypeDouble:
+		return json.Number(key)
+	}
+	return key
+}
+
+func connectInt(v any, bits int) (int64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("expected a number, got %T", v)
+	}
+	i, err := strconv.ParseInt(n.String(), 10, bits)
+	if err != nil {
+		// integral numbers can be formatted as floats (e.g. 1e3)
+		f, ferr := n.Float64()
+		if ferr != nil || f != math.Trunc(f) {
+			return 0, fmt.Errorf("expected an integer, got %v", n)
+		}
+		i = int64(f)
+		if i < -1<<(bits-1) || i > 1<<(bits-1)-1 {
+			return 0, fmt.Errorf("integer %v overflows int%d", n, bits)
+		}
+	}
+	return i, nil
+}
+
+func connectFloat(v any) (float64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("expected a number, got %T", v)
+	}
+	return n.Float64()
+}
+
+func connectBool(v any) (bool, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a boolean, got %T", v)
+	}
+	return b, nil
+}
+
+func connectString(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		// structured values in fields with a string schema (e.g. fields that
+		// were nil when the schema was reflected) are encoded as JSON
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
+
+func connectBytes(v any) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected base64 encoded bytes, got %T", v)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func connectStruct(v any) (map[string]any, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a struct, got %T", v)
+	}
+	return m, nil
+}
+
+func connectArray(v any) ([]any, error) {
+	a, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array, got %T", v)
+	}
+	return a, nil
+}
@@ -0,0 +1,320 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/conduitio/conduit-connector-sdk/kafkaconnect"
+	"github.com/hamba/avro/v2"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// avroEncoding is the Avro schema derived from a kafka connect schema.
+type avroEncoding struct {
+	schema avro.Schema
+	// registrySchema is the canonical form of the Avro schema, which is
+	// registered in the schema registry.
+	registrySchema registrySchema
+}
+
+func newAvroEncoding(s *kafkaconnect.Schema) (avroEncoding, error) {
+	schema, err := avroSchema(s)
+	if err != nil {
+		return avroEncoding{}, err
+	}
+	return avroEncoding{
+		schema: schema,
+		// String returns the canonical form
+		registrySchema: registrySchema{Type: registryTypeAvro, Schema: schema.String()},
+	}, nil
+}
+
+// encodeAvro encodes the payload of the envelope in the Avro binary encoding
+// of the Avro schema derived from the kafka connect schema. The Avro schema is
+// returned as the schema of the encoded data, the producer registers it and
+// prefixes the data with its ID. Derived schemas are cached in the cache, if
+// it isn't nil.
+func encodeAvro(env kafkaconnect.Envelope, cache *lru.Cache[string, avroEncoding]) (encodedData, error) {
+	enc, err := cachedEncoding(cache, &env.Schema, newAvroEncoding)
+	if err != nil {
+		return encodedData{}, fmt.Errorf("failed to convert kafka connect schema to avro: %w", err)
+	}
+	payload, err := normalizePayload(env.Payload)
+	if err != nil {
+		return encodedData{}, fmt.Errorf("failed to normalize payload: %w", err)
+	}
+
+	w := avro.NewWriter(nil, 512)
+	if err := writeAvro(w, &env.Schema, payload); err != nil {
+		return encodedData{}, fmt.Errorf("failed to encode payload as avro: %w", err)
+	}
+	return encodedData{
+		bytes:  w.Buffer(),
+		schema: &enc.registrySchema,
+	}, nil
+}
+
+// avroSchema converts the kafka connect schema into an Avro schema. Structs
+// are converted into records, optional types into unions with null and maps
+// with non-string keys into arrays of key-value records, like the Confluent
+// Avro converter does.
+func avroSchema(s *kafkaconnect.Schema) (avro.Schema, error) {
+	b := avroSchemaBuilder{names: make(map[string]int)}
+	return b.build(s)
+}
+
+type avroSchemaBuilder struct {
+	// names contains the number of records with the same name, Avro requires
+	// names of records to be unique within the schema.
+	names map[string]int
+}
+
+func (b avroSchemaBuilder) build(s *kafkaconnect.Schema) (avro.Schema, error) {
+	schema, err := b.buildType(s)
+	if err != nil {
+		return nil, err
+	}
+	if !s.Optional {
+		return schema, nil
+	}
+	return avro.NewUnionSchema([]avro.Schema{avro.NewNullSchema(), schema})
+}
+
+func (b avroSchemaBuilder) buildType(s *kafkaconnect.Schema) (avro.Schema, error) {
+	switch s.Type {
+	case kafkaconnect.TypeBoolean:
+		return avro.NewPrimitiveSchema(avro.Boolean, nil), nil
+	case kafkaconnect.TypeInt8, kafkaconnect.TypeInt16, kafkaconnect.TypeInt32:
+		return avro.NewPrimitiveSchema(avro.Int, nil), nil
+	case kafkaconnect.TypeInt64:
+		return avro.NewPrimitiveSchema(avro.Long, nil), nil
+	case kafkaconnect.TypeFloat:
+		return avro.NewPrimitiveSchema(avro.Float, nil), nil
+	case kafkaconnect.TypeDouble:
+		return avro.NewPrimitiveSchema(avro.Double, nil), nil
+	case kafkaconnect.TypeBytes:
+		return avro.NewPrimitiveSchema(avro.Bytes, nil), nil
+	case kafkaconnect.TypeString:
+		return avro.NewPrimitiveSchema(avro.String, nil), nil
+	case kafkaconnect.TypeArray:
+		items, err := b.build(elemSchema(s.Items))
+		if err != nil {
+			return nil, err
+		}
+		return avro.NewArraySchema(items), nil
+	case kafkaconnect.TypeMap:
+		values, err := b.build(elemSchema(s.Values))
+		if err != nil {
+			return nil, err
+		}
+		keys := elemSchema(s.Keys)
+		if keys.Type == kafkaconnect.TypeString && !keys.Optional {
+			return avro.NewMapSchema(values), nil
+		}
+		key, err := b.build(keys)
+		if err != nil {
+			return nil, err
+		}
+		keyField, err := avro.NewField("key", key)
+		if err != nil {
+			return nil, err
+		}
+		valueField, err := avro.NewField("value", values)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := avro.NewRecordSchema(b.name("MapEntry"), "", []*avro.Field{keyField, valueField})
+		if err != nil {
+			return nil, err
+		}
+		return avro.NewArraySchema(entry), nil
+	case kafkaconnect.TypeStruct:
+		// reserve the name before building nested records, so the outer
+		// record gets the name without a counter
+		name := "ConnectDefault"
+		if s.Name != "" {
+			parts := strings.Split(s.Name, ".")
+			for i, p := range parts {
+				parts[i] = connectIdentifier(p)
+			}
+			name = strings.Join(parts, ".")
+		}
+		name = b.name(name)
+
+		names := connectFieldNames(s.Fields)
+		fields := make([]*avro.Field, len(s.Fields))
+		for i := range s.Fields {
+			typ, err := b.build(&s.Fields[i])
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", s.Fields[i].Field, err)
+			}
+			var opts []avro.SchemaOption
+			if s.Fields[i].Optional {
+				opts = append(opts, avro.WithDefault(nil))
+			}
+			fields[i], err = avro.NewField(names[i], typ, opts...)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", s.Fields[i].Field, err)
+			}
+		}
+		return avro.NewRecordSchema(name, "", fields)
+	default:
+		return nil, fmt.Errorf("unsupported type %q", s.Type)
+	}
+}
+
+// name returns a unique record name by appending a counter to names that
+// were already used.
+func (b avroSchemaBuilder) name(name string) string {
+	b.names[name]++
+	if n := b.names[name]; n > 1 {
+		return name + strconv.Itoa(n)
+	}
+	return name
+}
+
+// elemSchema returns the schema of array items, map keys or map values. The
+// schema is nil if kafkaconnect.Reflect couldn't determine it (e.g. for an
+// empty map of interfaces), in that case we fall back to an optional string,
+// same as kafkaconnect.Reflect does for nil values.
+func elemSchema(s *kafkaconnect.Schema) *kafkaconnect.Schema {
+	if s == nil {
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeString, Optional: true}
+	}
+	return s
+}
+
+// writeAvro writes the normalized value in the Avro binary encoding of the
+// Avro schema derived from the kafka connect schema.
+func writeAvro(w *avro.Writer, s *kafkaconnect.Schema, v any) error {
+	if s.Optional {
+		if v == nil {
+			w.WriteLong(0) // null branch of the union
+			return nil
+		}
+		w.WriteLong(1)
+	} else if v == nil {
+		return errors.New("value is required")
+	}
+
+	switch s.Type {
+	case kafkaconnect.TypeBoolean:
+		b, err := connectBool(v)
+		if err != nil {
+			return err
+		}
+		w.WriteBool(b)
+	case kafkaconnect.TypeInt8, kafkaconnect.TypeInt16, kafkaconnect.TypeInt32:
+		i, err := connectInt(v, 32)
+		if err != nil {
+			return err
+		}
+		w.WriteInt(int32(i))
+	case kafkaconnect.TypeInt64:
+		i, err := connectInt(v, 64)
+		if err != nil {
+			return err
+		}
+		w.WriteLong(i)
+	case kafkaconnect.TypeFloat:
+		f, err := connectFloat(v)
+		if err != nil {
+			return err
+		}
+		w.WriteFloat(float32(f))
+	case kafkaconnect.TypeDouble:
+		f, err := connectFloat(v)
+		if err != nil {
+			return err
+		}
+		w.WriteDouble(f)
+	case kafkaconnect.TypeBytes:
+		b, err := connectBytes(v)
+		if err != nil {
+			return err
+		}
+		w.WriteBytes(b)
+	case kafkaconnect.TypeString:
+		str, err := connectString(v)
+		if err != nil {
+			return err
+		}
+		w.WriteString(str)
+	case kafkaconnect.TypeArray:
+		items, err := connectArray(v)
+		if err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			w.WriteLong(int64(len(items)))
+			for i, item := range items {
+				if err := writeAvro(w, elemSchema(s.Items), item); err != nil {
+					return fmt.Errorf("item %d: %w", i, err)
+				}
+			}
+		}
+		w.WriteLong(0)
+	case kafkaconnect.TypeMap:
+		m, err := connectStruct(v)
+		if err != nil {
+			return err
+		}
+		keys := elemSchema(s.Keys)
+		stringKeys := keys.Type == kafkaconnect.TypeString && !keys.Optional
+		if len(m) > 0 {
+			w.WriteLong(int64(len(m)))
+			for _, k := range sortedKeys(m) {
+				if stringKeys {
+					w.WriteString(k)
+				} else if err := writeAvro(w, keys, mapKey(keys, k)); err != nil {
+					return fmt.Errorf("key %q: %w", k, err)
+				}
+				if err := writeAvro(w, elemSchema(s.Values), m[k]); err != nil {
+					return fmt.Errorf("key %q: %w", k, err)
+				}
+			}
+		}
+		w.WriteLong(0)
+	case kafkaconnect.TypeStruct:
+		m, err := connectStruct(v)
+		if err != nil {
+			return err
+		}
+		for i := range s.Fields {
+			if err := writeAvro(w, &s.Fields[i], m[s.Fields[i].Field]); err != nil {
+				return fmt.Errorf("field %q: %w", s.Fields[i].Field, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %q", s.Type)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the map in a deterministic order, so that
+// encoding the same value always produces the same bytes.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
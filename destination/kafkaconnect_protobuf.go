@@ -0,0 +1,496 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/conduitio/conduit-connector-sdk/kafkaconnect"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protobufEncoding is the Protobuf message descriptor derived from a kafka
+// connect schema.
+type protobufEncoding struct {
+	descriptor protoreflect.MessageDescriptor
+	// registrySchema is the file of the message descriptor in the Protobuf
+	// language, which is registered in the schema registry.
+	registrySchema registrySchema
+}
+
+func newProtobufEncoding(s *kafkaconnect.Schema) (protobufEncoding, error) {
+	md, err := protobufDescriptor(s)
+	if err != nil {
+		return protobufEncoding{}, err
+	}
+	return protobufEncoding{
+		descriptor: md,
+		registrySchema: registrySchema{
+			Type:   registryTypeProtobuf,
+			Schema: protobufSchemaText(protodesc.ToFileDescriptorProto(md.ParentFile())),
+		},
+	}, nil
+}
+
+// encodeProtobuf encodes the payload of the envelope as a Protobuf message
+// described by the message descriptor derived from the kafka connect schema.
+// The file containing the message is returned as the schema of the encoded
+// data, the producer registers it and prefixes the data with its ID. Derived
+// descriptors are cached in the cache, if it isn't nil.
+func encodeProtobuf(env kafkaconnect.Envelope, cache *lru.Cache[string, protobufEncoding]) (encodedData, error) {
+	enc, err := cachedEncoding(cache, &env.Schema, newProtobufEncoding)
+	if err != nil {
+		return encodedData{}, fmt.Errorf("failed to convert kafka connect schema to protobuf: %w", err)
+	}
+	payload, err := normalizePayload(env.Payload)
+	if err != nil {
+		return encodedData{}, fmt.Errorf("failed to normalize payload: %w", err)
+	}
+
+	schema := &env.Schema
+	if schema.Type != kafkaconnect.TypeStruct {
+		// the message wraps the value in a single field
+		schema = protobufWrapperSchema(schema)
+		payload = map[string]any{"value": payload}
+	}
+	msg := dynamicpb.NewMessage(enc.descriptor)
+	if payload != nil {
+		m, err := connectStruct(payload)
+		if err != nil {
+			return encodedData{}, fmt.Errorf("failed to encode payload as protobuf: %w", err)
+		}
+		if err := setProtobufFields(msg, schema, m); err != nil {
+			return encodedData{}, fmt.Errorf("failed to encode payload as protobuf: %w", err)
+		}
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return encodedData{}, fmt.Errorf("failed to encode payload as protobuf: %w", err)
+	}
+	return encodedData{bytes: b, schema: &enc.registrySchema}, nil
+}
+
+// protobufDescriptor converts the kafka connect schema into a proto3 message
+// descriptor. Struct fields are numbered by protobufFieldNumber, nested
+// structs are converted into nested messages. Schemas that are not structs
+// are wrapped in a message with a single field called "value". Protobuf has
+// no notion of null, null values are omitted and are decoded as the default
+// value of the field.
+func protobufDescriptor(s *kafkaconnect.Schema) (protoreflect.MessageDescriptor, error) {
+	if s.Type != kafkaconnect.TypeStruct {
+		s = protobufWrapperSchema(s)
+	}
+	name := "ConnectDefault"
+	if s.Name != "" {
+		parts := strings.Split(s.Name, ".")
+		name = connectIdentifier(parts[len(parts)-1])
+	}
+
+	msg, err := protobufMessage(name, s)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("kafkaconnect.proto"),
+		Package:     proto.String("kafkaconnect"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{msg},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return fd.Messages().Get(0), nil
+}
+
+// Field numbers between 19000 and 19999 are reserved for the Protobuf
+// implementation.
+const (
+	protobufFirstReservedNum = 19000
+	protobufLastReservedNum  = 19999
+)
+
+// protobufFieldNumber returns the number of the i-th field of a message.
+// Fields are numbered sequentially in the order of the schema, which is
+// stable (fields of schemas inferred from the data are sorted by name), so
+// small numbers with short tags are used. Consumers decode the data with the
+// schema it references in the schema registry, so numbers can change between
+// schemas.
+func protobufFieldNumber(i int) int32 {
+	n := int32(i) + 1 //nolint:gosec // messages don't have that many fields
+	if n >= protobufFirstReservedNum {
+		n += protobufLastReservedNum - protobufFirstReservedNum + 1
+	}
+	return n
+}
+
+func protobufWrapperSchema(s *kafkaconnect.Schema) *kafkaconnect.Schema {
+	field := *s
+	field.Field = "value"
+	return &kafkaconnect.Schema{
+		Type:   kafkaconnect.TypeStruct,
+		Fields: []kafkaconnect.Schema{field},
+	}
+}
+
+func protobufMessage(name string, s *kafkaconnect.Schema) (*descriptorpb.DescriptorProto, error) {
+	msg := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	names := connectFieldNames(s.Fields)
+
+	// Nested types share the scope with fields, make sure they don't collide.
+	// Map entries need to be named after the field, so they are reserved
+	// first.
+	taken := make(map[string]bool, len(names))
+	for i, n := range names {
+		taken[n] = true
+		if s.Fields[i].Type == kafkaconnect.TypeMap {
+			taken[protobufTypeName(n)+"Entry"] = true
+		}
+	}
+	nestedName := func(field string) string {
+		name := protobufTypeName(field)
+		for i := 2; taken[name]; i++ {
+			name = protobufTypeName(field) + strconv.Itoa(i)
+		}
+		taken[name] = true
+		return name
+	}
+	nestedMessage := func(field string, s *kafkaconnect.Schema) (string, error) {
+		nested, err := protobufMessage(nestedName(field), s)
+		if err != nil {
+			return "", err
+		}
+		msg.NestedType = append(msg.NestedType, nested)
+		return nested.GetName(), nil
+	}
+
+	for i := range s.Fields {
+		f := &s.Fields[i]
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(names[i]),
+			JsonName: proto.String(names[i]),
+			Number:   proto.Int32(protobufFieldNumber(i)),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+
+		var err error
+		elem := f
+		switch f.Type {
+		case kafkaconnect.TypeArray:
+			fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			elem = elemSchema(f.Items)
+			if elem.Type == kafkaconnect.TypeArray || elem.Type == kafkaconnect.TypeMap {
+				return nil, fmt.Errorf("field %q: nested arrays and maps in arrays are not supported", f.Field)
+			}
+		case kafkaconnect.TypeMap:
+			var valueType string
+			if values := elemSchema(f.Values); values.Type == kafkaconnect.TypeStruct {
+				valueType, err = nestedMessage(names[i]+"_value", values)
+				if err != nil {
+					return nil, fmt.Errorf("field %q: %w", f.Field, err)
+				}
+			}
+			entry, err := protobufMapEntry(protobufTypeName(names[i])+"Entry", f, valueType)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Field, err)
+			}
+			msg.NestedType = append(msg.NestedType, entry)
+			fd.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			fd.TypeName = proto.String(entry.GetName())
+			msg.Field = append(msg.Field, fd)
+			continue
+		}
+
+		if elem.Type == kafkaconnect.TypeStruct {
+			typeName, err := nestedMessage(names[i], elem)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Field, err)
+			}
+			fd.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			fd.TypeName = proto.String(typeName)
+		} else {
+			typ, err := protobufScalarType(elem.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Field, err)
+			}
+			fd.Type = typ.Enum()
+		}
+		msg.Field = append(msg.Field, fd)
+	}
+	return msg, nil
+}
+
+// protobufMapEntry returns the map entry message of a map field, the key and
+// value are stored in fields 1 and 2. Map entries can't contain nested types,
+// struct values are declared as siblings of the entry, valueType is the name
+// of that message.
+func protobufMapEntry(name string, s *kafkaconnect.Schema, valueType string) (*descriptorpb.DescriptorProto, error) {
+	keys, values := elemSchema(s.Keys), elemSchema(s.Values)
+	switch keys.Type {
+	case kafkaconnect.TypeFloat, kafkaconnect.TypeDouble, kafkaconnect.TypeBytes,
+		kafkaconnect.TypeArray, kafkaconnect.TypeMap, kafkaconnect.TypeStruct:
+		return nil, fmt.Errorf("map keys of type %q are not supported", keys.Type)
+	}
+	keyType, _ := protobufScalarType(keys.Type)
+
+	value := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("value"),
+		JsonName: proto.String("value"),
+		Number:   proto.Int32(2),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	switch values.Type {
+	case kafkaconnect.TypeArray, kafkaconnect.TypeMap:
+		return nil, fmt.Errorf("map values of type %q are not supported", values.Type)
+	case kafkaconnect.TypeStruct:
+		value.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+		value.TypeName = proto.String(valueType)
+	default:
+		typ, err := protobufScalarType(values.Type)
+		if err != nil {
+			return nil, err
+		}
+		value.Type = typ.Enum()
+	}
+
+	return &descriptorpb.DescriptorProto{
+		Name: proto.String(name),
+		Field: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("key"),
+			JsonName: proto.String("key"),
+			Number:   proto.Int32(1),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     keyType.Enum(),
+		}, value},
+		Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+	}, nil
+}
+
+// protobufSchemaText returns the file in the Protobuf language, which is the
+// format the schema registry expects. It supports the subset of the language
+// used by protobufDescriptor, i.e. scalar, message, repeated and map fields
+// in nested messages.
+func protobufSchemaText(fd *descriptorpb.FileDescriptorProto) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "syntax = %q;\npackage %s;\n", fd.GetSyntax(), fd.GetPackage())
+	for _, m := range fd.GetMessageType() {
+		sb.WriteString("\n")
+		writeProtobufMessage(&sb, m, "")
+	}
+	return sb.String()
+}
+
+func writeProtobufMessage(sb *strings.Builder, m *descriptorpb.DescriptorProto, indent string) {
+	fmt.Fprintf(sb, "%smessage %s {\n", indent, m.GetName())
+	entries := make(map[string]*descriptorpb.DescriptorProto)
+	for _, nested := range m.GetNestedType() {
+		if nested.GetOptions().GetMapEntry() {
+			entries[nested.GetName()] = nested // declared by the map field
+			continue
+		}
+		writeProtobufMessage(sb, nested, indent+"  ")
+	}
+	for _, f := range m.GetField() {
+		typ := protobufTypeText(f)
+		// type names are fully qualified, map entries are nested in m
+		name := f.GetTypeName()[strings.LastIndex(f.GetTypeName(), ".")+1:]
+		switch entry, ok := entries[name]; {
+		case ok:
+			typ = fmt.Sprintf("map<%s, %s>", protobufTypeText(entry.GetField()[0]), protobufTypeText(entry.GetField()[1]))
+		case f.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+			typ = "repeated " + typ
+		}
+		fmt.Fprintf(sb, "%s  %s %s = %d;\n", indent, typ, f.GetName(), f.GetNumber())
+	}
+	fmt.Fprintf(sb, "%s}\n", indent)
+}
+
+// protobufTypeText returns the type of the field in the Protobuf language.
+func protobufTypeText(f *descriptorpb.FieldDescriptorProto) string {
+	if f.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		return f.GetTypeName()
+	}
+	// e.g. TYPE_INT64 is int64
+	return strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+}
+
+// protobufTypeName converts a field name into a camel cased type name, the
+// same way protoc derives names of map entries.
+func protobufTypeName(field string) string {
+	var sb strings.Builder
+	upperNext := true
+	for _, r := range field {
+		switch {
+		case r == '_':
+			upperNext = true
+		case upperNext:
+			sb.WriteRune(unicode.ToUpper(r))
+			upperNext = false
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 0 {
+		return "Field"
+	}
+	return sb.String()
+}
+
+func protobufScalarType(t kafkaconnect.Type) (descriptorpb.FieldDescriptorProto_Type, error) {
+	switch t {
+	case kafkaconnect.TypeBoolean:
+		return descriptorpb.FieldDescriptorProto_TYPE_BOOL, nil
+	case kafkaconnect.TypeInt8, kafkaconnect.TypeInt16, kafkaconnect.TypeInt32:
+		return descriptorpb.FieldDescriptorProto_TYPE_INT32, nil
+	case kafkaconnect.TypeInt64:
+		return descriptorpb.FieldDescriptorProto_TYPE_INT64, nil
+	case kafkaconnect.TypeFloat:
+		return descriptorpb.FieldDescriptorProto_TYPE_FLOAT, nil
+	case kafkaconnect.TypeDouble:
+		return descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, nil
+	case kafkaconnect.TypeBytes:
+		return descriptorpb.FieldDescriptorProto_TYPE_BYTES, nil
+	case kafkaconnect.TypeString:
+		return descriptorpb.FieldDescriptorProto_TYPE_STRING, nil
+	default:
+		return 0, fmt.Errorf("unsupported type %q", t)
+	}
+}
+
+// setProtobufFields sets the fields of the message to the values of the
+// normalized struct. The fields of the message descriptor are in the same
+// order as the fields of the schema.
+func setProtobufFields(msg protoreflect.Message, s *kafkaconnect.Schema, m map[string]any) error {
+	fields := msg.Descriptor().Fields()
+	for i := range s.Fields {
+		f := &s.Fields[i]
+		fd := fields.Get(i)
+		v := m[f.Field]
+		if v == nil {
+			continue // null values are omitted
+		}
+
+		var err error
+		switch {
+		case fd.IsMap():
+			err = setProtobufMap(msg.Mutable(fd).Map(), f, v)
+		case fd.IsList():
+			err = setProtobufList(msg.Mutable(fd).List(), f, v)
+		default:
+			var val protoreflect.Value
+			val, err = protobufValue(func() protoreflect.Value { return msg.NewField(fd) }, f, v)
+			if err == nil {
+				msg.Set(fd, val)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("field %q: %w", f.Field, err)
+		}
+	}
+	return nil
+}
+
+func setProtobufList(list protoreflect.List, s *kafkaconnect.Schema, v any) error {
+	items, err := connectArray(v)
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		if item == nil {
+			return fmt.Errorf("item %d: protobuf doesn't support null items in arrays", i)
+		}
+		val, err := protobufValue(list.NewElement, elemSchema(s.Items), item)
+		if err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+		list.Append(val)
+	}
+	return nil
+}
+
+func setProtobufMap(mp protoreflect.Map, s *kafkaconnect.Schema, v any) error {
+	m, err := connectStruct(v)
+	if err != nil {
+		return err
+	}
+	keys := elemSchema(s.Keys)
+	for k, mv := range m {
+		key, err := protobufValue(nil, keys, mapKey(keys, k))
+		if err != nil {
+			return fmt.Errorf("key %q: %w", k, err)
+		}
+		if mv == nil {
+			return fmt.Errorf("key %q: protobuf doesn't support null map values", k)
+		}
+		val, err := protobufValue(mp.NewValue, elemSchema(s.Values), mv)
+		if err != nil {
+			return fmt.Errorf("key %q: %w", k, err)
+		}
+		mp.Set(key.MapKey(), val)
+	}
+	return nil
+}
+
+// protobufValue converts a normalized value into a protobuf value. The
+// function newMessage is used to create messages for struct values.
+func protobufValue(newMessage func() protoreflect.Value, s *kafkaconnect.Schema, v any) (protoreflect.Value, error) {
+	switch s.Type {
+	case kafkaconnect.TypeBoolean:
+		b, err := connectBool(v)
+		return protoreflect.ValueOfBool(b), err
+	case kafkaconnect.TypeInt8, kafkaconnect.TypeInt16, kafkaconnect.TypeInt32:
+		i, err := connectInt(v, 32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case kafkaconnect.TypeInt64:
+		i, err := connectInt(v, 64)
+		return protoreflect.ValueOfInt64(i), err
+	case kafkaconnect.TypeFloat:
+		f, err := connectFloat(v)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case kafkaconnect.TypeDouble:
+		f, err := connectFloat(v)
+		return protoreflect.ValueOfFloat64(f), err
+	case kafkaconnect.TypeBytes:
+		b, err := connectBytes(v)
+		return protoreflect.ValueOfBytes(b), err
+	case kafkaconnect.TypeString:
+		str, err := connectString(v)
+		return protoreflect.ValueOfString(str), err
+	case kafkaconnect.TypeStruct:
+		if newMessage == nil {
+			return protoreflect.Value{}, errors.New("unexpected struct")
+		}
+		m, err := connectStruct(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		val := newMessage()
+		if err := setProtobufFields(val.Message(), s, m); err != nil {
+			return protoreflect.Value{}, err
+		}
+		return val, nil
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported type %q", s.Type)
+	}
+}
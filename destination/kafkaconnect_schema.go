@@ -0,0 +1,419 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	cschema "github.com/conduitio/conduit-commons/schema"
+	"github.com/conduitio/conduit-connector-sdk/kafkaconnect"
+	sdkschema "github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/goccy/go-json"
+	"github.com/hamba/avro/v2"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// Names of logical Kafka Connect types, see
+// https://kafka.apache.org/documentation/#connect_schemas and
+// https://debezium.io/documentation/reference/stable/connectors/postgresql.html#postgresql-temporal-types.
+const (
+	connectDecimal        = "org.apache.kafka.connect.data.Decimal"
+	connectDate           = "org.apache.kafka.connect.data.Date"
+	connectTime           = "org.apache.kafka.connect.data.Time"
+	connectTimestamp      = "org.apache.kafka.connect.data.Timestamp"
+	connectMicroTime      = "io.debezium.time.MicroTime"
+	connectMicroTimestamp = "io.debezium.time.MicroTimestamp"
+	connectUUID           = "io.debezium.data.Uuid"
+
+	connectDecimalScale     = "scale"
+	connectDecimalPrecision = "connect.decimal.precision"
+)
+
+// schemaEncoder is a dataEncoder that can use the schema attached to the
+// record instead of inferring it from the data.
+type schemaEncoder interface {
+	dataEncoder
+	// EncodeWithSchema encodes the data using the schema. If the schema is
+	// nil, it behaves like Encode.
+	EncodeWithSchema(opencdc.Data, *kafkaconnect.Schema) (encodedData, error)
+}
+
+// connectSchemas fetches schemas attached to records (i.e. referenced by the
+// opencdc.key.schema.* and opencdc.payload.schema.* metadata fields) from the
+// schema service and converts them into kafka connect schemas. It is safe for
+// concurrent use.
+type connectSchemas struct {
+	// cache contains converted schemas, keyed by subject and version.
+	cache *lru.Cache[string, *kafkaconnect.Schema]
+	// get fetches a schema, it's sdkschema.Get by default.
+	get func(ctx context.Context, subject string, version int) (cschema.Schema, error)
+}
+
+func newConnectSchemas() (*connectSchemas, error) {
+	cache, err := lru.New[string, *kafkaconnect.Schema](topicCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &connectSchemas{cache: cache, get: sdkschema.Get}, nil
+}
+
+// keySchema returns the kafka connect schema of the record key, or nil if the
+// record has no attached key schema.
+func (s *connectSchemas) keySchema(ctx context.Context, r opencdc.Record) (*kafkaconnect.Schema, error) {
+	subject, errSubject := r.Metadata.GetKeySchemaSubject()
+	version, errVersion := r.Metadata.GetKeySchemaVersion()
+	return s.schema(ctx, subject, version, errors.Join(errSubject, errVersion))
+}
+
+// payloadSchema returns the kafka connect schema of the record payload, or nil
+// if the record has no attached payload schema.
+func (s *connectSchemas) payloadSchema(ctx context.Context, r opencdc.Record) (*kafkaconnect.Schema, error) {
+	subject, errSubject := r.Metadata.GetPayloadSchemaSubject()
+	version, errVersion := r.Metadata.GetPayloadSchemaVersion()
+	return s.schema(ctx, subject, version, errors.Join(errSubject, errVersion))
+}
+
+func (s *connectSchemas) schema(ctx context.Context, subject string, version int, metadataErr error) (*kafkaconnect.Schema, error) {
+	switch {
+	case s == nil:
+		return nil, nil //nolint:nilnil // no schemas, the schema is inferred
+	case errors.Is(metadataErr, opencdc.ErrMetadataFieldNotFound):
+		return nil, nil //nolint:nilnil // no attached schema, the schema is inferred
+	case metadataErr != nil:
+		return nil, fmt.Errorf("invalid schema metadata: %w", metadataErr)
+	}
+
+	key := subject + ":" + strconv.Itoa(version)
+	if cs, ok := s.cache.Get(key); ok {
+		return cs, nil
+	}
+
+	sch, err := s.get(ctx, subject, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema %q version %d: %w", subject, version, err)
+	}
+	if sch.Type != cschema.TypeAvro {
+		return nil, fmt.Errorf("schema %q version %d: unsupported schema type %v", subject, version, sch.Type)
+	}
+	as, err := avro.ParseBytes(sch.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %q version %d: %w", subject, version, err)
+	}
+	cs, err := connectSchemaFromAvro(as)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert schema %q version %d to kafka connect schema: %w", subject, version, err)
+	}
+
+	s.cache.Add(key, cs)
+	return cs, nil
+}
+
+// connectSchemaFromAvro converts an Avro schema into a kafka connect schema.
+// Nullable unions are converted into optional types, logical types into the
+// corresponding logical kafka connect types.
+func connectSchemaFromAvro(s avro.Schema) (*kafkaconnect.Schema, error) {
+	switch s := s.(type) {
+	case *avro.RefSchema:
+		return connectSchemaFromAvro(s.Schema())
+	case *avro.UnionSchema:
+		if !s.Nullable() {
+			return nil, errors.New("only unions of null and one other type are supported")
+		}
+		_, typ := s.Indices()
+		cs, err := connectSchemaFromAvro(s.Types()[typ])
+		if err != nil {
+			return nil, err
+		}
+		cs.Optional = true
+		return cs, nil
+	case *avro.RecordSchema:
+		cs := &kafkaconnect.Schema{
+			Type: kafkaconnect.TypeStruct,
+			Name: s.FullName(),
+			Doc:  s.Doc(),
+		}
+		for _, f := range s.Fields() {
+			fs, err := connectSchemaFromAvro(f.Type())
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Name(), err)
+			}
+			fs.Field = f.Name()
+			fs.Doc = f.Doc()
+			cs.Fields = append(cs.Fields, *fs)
+		}
+		return cs, nil
+	case *avro.ArraySchema:
+		items, err := connectSchemaFromAvro(s.Items())
+		if err != nil {
+			return nil, err
+		}
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeArray, Items: items}, nil
+	case *avro.MapSchema:
+		values, err := connectSchemaFromAvro(s.Values())
+		if err != nil {
+			return nil, err
+		}
+		return &kafkaconnect.Schema{
+			Type:   kafkaconnect.TypeMap,
+			Keys:   &kafkaconnect.Schema{Type: kafkaconnect.TypeString},
+			Values: values,
+		}, nil
+	case *avro.EnumSchema:
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeString, Name: s.FullName()}, nil
+	case *avro.FixedSchema:
+		if ls, ok := s.Logical().(*avro.DecimalLogicalSchema); ok {
+			return connectDecimalSchema(ls), nil
+		}
+		if s.Logical() != nil {
+			return nil, fmt.Errorf("unsupported logical type %q", s.Logical().Type())
+		}
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeBytes, Name: s.FullName()}, nil
+	case *avro.PrimitiveSchema:
+		return connectSchemaFromAvroPrimitive(s)
+	default:
+		return nil, fmt.Errorf("unsupported avro type %q", s.Type())
+	}
+}
+
+func connectSchemaFromAvroPrimitive(s *avro.PrimitiveSchema) (*kafkaconnect.Schema, error) {
+	if ls := s.Logical(); ls != nil {
+		switch ls.Type() {
+		case avro.Decimal:
+			return connectDecimalSchema(ls.(*avro.DecimalLogicalSchema)), nil
+		case avro.UUID:
+			return &kafkaconnect.Schema{Type: kafkaconnect.TypeString, Name: connectUUID, Version: 1}, nil
+		case avro.Date:
+			return &kafkaconnect.Schema{Type: kafkaconnect.TypeInt32, Name: connectDate, Version: 1}, nil
+		case avro.TimeMillis:
+			return &kafkaconnect.Schema{Type: kafkaconnect.TypeInt32, Name: connectTime, Version: 1}, nil
+		case avro.TimeMicros:
+			return &kafkaconnect.Schema{Type: kafkaconnect.TypeInt64, Name: connectMicroTime, Version: 1}, nil
+		case avro.TimestampMillis, avro.LocalTimestampMillis:
+			return &kafkaconnect.Schema{Type: kafkaconnect.TypeInt64, Name: connectTimestamp, Version: 1}, nil
+		case avro.TimestampMicros, avro.LocalTimestampMicros:
+			return &kafkaconnect.Schema{Type: kafkaconnect.TypeInt64, Name: connectMicroTimestamp, Version: 1}, nil
+		default:
+			return nil, fmt.Errorf("unsupported logical type %q", ls.Type())
+		}
+	}
+
+	switch s.Type() {
+	case avro.Boolean:
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeBoolean}, nil
+	case avro.Int:
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeInt32}, nil
+	case avro.Long:
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeInt64}, nil
+	case avro.Float:
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeFloat}, nil
+	case avro.Double:
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeDouble}, nil
+	case avro.Bytes:
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeBytes}, nil
+	case avro.String:
+		return &kafkaconnect.Schema{Type: kafkaconnect.TypeString}, nil
+	default:
+		return nil, fmt.Errorf("unsupported avro type %q", s.Type())
+	}
+}
+
+func connectDecimalSchema(ls *avro.DecimalLogicalSchema) *kafkaconnect.Schema {
+	return &kafkaconnect.Schema{
+		Type:    kafkaconnect.TypeBytes,
+		Name:    connectDecimal,
+		Version: 1,
+		Parameters: map[string]string{
+			connectDecimalScale:     strconv.Itoa(ls.Scale()),
+			connectDecimalPrecision: strconv.Itoa(ls.Precision()),
+		},
+	}
+}
+
+// connectPayload converts the value into the representation expected by the
+// kafka connect schema, e.g. timestamps into milliseconds since epoch and
+// decimals into their unscaled bytes. Values are either decoded by the schema
+// middleware (e.g. time.Time, *big.Rat) or, in case of records serialized
+// as Debezium envelopes, their JSON representation.
+func connectPayload(s *kafkaconnect.Schema, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch s.Type {
+	case kafkaconnect.TypeStruct:
+		m, err := connectMap(v)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]any, len(s.Fields))
+		for i := range s.Fields {
+			f := &s.Fields[i]
+			fv, err := connectPayload(f, m[f.Field])
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Field, err)
+			}
+			out[f.Field] = fv
+		}
+		return out, nil
+	case kafkaconnect.TypeMap:
+		m, err := connectMap(v)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]any, len(m))
+		for k, mv := range m {
+			out[k], err = connectPayload(elemSchema(s.Values), mv)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k, err)
+			}
+		}
+		return out, nil
+	case kafkaconnect.TypeArray:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, fmt.Errorf("expected an array, got %T", v)
+		}
+		out := make([]any, rv.Len())
+		for i := range out {
+			var err error
+			out[i], err = connectPayload(elemSchema(s.Items), rv.Index(i).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		return out, nil
+	}
+
+	switch s.Name {
+	case connectDecimal:
+		return connectDecimalBytes(s, v)
+	case connectDate:
+		t, err := connectTimeValue(v)
+		return int32(t.Unix() / (24 * 60 * 60)), err
+	case connectTimestamp:
+		t, err := connectTimeValue(v)
+		return t.UnixMilli(), err
+	case connectMicroTimestamp:
+		t, err := connectTimeValue(v)
+		return t.UnixMicro(), err
+	case connectTime:
+		d, err := connectDurationValue(v)
+		return int32(d.Milliseconds()), err
+	case connectMicroTime:
+		d, err := connectDurationValue(v)
+		return d.Microseconds(), err
+	}
+
+	if s.Type == kafkaconnect.TypeBytes {
+		// fixed values are decoded as byte arrays
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return b, nil
+		}
+	}
+	return v, nil
+}
+
+func connectMap(v any) (map[string]any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		return v, nil
+	case opencdc.StructuredData:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("expected a struct, got %T", v)
+	}
+}
+
+func connectTimeValue(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return time.Parse(time.RFC3339Nano, v)
+	default:
+		return time.Time{}, fmt.Errorf("expected a time, got %T", v)
+	}
+}
+
+func connectDurationValue(v any) (time.Duration, error) {
+	switch v := v.(type) {
+	case time.Duration:
+		return v, nil
+	case json.Number:
+		i, err := v.Int64()
+		return time.Duration(i), err
+	case float64:
+		return time.Duration(v), nil
+	default:
+		return 0, fmt.Errorf("expected a duration, got %T", v)
+	}
+}
+
+// connectDecimalBytes returns the unscaled value of the decimal as a big-endian
+// two's complement byte slice.
+func connectDecimalBytes(s *kafkaconnect.Schema, v any) ([]byte, error) {
+	var r *big.Rat
+	switch v := v.(type) {
+	case *big.Rat:
+		r = v
+	case string:
+		var ok bool
+		r, ok = new(big.Rat).SetString(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid decimal %q", v)
+		}
+	default:
+		return nil, fmt.Errorf("expected a decimal, got %T", v)
+	}
+
+	scale, err := strconv.Atoi(s.Parameters[connectDecimalScale])
+	if err != nil {
+		return nil, fmt.Errorf("invalid decimal scale: %w", err)
+	}
+	unscaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	if !unscaled.IsInt() {
+		return nil, fmt.Errorf("decimal %v has more than %d decimal places", r.FloatString(scale+1), scale)
+	}
+	return twosComplement(unscaled.Num()), nil
+}
+
+// twosComplement returns the big-endian two's complement representation of
+// the integer using the minimal number of bytes.
+func twosComplement(i *big.Int) []byte {
+	if i.Sign() >= 0 {
+		b := i.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+		return b
+	}
+	// -i = ^(i-1) for the magnitude, add a byte to fit the sign bit
+	n := len(new(big.Int).Neg(i).Bytes()) + 1
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(n*8))
+	b := new(big.Int).Add(mod, i).Bytes()
+	for len(b) > 1 && b[0] == 0xFF && b[1]&0x80 != 0 {
+		b = b[1:]
+	}
+	return b
+}
@@ -0,0 +1,216 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-sdk/kafkaconnect"
+	sdkschema "github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/goccy/go-json"
+	"github.com/hamba/avro/v2"
+	"github.com/matryer/is"
+)
+
+const testAvroSchema = `{
+  "type": "record",
+  "name": "Order",
+  "namespace": "shop",
+  "fields": [
+    {"name": "id", "type": "int"},
+    {"name": "note", "type": ["null", "string"], "default": null},
+    {"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+    {"name": "createdAt", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+    {"name": "day", "type": {"type": "int", "logicalType": "date"}},
+    {"name": "tags", "type": {"type": "array", "items": "string"}},
+    {"name": "attrs", "type": {"type": "map", "values": "long"}},
+    {"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "DONE"]}}
+  ]
+}`
+
+func TestConnectSchemaFromAvro(t *testing.T) {
+	is := is.New(t)
+
+	as, err := avro.Parse(testAvroSchema)
+	is.NoErr(err)
+	got, err := connectSchemaFromAvro(as)
+	is.NoErr(err)
+
+	is.Equal(got, &kafkaconnect.Schema{
+		Type: kafkaconnect.TypeStruct,
+		Name: "shop.Order",
+		Fields: []kafkaconnect.Schema{
+			{Field: "id", Type: kafkaconnect.TypeInt32},
+			{Field: "note", Type: kafkaconnect.TypeString, Optional: true},
+			{
+				Field: "amount", Type: kafkaconnect.TypeBytes, Name: connectDecimal, Version: 1,
+				Parameters: map[string]string{"scale": "2", "connect.decimal.precision": "10"},
+			},
+			{Field: "createdAt", Type: kafkaconnect.TypeInt64, Name: connectTimestamp, Version: 1},
+			{Field: "day", Type: kafkaconnect.TypeInt32, Name: connectDate, Version: 1},
+			{Field: "tags", Type: kafkaconnect.TypeArray, Items: &kafkaconnect.Schema{Type: kafkaconnect.TypeString}},
+			{
+				Field: "attrs", Type: kafkaconnect.TypeMap,
+				Keys:   &kafkaconnect.Schema{Type: kafkaconnect.TypeString},
+				Values: &kafkaconnect.Schema{Type: kafkaconnect.TypeInt64},
+			},
+			{Field: "status", Type: kafkaconnect.TypeString, Name: "shop.Status"},
+		},
+	})
+
+	_, err = connectSchemaFromAvro(avro.MustParse(`["string", "int"]`))
+	is.True(err != nil) // only nullable unions are supported
+}
+
+func TestTwosComplement(t *testing.T) {
+	testCases := []struct {
+		in   int64
+		want []byte
+	}{
+		{in: 0, want: []byte{0x00}},
+		{in: 1, want: []byte{0x01}},
+		{in: 127, want: []byte{0x7F}},
+		{in: 128, want: []byte{0x00, 0x80}},
+		{in: -1, want: []byte{0xFF}},
+		{in: -128, want: []byte{0x80}},
+		{in: -129, want: []byte{0xFF, 0x7F}},
+		{in: 12345, want: []byte{0x30, 0x39}},
+	}
+	for _, tc := range testCases {
+		t.Run(strconv.FormatInt(tc.in, 10), func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(twosComplement(big.NewInt(tc.in)), tc.want)
+		})
+	}
+}
+
+// testOrder returns an order as it is decoded by the schema middleware.
+func testOrder() opencdc.StructuredData {
+	return opencdc.StructuredData{
+		"id":        1,
+		"note":      nil,
+		"amount":    big.NewRat(12345, 100),
+		"createdAt": time.UnixMilli(1700000000123).UTC(),
+		"day":       time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		"tags":      []any{"a"},
+		"attrs":     map[string]any{"x": int64(1)},
+		"status":    "NEW",
+	}
+}
+
+func TestFranzProducer_PrepareRecord_AttachedSchema(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	sch, err := sdkschema.Create(ctx, sdkschema.TypeAvro, t.Name(), []byte(testAvroSchema))
+	is.NoErr(err)
+
+	schemas, err := newConnectSchemas()
+	is.NoErr(err)
+	p := &FranzProducer{
+		keyEncoder: kafkaConnectEncoder{},
+		schemas:    schemas,
+	}
+
+	r := opencdc.Record{
+		Metadata: opencdc.Metadata{},
+		Key:      testOrder(),
+		Payload:  opencdc.Change{After: opencdc.RawData("foo")},
+	}
+	r.Metadata.SetKeySchemaSubject(sch.Subject)
+	r.Metadata.SetKeySchemaVersion(sch.Version)
+
+	rec, _, err := p.prepareRecord(ctx, r)
+	is.NoErr(err)
+
+	var got struct {
+		Schema  kafkaconnect.Schema `json:"schema"`
+		Payload map[string]any      `json:"payload"`
+	}
+	is.NoErr(json.Unmarshal(rec.Key, &got))
+	is.Equal(got.Schema.Name, "shop.Order")
+	is.Equal(got.Schema.Fields[0].Type, kafkaconnect.TypeInt32) // reflection would infer int64
+	is.Equal(got.Schema.Fields[1].Optional, true)
+	is.Equal(got.Payload, map[string]any{
+		"id":        float64(1),
+		"note":      nil,
+		"amount":    "MDk=", // unscaled value 12345
+		"createdAt": float64(1700000000123),
+		"day":       float64(19724),
+		"tags":      []any{"a"},
+		"attrs":     map[string]any{"x": float64(1)},
+		"status":    "NEW",
+	})
+
+	// records without an attached schema use reflection
+	r.Metadata = opencdc.Metadata{}
+	r.Key = opencdc.StructuredData{"id": 1}
+	rec, _, err = p.prepareRecord(ctx, r)
+	is.NoErr(err)
+	is.NoErr(json.Unmarshal(rec.Key, &got))
+	is.Equal(got.Schema.Fields[0].Type, kafkaconnect.TypeInt64)
+
+	// records referencing a missing schema fail
+	r.Metadata.SetKeySchemaSubject("missing")
+	r.Metadata.SetKeySchemaVersion(1)
+	_, _, err = p.prepareRecord(ctx, r)
+	is.True(err != nil)
+}
+
+func TestKafkaConnectValueEncoder_AttachedSchema(t *testing.T) {
+	is := is.New(t)
+
+	as, err := avro.Parse(testAvroSchema)
+	is.NoErr(err)
+	payloadSchema, err := connectSchemaFromAvro(as)
+	is.NoErr(err)
+
+	// the Debezium serializer serializes the decoded values as JSON
+	after, err := json.Marshal(testOrder())
+	is.NoErr(err)
+	var sd opencdc.StructuredData
+	is.NoErr(json.Unmarshal(after, &sd))
+
+	env := kafkaconnect.DebeziumPayload{
+		After: sd,
+		Op:    kafkaconnect.DebeziumOpCreate,
+	}.ToEnvelope()
+	value, err := json.Marshal(env)
+	is.NoErr(err)
+
+	got, err := kafkaConnectValueEncoder{}.EncodeWithSchema(opencdc.RawData(value), payloadSchema)
+	is.NoErr(err)
+
+	var decoded struct {
+		Schema  kafkaconnect.Schema `json:"schema"`
+		Payload map[string]any      `json:"payload"`
+	}
+	is.NoErr(json.Unmarshal(got.bytes, &decoded))
+	is.Equal(got.schema, nil) // the JSON envelope embeds the schema
+
+	want := *payloadSchema
+	want.Field = "after"
+	want.Optional = true
+	is.Equal(decoded.Schema.Fields[1], want)
+	is.Equal(decoded.Schema.Fields[0].Name, "shop.Order") // before
+	is.Equal(decoded.Payload["after"].(map[string]any)["amount"], "MDk=")
+	is.Equal(decoded.Payload["after"].(map[string]any)["createdAt"], float64(1700000000123))
+	is.Equal(decoded.Payload["op"], "c")
+}
@@ -0,0 +1,407 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-kafka/common"
+	"github.com/conduitio/conduit-connector-sdk/kafkaconnect"
+	"github.com/goccy/go-json"
+	"github.com/hamba/avro/v2"
+	"github.com/matryer/is"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var testKafkaConnectKey = opencdc.StructuredData{
+	"id":     int64(1),
+	"ratio":  0.5,
+	"active": true,
+	"name":   "foo",
+	"tags":   []string{"a", "b"},
+	"nested": map[string]any{"x": int32(2), "table.name": "orders"},
+	"empty":  nil,
+}
+
+// decodeAvro decodes a value in the Avro binary encoding.
+func decodeAvro(t *testing.T, schema avro.Schema, b []byte) map[string]any {
+	is := is.New(t)
+	is.Helper()
+
+	var got map[string]any
+	is.NoErr(avro.Unmarshal(schema, b, &got))
+	return got
+}
+
+func TestKafkaConnectEncoder_Avro(t *testing.T) {
+	is := is.New(t)
+
+	got, err := kafkaConnectEncoder{encoding: "avro"}.Encode(testKafkaConnectKey)
+	is.NoErr(err)
+
+	s := kafkaconnect.Reflect(testKafkaConnectKey)
+	kafkaconnect.SortFields(s)
+	schema, err := avroSchema(s)
+	is.NoErr(err)
+	// consumers get the schema from the schema registry
+	is.Equal(got.schema, &registrySchema{Type: registryTypeAvro, Schema: schema.String()})
+
+	// structured data is optional, nullable unions of records and arrays are
+	// decoded as maps keyed by the type name
+	is.Equal(decodeAvro(t, schema, got.bytes), map[string]any{"ConnectDefault": map[string]any{
+		"id":     int64(1),
+		"ratio":  0.5,
+		"active": true,
+		"name":   "foo",
+		"tags":   map[string]any{"array": []any{"a", "b"}},
+		"nested": map[string]any{"ConnectDefault2": map[string]any{"x": 2, "table_name": "orders"}},
+		"empty":  nil,
+	}})
+
+	// the same data results in the same bytes, even though the fields of
+	// structured data are reflected in random order
+	for range 10 {
+		again, err := kafkaConnectEncoder{encoding: "avro"}.Encode(testKafkaConnectKey)
+		is.NoErr(err)
+		is.Equal(again, got)
+	}
+}
+
+func TestKafkaConnectEncoder_Protobuf(t *testing.T) {
+	is := is.New(t)
+
+	got, err := kafkaConnectEncoder{encoding: "protobuf"}.Encode(testKafkaConnectKey)
+	is.NoErr(err)
+
+	// consumers get the schema from the schema registry
+	is.Equal(got.schema, &registrySchema{Type: registryTypeProtobuf, Schema: `syntax = "proto3";
+package kafkaconnect;
+
+message ConnectDefault {
+  message Nested {
+    string table_name = 1;
+    int32 x = 2;
+  }
+  bool active = 1;
+  string empty = 2;
+  int64 id = 3;
+  string name = 4;
+  .kafkaconnect.ConnectDefault.Nested nested = 5;
+  double ratio = 6;
+  repeated string tags = 7;
+}
+`})
+
+	s := kafkaconnect.Reflect(testKafkaConnectKey)
+	kafkaconnect.SortFields(s)
+	md, err := protobufDescriptor(s)
+	is.NoErr(err)
+
+	msg := dynamicpb.NewMessage(md)
+	is.NoErr(proto.Unmarshal(got.bytes, msg))
+	b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	is.NoErr(err)
+
+	var decoded map[string]any
+	is.NoErr(json.Unmarshal(b, &decoded))
+	is.Equal(decoded, map[string]any{
+		"id":     "1", // protojson encodes int64 as strings
+		"ratio":  0.5,
+		"active": true,
+		"name":   "foo",
+		"tags":   []any{"a", "b"},
+		"nested": map[string]any{"x": float64(2), "table_name": "orders"},
+	})
+}
+
+func TestKafkaConnectEncoder_Protobuf_RawData(t *testing.T) {
+	is := is.New(t)
+
+	got, err := kafkaConnectEncoder{encoding: "protobuf"}.Encode(opencdc.RawData("not json"))
+	is.NoErr(err)
+
+	md, err := protobufDescriptor(kafkaconnect.Reflect(opencdc.RawData("not json")))
+	is.NoErr(err)
+	msg := dynamicpb.NewMessage(md)
+	is.NoErr(proto.Unmarshal(got.bytes, msg))
+	is.Equal(msg.Get(md.Fields().ByName("value")).Bytes(), []byte("not json"))
+}
+
+func TestProtobufSchemaText_Map(t *testing.T) {
+	is := is.New(t)
+
+	md, err := protobufDescriptor(&kafkaconnect.Schema{
+		Type: kafkaconnect.TypeStruct,
+		Name: "Order",
+		Fields: []kafkaconnect.Schema{{
+			Field:  "attrs",
+			Type:   kafkaconnect.TypeMap,
+			Keys:   &kafkaconnect.Schema{Type: kafkaconnect.TypeString},
+			Values: &kafkaconnect.Schema{Type: kafkaconnect.TypeInt64},
+		}, {
+			Field:  "items",
+			Type:   kafkaconnect.TypeMap,
+			Keys:   &kafkaconnect.Schema{Type: kafkaconnect.TypeString},
+			Values: &kafkaconnect.Schema{Type: kafkaconnect.TypeStruct, Fields: []kafkaconnect.Schema{{Field: "qty", Type: kafkaconnect.TypeInt32}}},
+		}},
+	})
+	is.NoErr(err)
+
+	// map entries are written as map fields
+	is.Equal(protobufSchemaText(protodesc.ToFileDescriptorProto(md.ParentFile())), `syntax = "proto3";
+package kafkaconnect;
+
+message Order {
+  message ItemsValue {
+    int32 qty = 1;
+  }
+  map<string, int64> attrs = 1;
+  map<string, .kafkaconnect.Order.ItemsValue> items = 2;
+}
+`)
+}
+
+func TestProtobufFieldNumber(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(protobufFieldNumber(0), int32(1))
+	is.Equal(protobufFieldNumber(18998), int32(18999))
+	// numbers reserved for the Protobuf implementation are skipped
+	is.Equal(protobufFieldNumber(18999), int32(20000))
+	is.Equal(protobufFieldNumber(19000), int32(20001))
+}
+
+func TestKafkaConnectEncoder_Cache(t *testing.T) {
+	for _, encoding := range []string{"avro", "protobuf"} {
+		t.Run(encoding, func(t *testing.T) {
+			is := is.New(t)
+
+			e, err := newKafkaConnectEncoder(encoding)
+			is.NoErr(err)
+			cacheLen := func() int {
+				if e.avroEncodings != nil {
+					return e.avroEncodings.Len()
+				}
+				return e.protobufEncodings.Len()
+			}
+
+			want, err := kafkaConnectEncoder{encoding: encoding}.Encode(testKafkaConnectKey)
+			is.NoErr(err)
+			for range 3 {
+				got, err := e.Encode(testKafkaConnectKey)
+				is.NoErr(err)
+				is.Equal(got, want)
+			}
+			is.Equal(cacheLen(), 1) // the schema is derived once
+
+			_, err = e.Encode(opencdc.StructuredData{"other": "schema"})
+			is.NoErr(err)
+			is.Equal(cacheLen(), 2)
+		})
+	}
+}
+
+func TestKafkaConnectValueEncoder_Debezium(t *testing.T) {
+	is := is.New(t)
+
+	env := kafkaconnect.DebeziumPayload{
+		After:  opencdc.StructuredData{"id": int64(9007199254740993), "status": "shipped"},
+		Source: opencdc.Metadata{"opencdc.collection": "orders"},
+		Op:     kafkaconnect.DebeziumOpCreate,
+	}.ToEnvelope()
+	env.Schema.Name = "orders.Envelope"
+	value, err := json.Marshal(env)
+	is.NoErr(err)
+
+	got, err := kafkaConnectValueEncoder{kafkaConnectEncoder{encoding: "avro"}}.Encode(opencdc.RawData(value))
+	is.NoErr(err)
+
+	for i := range env.Schema.Fields {
+		kafkaconnect.SortFields(&env.Schema.Fields[i])
+	}
+	schema, err := avroSchema(&env.Schema)
+	is.NoErr(err)
+	is.Equal(schema.(avro.NamedSchema).FullName(), "orders.Envelope")
+
+	is.Equal(got.schema.Schema, schema.String())
+	decoded := decodeAvro(t, schema, got.bytes)
+	is.Equal(decoded["op"], "c")
+	is.Equal(decoded["before"], nil)
+	is.Equal(decoded["after"], map[string]any{"ConnectDefault2": map[string]any{
+		"id":     int64(9007199254740993),
+		"status": "shipped",
+	}})
+}
+
+func TestFranzProducer_KafkaConnectEncoding(t *testing.T) {
+	testCases := []struct {
+		encoding         string
+		kafkaConnect     bool
+		wantKey          dataEncoder
+		wantValueEncoder bool
+	}{
+		{encoding: "json", kafkaConnect: false, wantKey: bytesEncoder{}},
+		{encoding: "avro", kafkaConnect: false, wantKey: bytesEncoder{}},
+		{encoding: "json", kafkaConnect: true, wantKey: kafkaConnectEncoder{encoding: "json"}, wantValueEncoder: true},
+		{encoding: "avro", kafkaConnect: true, wantKey: kafkaConnectEncoder{encoding: "avro"}, wantValueEncoder: true},
+		{encoding: "protobuf", kafkaConnect: true, wantKey: kafkaConnectEncoder{encoding: "protobuf"}, wantValueEncoder: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s/%v", tc.encoding, tc.kafkaConnect), func(t *testing.T) {
+			is := is.New(t)
+			cfg := Config{
+				Config:               common.Config{Servers: []string{"test-host:9092"}},
+				Topic:                "test",
+				Acks:                 "all",
+				BatchBytes:           1000012,
+				KafkaConnectEncoding: tc.encoding,
+				SchemaRegistryURL:    "http://localhost:8081",
+			}
+			if tc.kafkaConnect {
+				cfg = cfg.WithKafkaConnectKeyFormat()
+			}
+
+			p, err := NewFranzProducer(context.Background(), cfg)
+			is.NoErr(err)
+			defer p.Close(context.Background())
+
+			if kc, ok := p.keyEncoder.(kafkaConnectEncoder); ok {
+				is.Equal(kc.encoding, tc.wantKey.(kafkaConnectEncoder).encoding)
+			} else {
+				is.Equal(p.keyEncoder, tc.wantKey)
+			}
+			is.Equal(p.valueEncoder != nil, tc.wantValueEncoder)
+		})
+	}
+}
+
+// testRegistry is a schema registry that assigns sequential IDs to the
+// registered schemas.
+type testRegistry struct {
+	m        sync.Mutex
+	subjects map[string][]registrySchema
+	requests int
+}
+
+func newTestRegistry(t *testing.T) (*testRegistry, *registryClient) {
+	is := is.New(t)
+	tr := &testRegistry{subjects: make(map[string][]registrySchema)}
+	srv := httptest.NewServer(http.HandlerFunc(tr.ServeHTTP))
+	t.Cleanup(srv.Close)
+
+	cl, err := newRegistryClient(srv.URL+"/", "user", "pass", time.Second)
+	is.NoErr(err)
+	return tr, cl
+}
+
+func (tr *testRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tr.m.Lock()
+	defer tr.m.Unlock()
+	tr.requests++
+
+	user, pass, _ := r.BasicAuth()
+	subject, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/versions")
+	if user != "user" || pass != "pass" || r.Method != http.MethodPost || !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	tr.subjects[subject] = append(tr.subjects[subject], registrySchema{Type: req.SchemaType, Schema: req.Schema})
+	_ = json.NewEncoder(w).Encode(map[string]int{"id": tr.requests})
+}
+
+func TestFranzProducer_PrepareRecord_WireFormat(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	tr, registry := newTestRegistry(t)
+	kc, err := newKafkaConnectEncoder("avro")
+	is.NoErr(err)
+	p := &FranzProducer{
+		topic:        "orders",
+		keyEncoder:   kc,
+		valueEncoder: kafkaConnectValueEncoder{kc},
+		registry:     registry,
+	}
+
+	env := kafkaconnect.DebeziumPayload{
+		After: opencdc.StructuredData{"id": int64(1)},
+		Op:    kafkaconnect.DebeziumOpCreate,
+	}.ToEnvelope()
+	value, err := json.Marshal(env)
+	is.NoErr(err)
+
+	r := opencdc.Record{Key: testKafkaConnectKey}
+	r.SetSerializer(envelopeSerializer(value)) // like the debezium record format
+	for range 3 {
+		rec, _, err := p.prepareRecord(ctx, r)
+		is.NoErr(err)
+		is.Equal(len(rec.Headers), 0)
+
+		// the schemas are registered once under the topic name strategy
+		// subjects, the data references them by ID
+		key, err := kc.Encode(testKafkaConnectKey)
+		is.NoErr(err)
+		is.Equal(tr.subjects["orders-key"], []registrySchema{*key.schema})
+		is.Equal(rec.Key, registryWireFormat(1, registryTypeAvro, key.bytes))
+
+		is.Equal(len(tr.subjects["orders-value"]), 1)
+		is.Equal(rec.Value[:5], []byte{0, 0, 0, 0, 2})
+		schema, err := avro.Parse(tr.subjects["orders-value"][0].Schema)
+		is.NoErr(err)
+		decoded := decodeAvro(t, schema, rec.Value[5:])
+		is.Equal(decoded["op"], "c")
+	}
+	is.Equal(tr.requests, 2)
+
+	// without a schema registry the schemas can't be registered
+	p.registry = nil
+	_, _, err = p.prepareRecord(ctx, r)
+	is.True(err != nil)
+}
+
+func TestRegistryWireFormat(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(registryWireFormat(258, registryTypeAvro, []byte("data")), append([]byte{0, 0, 0, 1, 2}, "data"...))
+	// protobuf data is prefixed with the index of the first message
+	is.Equal(registryWireFormat(258, registryTypeProtobuf, []byte("data")), append([]byte{0, 0, 0, 1, 2, 0}, "data"...))
+}
+
+// envelopeSerializer serializes every record into the same envelope.
+type envelopeSerializer []byte
+
+func (s envelopeSerializer) Serialize(opencdc.Record) ([]byte, error) {
+	return s, nil
+}
@@ -0,0 +1,223 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-kafka/common"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+var ErrRecordTooLarge = errors.New("record too large")
+
+// recordOverhead is the upper bound of bytes a record adds to a record batch
+// on top of its key, value and headers. It consists of the record batch
+// header (61 bytes) and the varint encoded fields of the record itself.
+const recordOverhead = 61 + // record batch header
+	5 + // length
+	1 + // attributes
+	10 + // timestamp delta
+	5 + // offset delta
+	5 + // key length
+	5 + // value length
+	5 // headers count
+
+// recordSize returns the upper bound of the size of the record when it's
+// written to a record batch on its own.
+func recordSize(r *kgo.Record) int {
+	size := recordOverhead + len(r.Key) + len(r.Value)
+	for _, h := range r.Headers {
+		size += 5 + len(h.Key) + 5 + len(h.Value)
+	}
+	return size
+}
+
+// applyOversizedPolicy returns the Kafka records that should be produced for
+// the record. It returns no records if the record is skipped and multiple
+// records if the record is split into chunks.
+func (pr preparedRecord) applyOversizedPolicy(ctx context.Context, r opencdc.Record, rec *kgo.Record) ([]*kgo.Record, error) {
+	size := recordSize(rec)
+	if size <= pr.maxRecordBytes {
+		return []*kgo.Record{rec}, nil
+	}
+
+	switch pr.policy {
+	case "skip":
+		sdk.Logger(ctx).Warn().
+			Bytes("record_position", r.Position).
+			Int("size", size).
+			Int("batchBytes", pr.maxRecordBytes).
+			Msg("skipping record because it exceeds batchBytes")
+		return nil, nil
+	case "chunk":
+		return pr.chunkRecord(r, rec)
+	default: // fail, dlq
+		return nil, pr.tooLargeError(rec, size)
+	}
+}
+
+// chunkRecord splits the value of the record into chunks that fit into
+// batchBytes. The chunks are written to the same partition by chunkPartitioner.
+// The chunk ID is derived from the record, so a record that is produced again
+// (e.g. after a failed batch) results in the same chunks.
+func (pr preparedRecord) chunkRecord(r opencdc.Record, rec *kgo.Record) ([]*kgo.Record, error) {
+	id := chunkID(r, rec)
+
+	// The chunk count and index can't have more digits than the value length,
+	// use it to calculate the upper bound of the chunk headers.
+	probe := &kgo.Record{
+		Key: rec.Key,
+		Headers: append(slices.Clone(rec.Headers), common.Chunk{
+			ID:    id,
+			Index: len(rec.Value),
+			Count: len(rec.Value),
+		}.Headers()...),
+	}
+	chunkSize := pr.maxRecordBytes - recordSize(probe)
+	if chunkSize <= 0 {
+		return nil, pr.tooLargeError(rec, recordSize(rec))
+	}
+
+	count := (len(rec.Value) + chunkSize - 1) / chunkSize
+	chunks := make([]*kgo.Record, count)
+	for i := range chunks {
+		start := i * chunkSize
+		end := min(start+chunkSize, len(rec.Value))
+		chunks[i] = &kgo.Record{
+			Key:   rec.Key,
+			Value: rec.Value[start:end],
+			Topic: rec.Topic,
+			Headers: append(slices.Clone(rec.Headers), common.Chunk{
+				ID:    id,
+				Index: i,
+				Count: count,
+			}.Headers()...),
+		}
+	}
+	return chunks, nil
+}
+
+// chunkPartitioner is a kgo.Partitioner that writes all chunks of a record to
+// the same partition, otherwise the source can't reassemble the record. Chunks
+// of records with a key are written to the partition of the key (like the
+// default partitioner does), chunks of records without a key to the partition
+// of the chunk ID. Other records are partitioned by the wrapped partitioner.
+type chunkPartitioner struct {
+	partitioner kgo.Partitioner
+}
+
+func (p chunkPartitioner) ForTopic(topic string) kgo.TopicPartitioner {
+	tp := chunkTopicPartitioner{
+		TopicPartitioner: p.partitioner.ForTopic(topic),
+		keyPartitioner:   kgo.StickyKeyPartitioner(nil).ForTopic(topic),
+	}
+	// keep the optional interfaces of the wrapped partitioner
+	if _, ok := tp.TopicPartitioner.(kgo.TopicPartitionerOnNewBatch); ok {
+		return chunkBatchTopicPartitioner{tp}
+	}
+	return tp
+}
+
+type chunkTopicPartitioner struct {
+	kgo.TopicPartitioner
+	// keyPartitioner hashes keys the same way as the default partitioner.
+	keyPartitioner kgo.TopicPartitioner
+}
+
+// chunkHashKey returns the data hashed to partition the chunk, or nil if the
+// record is not a chunk.
+func chunkHashKey(r *kgo.Record) []byte {
+	chunk, ok, err := common.ParseChunk(r.Headers)
+	if err != nil || !ok {
+		return nil
+	}
+	if r.Key != nil {
+		return r.Key
+	}
+	return []byte(chunk.ID)
+}
+
+func (p chunkTopicPartitioner) RequiresConsistency(r *kgo.Record) bool {
+	return chunkHashKey(r) != nil || p.TopicPartitioner.RequiresConsistency(r)
+}
+
+func (p chunkTopicPartitioner) Partition(r *kgo.Record, n int) int {
+	if key := chunkHashKey(r); key != nil {
+		return p.keyPartitioner.Partition(&kgo.Record{Key: key}, n)
+	}
+	return p.TopicPartitioner.Partition(r, n)
+}
+
+func (p chunkTopicPartitioner) PartitionByBackup(r *kgo.Record, n int, backup kgo.TopicBackupIter) int {
+	if key := chunkHashKey(r); key != nil {
+		return p.keyPartitioner.Partition(&kgo.Record{Key: key}, n)
+	}
+	if bp, ok := p.TopicPartitioner.(kgo.TopicBackupPartitioner); ok {
+		return bp.PartitionByBackup(r, n, backup)
+	}
+	return p.TopicPartitioner.Partition(r, n)
+}
+
+// chunkBatchTopicPartitioner is a chunkTopicPartitioner wrapping a partitioner
+// that implements kgo.TopicPartitionerOnNewBatch.
+type chunkBatchTopicPartitioner struct {
+	chunkTopicPartitioner
+}
+
+func (p chunkBatchTopicPartitioner) OnNewBatch() {
+	p.TopicPartitioner.(kgo.TopicPartitionerOnNewBatch).OnNewBatch()
+}
+
+func chunkID(r opencdc.Record, rec *kgo.Record) string {
+	h := sha256.New()
+	h.Write(r.Position)
+	h.Write(rec.Key)
+	h.Write(rec.Value)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+func (pr preparedRecord) tooLargeError(rec *kgo.Record, size int) error {
+	return fmt.Errorf(
+		"%w: record size of %d bytes (key %d bytes, value %d bytes) exceeds batchBytes of %d bytes, "+
+			"increase batchBytes (and max.message.bytes of the topic) or change oversizedRecordPolicy",
+		ErrRecordTooLarge, size, len(rec.Key), len(rec.Value), pr.maxRecordBytes,
+	)
+}
+
+// handleProduceError adds diagnostics to errors caused by the broker rejecting
+// a record because of its size. Records rejected this way are ignored if the
+// policy is to skip oversized records.
+func (pr preparedRecord) handleProduceError(ctx context.Context, err error) error {
+	if !errors.Is(err, kerr.MessageTooLarge) {
+		return err
+	}
+	if pr.policy == "skip" {
+		sdk.Logger(ctx).Warn().Err(err).Msg("skipping record because it was rejected by the broker")
+		return nil
+	}
+	return fmt.Errorf(
+		"%w: the broker rejected the record, max.message.bytes of the topic is probably lower than batchBytes (%d bytes): %w",
+		ErrRecordTooLarge, pr.maxRecordBytes, err,
+	)
+}
@@ -0,0 +1,208 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-kafka/common"
+	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestFranzProducer_ApplyOversizedPolicy(t *testing.T) {
+	ctx := context.Background()
+	const maxRecordBytes = 1024
+
+	small := opencdc.Record{
+		Key:     opencdc.RawData("key"),
+		Payload: opencdc.Change{After: opencdc.RawData("foo")},
+	}
+	large := opencdc.Record{
+		Position: opencdc.Position("pos"),
+		Key:      opencdc.RawData("key"),
+		Payload:  opencdc.Change{After: opencdc.RawData(strings.Repeat("x", 5000))},
+	}
+
+	testCases := []struct {
+		policy      string
+		record      opencdc.Record
+		wantRecords int
+		wantChunked bool
+		wantErr     error
+	}{
+		{policy: "fail", record: small, wantRecords: 1},
+		{policy: "fail", record: large, wantErr: ErrRecordTooLarge},
+		{policy: "dlq", record: large, wantErr: ErrRecordTooLarge},
+		{policy: "skip", record: large, wantRecords: 0},
+		{policy: "chunk", record: small, wantRecords: 1},
+		{policy: "chunk", record: large, wantChunked: true},
+	}
+
+	for _, tc := range testCases {
+		name := tc.policy + "/" + string(tc.record.Key.Bytes())
+		if tc.record.Position != nil {
+			name = tc.policy + "/large"
+		}
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			p := &FranzProducer{
+				keyEncoder:            bytesEncoder{},
+				maxRecordBytes:        maxRecordBytes,
+				oversizedRecordPolicy: tc.policy,
+			}
+
+			pr, err := p.prepareRecords(ctx, tc.record)
+			got := pr.records
+			is.True(errors.Is(err, tc.wantErr))
+			if tc.wantChunked {
+				is.True(len(got) > 1)
+			} else {
+				is.Equal(len(got), tc.wantRecords)
+			}
+
+			var value []byte
+			for i, rec := range got {
+				is.True(recordSize(rec) <= maxRecordBytes)
+				is.Equal(rec.Key, []byte("key"))
+				value = append(value, rec.Value...)
+
+				chunk, ok, err := common.ParseChunk(rec.Headers)
+				is.NoErr(err)
+				is.Equal(ok, tc.wantChunked)
+				if ok {
+					is.Equal(chunk.Index, i)
+					is.Equal(chunk.Count, len(got))
+				}
+			}
+			if len(got) > 0 {
+				is.Equal(value, tc.record.Bytes())
+			}
+		})
+	}
+}
+
+func TestFranzProducer_ChunkRecord_StableID(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	p := &FranzProducer{
+		keyEncoder:            bytesEncoder{},
+		maxRecordBytes:        512,
+		oversizedRecordPolicy: "chunk",
+	}
+	r := opencdc.Record{
+		Position: opencdc.Position("pos"),
+		Key:      opencdc.RawData("key"),
+		Payload:  opencdc.Change{After: opencdc.RawData(strings.Repeat("x", 2000))},
+	}
+
+	first, err := p.prepareRecords(ctx, r)
+	is.NoErr(err)
+	second, err := p.prepareRecords(ctx, r)
+	is.NoErr(err)
+	is.Equal(first.records[0].Headers, second.records[0].Headers) // producing a record again results in the same chunks
+
+	r.Position = opencdc.Position("other")
+	third, err := p.prepareRecords(ctx, r)
+	is.NoErr(err)
+	is.True(!bytes.Equal(first.records[0].Headers[0].Value, third.records[0].Headers[0].Value))
+}
+
+func TestFranzProducer_Produce_OversizedRecord(t *testing.T) {
+	ctx := context.Background()
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(1, "test"),
+	)
+	is.New(t).NoErr(err)
+	t.Cleanup(cluster.Close)
+
+	records := []opencdc.Record{
+		{Key: opencdc.RawData("0"), Payload: opencdc.Change{After: opencdc.RawData("foo")}},
+		{Key: opencdc.RawData("1"), Payload: opencdc.Change{After: opencdc.RawData(strings.Repeat("x", 3000))}},
+		{Key: opencdc.RawData("2"), Payload: opencdc.Change{After: opencdc.RawData("bar")}},
+	}
+
+	testCases := []struct {
+		policy      string
+		wantN       int
+		wantErr     bool
+		wantRecords int
+	}{
+		{policy: "fail", wantN: 0, wantErr: true, wantRecords: 0},
+		{policy: "dlq", wantN: 1, wantErr: true, wantRecords: 1},
+		{policy: "skip", wantN: 3, wantRecords: 2},
+		{policy: "chunk", wantN: 3, wantRecords: -1}, // depends on the number of chunks
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			is := is.New(t)
+
+			cfg := Config{
+				Config:                common.Config{Servers: cluster.ListenAddrs()},
+				Topic:                 "test",
+				BatchBytes:            1024,
+				DeliveryTimeout:       time.Second,
+				Acks:                  "all",
+				OversizedRecordPolicy: tc.policy,
+			}
+			p, err := NewFranzProducer(ctx, cfg)
+			is.NoErr(err)
+			defer p.Close(ctx)
+
+			before := topicEndOffset(t, cluster, "test")
+			n, err := p.Produce(ctx, records)
+			is.Equal(n, tc.wantN)
+			is.Equal(err != nil, tc.wantErr)
+			if err != nil {
+				is.True(errors.Is(err, ErrRecordTooLarge))
+			}
+			written := topicEndOffset(t, cluster, "test") - before
+			if tc.wantRecords >= 0 {
+				is.Equal(written, int64(tc.wantRecords))
+			} else {
+				is.True(written > int64(len(records)))
+			}
+		})
+	}
+}
+
+// topicEndOffset returns the end offset of partition 0 of the topic.
+func topicEndOffset(t *testing.T, cluster *kfake.Cluster, topic string) int64 {
+	is := is.New(t)
+	is.Helper()
+
+	cl, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...))
+	is.NoErr(err)
+	defer cl.Close()
+
+	offsets, err := kadm.NewClient(cl).ListEndOffsets(context.Background(), topic)
+	is.NoErr(err)
+	o, ok := offsets.Lookup(topic, 0)
+	is.True(ok)
+	is.NoErr(o.Err)
+	return o.Offset
+}
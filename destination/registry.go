@@ -0,0 +1,135 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// Schema types supported by the schema registry.
+const (
+	registryTypeAvro     = "AVRO"
+	registryTypeProtobuf = "PROTOBUF"
+)
+
+// registrySchema is a schema that is registered in the schema registry.
+type registrySchema struct {
+	Type   string
+	Schema string
+}
+
+// registryClient registers schemas in a Confluent compatible schema registry.
+// Registering a schema that is already registered under the subject returns
+// its ID, so the IDs are cached and a schema is registered once per subject.
+type registryClient struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+	ids      *lru.Cache[string, int]
+}
+
+func newRegistryClient(url, username, password string, timeout time.Duration) (*registryClient, error) {
+	ids, err := lru.New[string, int](topicCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &registryClient{
+		url:      strings.TrimSuffix(url, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: timeout},
+		ids:      ids,
+	}, nil
+}
+
+// Register registers the schema under the subject and returns its ID.
+func (c *registryClient) Register(ctx context.Context, subject string, s registrySchema) (int, error) {
+	key := subject + "\x00" + s.Type + "\x00" + s.Schema
+	if id, ok := c.ids.Get(key); ok {
+		return id, nil
+	}
+
+	body, err := json.Marshal(struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}{Schema: s.Schema, SchemaType: s.Type})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		c.url+"/subjects/"+url.PathEscape(subject)+"/versions",
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to register schema for subject %q: %w", subject, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response for subject %q: %w", subject, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to register schema for subject %q: schema registry responded with %s: %s", subject, resp.Status, respBody)
+	}
+
+	var out struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return 0, fmt.Errorf("failed to decode response for subject %q: %w", subject, err)
+	}
+	c.ids.Add(key, out.ID)
+	return out.ID, nil
+}
+
+// registryWireFormat prefixes the encoded data with the header of the
+// Confluent wire format, a magic byte (always 0) followed by the schema ID as
+// a big-endian uint32. Protobuf data is additionally prefixed with the indexes
+// of the message in the schema, encoded as a single 0 for the first message.
+// See https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#wire-format.
+func registryWireFormat(id int, typ string, b []byte) []byte {
+	out := make([]byte, 0, len(b)+6)
+	out = append(out, 0)
+	out = binary.BigEndian.AppendUint32(out, uint32(id)) //nolint:gosec // schema IDs are positive 32-bit integers
+	if typ == registryTypeProtobuf {
+		out = append(out, 0)
+	}
+	return append(out, b...)
+}
@@ -0,0 +1,193 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// topicOverride contains settings that override the global producer settings
+// for topics matching the pattern. Empty fields are not overridden.
+type topicOverride struct {
+	pattern string
+
+	compression string
+	acks        string
+	batchBytes  int32
+	partitioner string
+	keyFormat   string
+}
+
+// parseTopicOverrides parses Config.TopicOverrides. The returned overrides
+// are sorted by precedence: exact topic names first, followed by glob
+// patterns from the longest to the shortest.
+func (c Config) parseTopicOverrides() ([]topicOverride, error) {
+	var multierr []error
+	overrides := make([]topicOverride, 0, len(c.TopicOverrides))
+	for pattern, settings := range c.TopicOverrides {
+		o, err := parseTopicOverride(pattern, settings)
+		if err != nil {
+			multierr = append(multierr, fmt.Errorf("invalid topic override %q: %w", pattern, err))
+			continue
+		}
+		overrides = append(overrides, o)
+	}
+	if len(multierr) > 0 {
+		return nil, errors.Join(multierr...)
+	}
+
+	slices.SortFunc(overrides, func(a, b topicOverride) int {
+		if aGlob, bGlob := a.isGlob(), b.isGlob(); aGlob != bGlob {
+			if aGlob {
+				return 1
+			}
+			return -1
+		}
+		if len(a.pattern) != len(b.pattern) {
+			return len(b.pattern) - len(a.pattern)
+		}
+		return strings.Compare(a.pattern, b.pattern)
+	})
+	return overrides, nil
+}
+
+// parseTopicOverride parses the settings of a topic override, which are
+// formatted as a comma separated list of key=value pairs, e.g.
+// "compression=zstd,acks=one".
+func parseTopicOverride(pattern, settings string) (topicOverride, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return topicOverride{}, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	o := topicOverride{pattern: pattern}
+	var multierr []error
+	for _, setting := range strings.Split(settings, ",") {
+		if strings.TrimSpace(setting) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(setting, "=")
+		if !ok {
+			multierr = append(multierr, fmt.Errorf("setting %q is not formatted as key=value", setting))
+			continue
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+
+		var err error
+		switch k {
+		case "compression":
+			o.compression, err = oneOf(v, "none", "gzip", "snappy", "lz4", "zstd")
+		case "acks":
+			o.acks, err = oneOf(v, "none", "one", "all")
+		case "batchBytes":
+			var i int64
+			i, err = strconv.ParseInt(v, 10, 32)
+			if err == nil && i <= 0 {
+				err = errors.New("value must be positive")
+			}
+			o.batchBytes = int32(i)
+		case "partitioner":
+			o.partitioner, err = oneOf(v, "default", "sticky-key", "round-robin", "least-backup")
+		case "keyFormat":
+			o.keyFormat, err = oneOf(v, "raw", "kafka-connect")
+		default:
+			err = errors.New("unknown setting")
+		}
+		if err != nil {
+			multierr = append(multierr, fmt.Errorf("setting %q: %w", k, err))
+		}
+	}
+	if len(multierr) > 0 {
+		return topicOverride{}, errors.Join(multierr...)
+	}
+	return o, nil
+}
+
+func oneOf(v string, allowed ...string) (string, error) {
+	if !slices.Contains(allowed, v) {
+		return "", fmt.Errorf("value %q is not one of %s", v, strings.Join(allowed, ", "))
+	}
+	return v, nil
+}
+
+func (o topicOverride) isGlob() bool {
+	return strings.ContainsAny(o.pattern, `*?[\`)
+}
+
+func (o topicOverride) matches(topic string) bool {
+	if !o.isGlob() {
+		return o.pattern == topic
+	}
+	ok, _ := path.Match(o.pattern, topic)
+	return ok
+}
+
+// applyTo returns the config with the client settings of the override applied.
+func (o topicOverride) applyTo(cfg Config) Config {
+	if o.compression != "" {
+		cfg.Compression = o.compression
+	}
+	if o.acks != "" {
+		cfg.Acks = o.acks
+	}
+	if o.batchBytes != 0 {
+		cfg.BatchBytes = o.batchBytes
+	}
+	return cfg
+}
+
+// matchTopicOverride returns the first override matching the topic. The
+// overrides need to be sorted by precedence.
+func matchTopicOverride(overrides []topicOverride, topic string) (topicOverride, bool) {
+	for _, o := range overrides {
+		if o.matches(topic) {
+			return o, true
+		}
+	}
+	return topicOverride{}, false
+}
+
+// topicPartitioner is a kgo.Partitioner that uses the partitioner of the
+// topic override matching the topic, falling back to the franz-go default.
+type topicPartitioner struct {
+	overrides []topicOverride
+}
+
+func (p topicPartitioner) ForTopic(topic string) kgo.TopicPartitioner {
+	var name string
+	if o, ok := matchTopicOverride(p.overrides, topic); ok {
+		name = o.partitioner
+	}
+
+	var partitioner kgo.Partitioner
+	switch name {
+	case "sticky-key":
+		partitioner = kgo.StickyKeyPartitioner(nil)
+	case "round-robin":
+		partitioner = kgo.RoundRobinPartitioner()
+	case "least-backup":
+		partitioner = kgo.LeastBackupPartitioner()
+	default:
+		// same as the franz-go default
+		partitioner = kgo.UniformBytesPartitioner(64<<10, true, true, nil)
+	}
+	return partitioner.ForTopic(topic)
+}
@@ -0,0 +1,204 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destination
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-kafka/common"
+	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestConfig_ParseTopicOverrides(t *testing.T) {
+	is := is.New(t)
+
+	cfg := Config{
+		TopicOverrides: map[string]string{
+			"*":           "compression=none",
+			"logs.*":      "compression=zstd, acks=one",
+			"logs.audit":  "acks=all,batchBytes=2048",
+			"logs.app.*":  "partitioner=round-robin",
+			"metrics.cpu": "keyFormat=kafka-connect",
+		},
+	}
+
+	got, err := cfg.parseTopicOverrides()
+	is.NoErr(err)
+
+	patterns := make([]string, len(got))
+	for i, o := range got {
+		patterns[i] = o.pattern
+	}
+	is.Equal(patterns, []string{"metrics.cpu", "logs.audit", "logs.app.*", "logs.*", "*"})
+
+	is.Equal(got[1], topicOverride{pattern: "logs.audit", acks: "all", batchBytes: 2048})
+	is.Equal(got[3], topicOverride{pattern: "logs.*", compression: "zstd", acks: "one"})
+
+	testCases := []struct {
+		topic       string
+		wantPattern string
+	}{
+		{topic: "logs.audit", wantPattern: "logs.audit"},
+		{topic: "logs.app.web", wantPattern: "logs.app.*"},
+		{topic: "logs.db", wantPattern: "logs.*"},
+		{topic: "metrics.cpu", wantPattern: "metrics.cpu"},
+		{topic: "metrics.mem", wantPattern: "*"},
+	}
+	for _, tc := range testCases {
+		o, ok := matchTopicOverride(got, tc.topic)
+		is.True(ok)
+		is.Equal(o.pattern, tc.wantPattern)
+	}
+}
+
+func TestConfig_ParseTopicOverrides_Invalid(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		settings string
+		wantErr  string
+	}{
+		{pattern: "foo", settings: "compression", wantErr: "not formatted as key=value"},
+		{pattern: "foo", settings: "compression=brotli", wantErr: `setting "compression": value "brotli" is not one of`},
+		{pattern: "foo", settings: "acks=two", wantErr: `setting "acks": value "two" is not one of`},
+		{pattern: "foo", settings: "batchBytes=0", wantErr: `setting "batchBytes": value must be positive`},
+		{pattern: "foo", settings: "partitioner=random", wantErr: `setting "partitioner": value "random" is not one of`},
+		{pattern: "foo", settings: "ordering=strict", wantErr: `setting "ordering": unknown setting`},
+		{pattern: "foo[", settings: "acks=one", wantErr: "invalid pattern"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.settings, func(t *testing.T) {
+			is := is.New(t)
+			cfg := Config{TopicOverrides: map[string]string{tc.pattern: tc.settings}}
+			_, err := cfg.parseTopicOverrides()
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tc.wantErr))
+		})
+	}
+}
+
+func TestFranzProducer_TopicOverrides(t *testing.T) {
+	is := is.New(t)
+
+	cfg := Config{
+		Config:      common.Config{Servers: []string{"test-host:9092"}},
+		Topic:       `{{ index .Metadata "topic" }}`,
+		BatchBytes:  512,
+		Acks:        "all",
+		Compression: "snappy",
+		TopicOverrides: map[string]string{
+			"logs.*":      "compression=zstd,acks=one",
+			"logs.audit":  "acks=one,compression=zstd,batchBytes=1024",
+			"metrics.*":   "compression=zstd,acks=one",
+			"metrics.raw": "keyFormat=kafka-connect",
+		},
+	}
+
+	p, err := NewFranzProducer(context.Background(), cfg)
+	is.NoErr(err)
+	defer p.Close(context.Background())
+
+	// "logs.*" and "metrics.*" share a client, "logs.audit" needs its own
+	is.Equal(len(p.overrideClients), 2)
+
+	def := p.settingsFor("other")
+	is.Equal(def.client, p.client)
+	is.Equal(def.maxRecordBytes, 512)
+
+	logs := p.settingsFor("logs.app")
+	is.True(logs.client != p.client)
+	is.Equal(logs.client.OptValue(kgo.RequiredAcks), kgo.LeaderAck())
+	is.Equal(logs.client.OptValue(kgo.DisableIdempotentWrite), true)
+	is.Equal(logs.client.OptValue(kgo.ProducerBatchCompression), []kgo.CompressionCodec{kgo.ZstdCompression(), kgo.NoCompression()})
+	is.Equal(p.settingsFor("metrics.cpu").client, logs.client)
+
+	audit := p.settingsFor("logs.audit")
+	is.True(audit.client != logs.client)
+	is.Equal(audit.maxRecordBytes, 1024)
+
+	raw := p.settingsFor("metrics.raw")
+	is.Equal(raw.client, p.client)
+	is.Equal(raw.keyEncoder, kafkaConnectEncoder{})
+}
+
+func TestFranzProducer_TopicOverrides_Partitioner(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(3, "hashed", "spread"),
+	)
+	is.NoErr(err)
+	t.Cleanup(cluster.Close)
+
+	cfg := Config{
+		Config:          common.Config{Servers: cluster.ListenAddrs()},
+		Topic:           `{{ index .Metadata "topic" }}`,
+		BatchBytes:      1000012,
+		DeliveryTimeout: time.Second,
+		Acks:            "all",
+		TopicOverrides: map[string]string{
+			"spread": "partitioner=round-robin",
+		},
+	}
+	p, err := NewFranzProducer(ctx, cfg)
+	is.NoErr(err)
+	defer p.Close(ctx)
+
+	var records []opencdc.Record
+	for _, topic := range []string{"hashed", "spread"} {
+		for range 6 {
+			records = append(records, opencdc.Record{
+				Key:      opencdc.RawData("same-key"),
+				Metadata: map[string]string{"topic": topic},
+				Payload:  opencdc.Change{After: opencdc.RawData("foo")},
+			})
+		}
+	}
+	n, err := p.Produce(ctx, records)
+	is.NoErr(err)
+	is.Equal(n, len(records))
+
+	cl, err := kgo.NewClient(
+		kgo.SeedBrokers(cluster.ListenAddrs()...),
+		kgo.ConsumeTopics("hashed", "spread"),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	is.NoErr(err)
+	defer cl.Close()
+
+	partitions := map[string]map[int32]bool{}
+	for consumed := 0; consumed < len(records); {
+		fetches := cl.PollFetches(ctx)
+		is.NoErr(fetches.Err())
+		fetches.EachRecord(func(r *kgo.Record) {
+			if partitions[r.Topic] == nil {
+				partitions[r.Topic] = map[int32]bool{}
+			}
+			partitions[r.Topic][r.Partition] = true
+			consumed++
+		})
+	}
+
+	is.Equal(len(partitions["hashed"]), 1) // records with the same key are hashed to the same partition
+	is.Equal(len(partitions["spread"]), 3) // round-robin ignores the key
+}
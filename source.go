@@ -16,18 +16,55 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/conduitio/conduit-commons/config"
 	"github.com/conduitio/conduit-commons/lang"
 	"github.com/conduitio/conduit-commons/opencdc"
 	"github.com/conduitio/conduit-connector-kafka/source"
 	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/conduitio/conduit-connector-sdk/schema"
 	"github.com/google/uuid"
 )
 
 const (
 	MetadataKafkaHeaderPrefix = "kafka.header."
+	// MetadataKafkaKeyDecodeError contains the error of decoding the key, if
+	// it couldn't be decoded using the configured key format or didn't match
+	// its schema.
+	MetadataKafkaKeyDecodeError = "kafka.key.decodeError"
+	// MetadataKafkaValueDecodeError contains the error of decoding the value,
+	// if it couldn't be decoded using the configured value format or didn't
+	// match its schema.
+	MetadataKafkaValueDecodeError = "kafka.value.decodeError"
+
+	// MetadataKafkaPartition contains the partition of the Kafka record.
+	MetadataKafkaPartition = "kafka.partition"
+	// MetadataKafkaOffset contains the offset of the Kafka record in its
+	// partition.
+	MetadataKafkaOffset = "kafka.offset"
+	// MetadataKafkaLeaderEpoch contains the leader epoch of the broker at the
+	// time the record was written. It is not set if the epoch is unknown.
+	MetadataKafkaLeaderEpoch = "kafka.leaderEpoch"
+	// MetadataKafkaTimestampType contains the type of the record timestamp,
+	// "create" if it was set by the producer and "log-append" if it was set by
+	// the broker. It is not set for records without a timestamp type.
+	MetadataKafkaTimestampType = "kafka.timestampType"
+	// MetadataKafkaProducerID contains the ID of the idempotent or
+	// transactional producer that wrote the record, it is not set for other
+	// producers.
+	MetadataKafkaProducerID = "kafka.producerId"
+	// MetadataKafkaProducerEpoch contains the epoch of the producer that wrote
+	// the record, it is set together with MetadataKafkaProducerID.
+	MetadataKafkaProducerEpoch = "kafka.producerEpoch"
+	// MetadataKafkaKeyPresent is "true" if the Kafka record has a key and
+	// "false" if the key is null.
+	MetadataKafkaKeyPresent = "kafka.key.present"
+	// MetadataKafkaCluster contains the name of the cluster the record was
+	// read from. It is only set if the cluster has a name.
+	MetadataKafkaCluster = "kafka.cluster"
 )
 
 type Source struct {
@@ -35,13 +72,20 @@ type Source struct {
 
 	consumer source.Consumer
 	config   source.Config
+	// decoder is nil if attaching schemas is disabled.
+	decoder *source.SchemaDecoder
+	// keyDecoder and valueDecoder are nil for the raw format.
+	keyDecoder   source.FormatDecoder
+	valueDecoder source.FormatDecoder
+	headers      source.HeaderDecoder
 }
 
 func NewSource() sdk.Source {
 	return sdk.SourceWithMiddleware(
 		&Source{},
 		sdk.DefaultSourceMiddleware(
-			// disable schema extraction by default, because the source produces raw data
+			// disable schema extraction by default, because the source produces
+			// raw data or structured data with attached schemas
 			sdk.SourceWithSchemaExtractionConfig{
 				PayloadEnabled: lang.Ptr((false)),
 				KeyEnabled:     lang.Ptr(false),
@@ -67,21 +111,64 @@ func (s *Source) Configure(ctx context.Context, cfg config.Config) error {
 }
 
 func (s *Source) Open(ctx context.Context, sdkPos opencdc.Position) error {
-	err := s.config.TryDial(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to dial broker: %w", err)
+	clusters := s.config.ClusterConfigs()
+	for name, cfg := range clusters {
+		err := cfg.TryDial(ctx)
+		if err != nil {
+			if len(clusters) > 1 {
+				return fmt.Errorf("failed to dial broker of cluster %q: %w", name, err)
+			}
+			return fmt.Errorf("failed to dial broker: %w", err)
+		}
+	}
+
+	var err error
+	if s.config.AttachSchemas {
+		s.decoder, err = source.NewSchemaDecoder(s.config)
+		if err != nil {
+			return fmt.Errorf("failed to create schema decoder: %w", err)
+		}
+	}
+
+	s.headers = source.NewHeaderDecoder(s.config)
+	if s.config.KeyFormat != source.FormatRaw {
+		s.keyDecoder, err = source.NewFormatDecoder(s.config.KeyFormat, s.config.KeySchemaFile, s.config.KeyMessageName)
+		if err != nil {
+			return fmt.Errorf("failed to create key decoder: %w", err)
+		}
+	}
+	if s.config.ValueFormat != source.FormatRaw {
+		s.valueDecoder, err = source.NewFormatDecoder(s.config.ValueFormat, s.config.ValueSchemaFile, s.config.ValueMessageName)
+		if err != nil {
+			return fmt.Errorf("failed to create value decoder: %w", err)
+		}
+	}
+
+	if len(s.config.Partitions) > 0 {
+		s.consumer, err = s.newPartitionConsumer(ctx, sdkPos)
+		return err
 	}
 
+	// offsets are the offsets stored in the position, keyed by cluster
+	var offsets map[string]source.PartitionOffsets
 	if sdkPos != nil {
 		// update group ID in the config
 		p, err := source.ParseSDKPosition(sdkPos)
 		if err != nil {
 			return err
 		}
+		if p.GroupID == "" && p.Partitions != nil {
+			return fmt.Errorf("the old position was created by reading assigned partitions (%v), it can't be used to resume reading from a consumer group", p.Partitions)
+		}
 		if s.config.GroupID != "" && s.config.GroupID != p.GroupID {
 			return fmt.Errorf("the old position contains a different consumer group ID than the connector configuration (%q vs %q), please check if the configured group ID changed since the last run", p.GroupID, s.config.GroupID)
 		}
 		s.config.GroupID = p.GroupID
+		offsets = p.Clusters
+		if offsets == nil {
+			// the position was created by reading from a single cluster
+			offsets = map[string]source.PartitionOffsets{p.Cluster: p.Partitions}
+		}
 	}
 	if s.config.GroupID == "" {
 		// this must be the first run of the connector, create a new group ID
@@ -89,14 +176,59 @@ func (s *Source) Open(ctx context.Context, sdkPos opencdc.Position) error {
 		sdk.Logger(ctx).Info().Str("groupId", s.config.GroupID).Msg("assigning source to new consumer group")
 	}
 
-	s.consumer, err = source.NewFranzConsumer(ctx, s.config)
-	if err != nil {
-		return fmt.Errorf("failed to create Kafka consumer: %w", err)
+	if len(s.config.Clusters) == 0 {
+		s.consumer, err = source.NewFranzConsumer(ctx, s.config, offsets[s.config.ClusterName])
+		if err != nil {
+			return fmt.Errorf("failed to create Kafka consumer: %w", err)
+		}
+		return nil
+	}
+
+	// collect the cluster configs again, they need to contain the group ID
+	consumers := make(map[string]source.Consumer, len(clusters))
+	for name, cfg := range s.config.ClusterConfigs() {
+		consumer, err := source.NewFranzConsumer(ctx, cfg, offsets[name])
+		if err != nil {
+			for _, c := range consumers {
+				_ = c.Close(ctx)
+			}
+			return fmt.Errorf("failed to create Kafka consumer for cluster %q: %w", name, err)
+		}
+		consumers[name] = consumer
 	}
+	s.consumer = source.NewMultiClusterConsumer(ctx, consumers, offsets)
 
 	return nil
 }
 
+// newPartitionConsumer creates a consumer reading the configured partitions,
+// starting at the offsets stored in the position, if any.
+func (s *Source) newPartitionConsumer(ctx context.Context, sdkPos opencdc.Position) (source.Consumer, error) {
+	a, err := s.config.PartitionAssignment()
+	if err != nil {
+		return nil, err
+	}
+	if sdkPos != nil {
+		p, err := source.ParseSDKPosition(sdkPos)
+		if err != nil {
+			return nil, err
+		}
+		if p.GroupID != "" {
+			return nil, fmt.Errorf("the old position was created by a consumer group (%q), it can't be used to resume reading assigned partitions", p.GroupID)
+		}
+		a, err = a.WithOffsets(p.Partitions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	consumer, err := source.NewPartitionConsumer(ctx, s.config, a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka consumer: %w", err)
+	}
+	return consumer, nil
+}
+
 func (s *Source) Read(ctx context.Context) (opencdc.Record, error) {
 	rec, err := s.consumer.Consume(ctx)
 	if err != nil {
@@ -106,25 +238,123 @@ func (s *Source) Read(ctx context.Context) (opencdc.Record, error) {
 	metadata := opencdc.Metadata{}
 	metadata.SetCollection(rec.Topic)
 	metadata.SetCreatedAt(rec.Timestamp)
-	for _, h := range rec.Headers {
-		metadata[MetadataKafkaHeaderPrefix+h.Key] = string(h.Value)
+	setRecordMetadata(metadata, rec)
+	cluster := source.RecordCluster(rec)
+	if cluster != "" {
+		metadata[MetadataKafkaCluster] = cluster
 	}
+	s.headers.Decode(rec.Headers, metadata, MetadataKafkaHeaderPrefix)
 
-	return sdk.Util.Source.NewRecordCreate(
-		source.Position{
-			GroupID:   s.config.GroupID,
-			Topic:     rec.Topic,
-			Partition: rec.Partition,
-			Offset:    rec.Offset,
-		}.ToSDKPosition(),
+	key, keySchema, err := s.decode(ctx, s.keyDecoder, rec.Topic+".key", rec.Key, metadata, MetadataKafkaKeyDecodeError)
+	if err != nil {
+		return opencdc.Record{}, fmt.Errorf("failed to decode key: %w", err)
+	}
+	value, valueSchema, err := s.decode(ctx, s.valueDecoder, rec.Topic+".payload", rec.Value, metadata, MetadataKafkaValueDecodeError)
+	if err != nil {
+		return opencdc.Record{}, fmt.Errorf("failed to decode value: %w", err)
+	}
+
+	pos := source.Position{
+		GroupID:   s.config.GroupID,
+		Topic:     rec.Topic,
+		Partition: rec.Partition,
+		Offset:    rec.Offset,
+		Cluster:   cluster,
+		Clusters:  source.RecordClusterOffsets(rec),
+	}
+	if pos.Clusters == nil {
+		pos.Partitions = source.RecordPartitionOffsets(rec)
+	}
+	r := sdk.Util.Source.NewRecordCreate(
+		pos.ToSDKPosition(),
 		metadata,
-		opencdc.RawData(rec.Key),
-		opencdc.RawData(rec.Value),
-	), nil
+		key,
+		value,
+	)
+	if keySchema != nil {
+		schema.AttachKeySchemaToRecord(r, *keySchema)
+	}
+	if valueSchema != nil {
+		schema.AttachPayloadSchemaToRecord(r, *valueSchema)
+	}
+	return r, nil
 }
 
-func (s *Source) Ack(ctx context.Context, _ opencdc.Position) error {
-	return s.consumer.Ack(ctx)
+// setRecordMetadata sets the metadata fields describing the Kafka record.
+func setRecordMetadata(metadata opencdc.Metadata, rec *source.Record) {
+	metadata[MetadataKafkaPartition] = strconv.FormatInt(int64(rec.Partition), 10)
+	metadata[MetadataKafkaOffset] = strconv.FormatInt(rec.Offset, 10)
+	if rec.LeaderEpoch >= 0 {
+		metadata[MetadataKafkaLeaderEpoch] = strconv.FormatInt(int64(rec.LeaderEpoch), 10)
+	}
+	switch rec.Attrs.TimestampType() {
+	case 0:
+		metadata[MetadataKafkaTimestampType] = "create"
+	case 1:
+		metadata[MetadataKafkaTimestampType] = "log-append"
+	}
+	if rec.ProducerID >= 0 {
+		metadata[MetadataKafkaProducerID] = strconv.FormatInt(rec.ProducerID, 10)
+		metadata[MetadataKafkaProducerEpoch] = strconv.FormatInt(int64(rec.ProducerEpoch), 10)
+	}
+	metadata[MetadataKafkaKeyPresent] = strconv.FormatBool(rec.Key != nil)
+}
+
+// decode decodes the key or value using the format decoder. If the format is
+// raw, the data is decoded by the schema decoder instead, if attaching schemas
+// is enabled. Data that can't be decoded or doesn't match its schema is
+// returned as raw data and the error is stored in the metadata field errorKey,
+// unless the decode error policy is "fail". Empty data (e.g. the value of a
+// tombstone) is never decoded.
+func (s *Source) decode(
+	ctx context.Context,
+	dec source.FormatDecoder,
+	subject string,
+	b []byte,
+	metadata opencdc.Metadata,
+	errorKey string,
+) (opencdc.Data, *schema.Schema, error) {
+	switch {
+	case len(b) == 0:
+		return opencdc.RawData(b), nil, nil
+	case dec != nil:
+		sd, err := dec.Decode(b)
+		if err != nil {
+			return s.decodeError(b, metadata, errorKey, err)
+		}
+		return sd, nil, nil
+	case s.decoder != nil:
+		data, sch, err := s.decoder.Decode(ctx, subject, b)
+		if errors.Is(err, source.ErrDecode) {
+			return s.decodeError(b, metadata, errorKey, err)
+		}
+		return data, sch, err
+	default:
+		return opencdc.RawData(b), nil, nil
+	}
+}
+
+// decodeError handles data that couldn't be decoded according to the decode
+// error policy.
+func (s *Source) decodeError(
+	b []byte,
+	metadata opencdc.Metadata,
+	errorKey string,
+	err error,
+) (opencdc.Data, *schema.Schema, error) {
+	if s.config.DecodeErrorPolicy == source.DecodeErrorPolicyFail {
+		return nil, nil, err
+	}
+	metadata[errorKey] = err.Error()
+	return opencdc.RawData(b), nil, nil
+}
+
+func (s *Source) Ack(ctx context.Context, sdkPos opencdc.Position) error {
+	p, err := source.ParseSDKPosition(sdkPos)
+	if err != nil {
+		return err
+	}
+	return s.consumer.Ack(ctx, p)
 }
 
 func (s *Source) Teardown(ctx context.Context) error {
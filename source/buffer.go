@@ -0,0 +1,172 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"sync"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// defaultMaxBufferedRecords is the default of Config.MaxBufferedRecords.
+const defaultMaxBufferedRecords = 10000
+
+// bufferLimiter keeps track of the records buffered by the consumer, i.e.
+// fetched records in the partition queues and records that were read but not
+// acknowledged yet. Fetching is paused while the buffer exceeds its limits and
+// resumed once it is drained to half of the limits. It is safe for concurrent
+// use.
+type bufferLimiter struct {
+	client Client
+	topics []string
+
+	maxRecords int
+	// maxBytes is 0 if the number of buffered bytes is not limited.
+	maxBytes int
+
+	m       sync.Mutex
+	records int
+	bytes   int
+	// unacked contains the sizes of records that were read but not
+	// acknowledged yet.
+	unacked map[recordOffset]int
+	paused  bool
+}
+
+func newBufferLimiter(client Client, topics []string, maxRecords, maxBytes int) *bufferLimiter {
+	if maxRecords == 0 {
+		maxRecords = defaultMaxBufferedRecords
+	}
+	return &bufferLimiter{
+		client:     client,
+		topics:     topics,
+		maxRecords: maxRecords,
+		maxBytes:   maxBytes,
+		unacked:    make(map[recordOffset]int),
+	}
+}
+
+// recordOffset identifies a record by its topic, partition and offset.
+type recordOffset struct {
+	topicPartition
+	offset int64
+}
+
+// recordSize returns the number of bytes of the record's key, value and
+// headers.
+func recordSize(r *kgo.Record) int {
+	size := len(r.Key) + len(r.Value)
+	for _, h := range r.Headers {
+		size += len(h.Key) + len(h.Value)
+	}
+	return size
+}
+
+// Fetched adds fetched records to the buffer.
+func (l *bufferLimiter) Fetched(fetches kgo.Fetches) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	fetches.EachRecord(func(r *kgo.Record) {
+		l.records++
+		l.bytes += recordSize(r)
+	})
+}
+
+// Dequeued removes a fetched record taken from the partition queues from the
+// buffer. If the record is read, it is added again by Read. A nil record is
+// ignored.
+func (l *bufferLimiter) Dequeued(r *kgo.Record) {
+	if r == nil {
+		return
+	}
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.records--
+	l.bytes -= recordSize(r)
+}
+
+// Read adds a record that was read to the buffer, it stays there until it is
+// acknowledged.
+func (l *bufferLimiter) Read(r *kgo.Record) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	size := recordSize(r)
+	l.records++
+	l.bytes += size
+	l.unacked[recordOffset{topicPartition{topic: r.Topic, partition: r.Partition}, r.Offset}] = size
+}
+
+// Acked removes the record at the position from the buffer and resumes
+// fetching if the buffer was drained.
+func (l *bufferLimiter) Acked(ctx context.Context, pos Position) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	key := recordOffset{topicPartition{topic: pos.Topic, partition: pos.Partition}, pos.Offset}
+	size, ok := l.unacked[key]
+	if !ok {
+		return
+	}
+	delete(l.unacked, key)
+	l.records--
+	l.bytes -= size
+	l.update(ctx)
+}
+
+// Revoked removes the records of the partitions that were read but not
+// acknowledged yet from the buffer, acks of these records are ignored. Records
+// dropped from the partition queues need to be removed with Dequeued.
+func (l *bufferLimiter) Revoked(ctx context.Context, partitions map[topicPartition]bool) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	for key, size := range l.unacked {
+		if partitions[key.topicPartition] {
+			delete(l.unacked, key)
+			l.records--
+			l.bytes -= size
+		}
+	}
+	l.update(ctx)
+}
+
+// Update pauses fetching if the buffer exceeds its limits and resumes
+// fetching if the buffer was drained.
+func (l *bufferLimiter) Update(ctx context.Context) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	l.update(ctx)
+}
+
+func (l *bufferLimiter) update(ctx context.Context) {
+	switch {
+	case !l.paused && (l.records >= l.maxRecords || (l.maxBytes > 0 && l.bytes >= l.maxBytes)):
+		l.paused = true
+		l.client.PauseFetchTopics(l.topics...)
+		sdk.Logger(ctx).Info().
+			Int("bufferedRecords", l.records).
+			Int("bufferedBytes", l.bytes).
+			Int("maxBufferedRecords", l.maxRecords).
+			Int("maxBufferedBytes", l.maxBytes).
+			Msg("buffer limit reached, pausing fetching until records are acknowledged")
+	case l.paused && l.records <= l.maxRecords/2 && (l.maxBytes == 0 || l.bytes <= l.maxBytes/2):
+		l.paused = false
+		l.client.ResumeFetchTopics(l.topics...)
+		sdk.Logger(ctx).Info().
+			Int("bufferedRecords", l.records).
+			Int("bufferedBytes", l.bytes).
+			Msg("buffer drained, resuming fetching")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBufferLimiter_Bytes(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	cl := NewMockClient(gomock.NewController(t))
+	l := newBufferLimiter(cl, []string{"test"}, 0, 10)
+	is.Equal(l.maxRecords, defaultMaxBufferedRecords)
+
+	newRecord := func(offset int64) *kgo.Record {
+		return &kgo.Record{
+			Topic:   "test",
+			Offset:  offset,
+			Key:     []byte("k"),
+			Value:   []byte("val"),
+			Headers: []kgo.RecordHeader{{Key: "h", Value: []byte("v")}},
+		}
+	}
+	is.Equal(recordSize(newRecord(0)), 6)
+
+	l.Read(newRecord(0))
+	l.Update(ctx) // 6 bytes
+
+	cl.EXPECT().PauseFetchTopics("test")
+	l.Read(newRecord(1))
+	l.Update(ctx) // 12 bytes
+
+	l.Acked(ctx, Position{Topic: "test", Offset: 1}) // 6 bytes, more than half of the limit
+
+	cl.EXPECT().ResumeFetchTopics("test")
+	l.Acked(ctx, Position{Topic: "test", Offset: 0})
+	is.Equal(l.records, 0)
+	is.Equal(l.bytes, 0)
+
+	l.Acked(ctx, Position{Topic: "test", Offset: 0}) // already acked
+	is.Equal(l.records, 0)
+}
+
+func TestFranzConsumer_Consume_MaxBufferedRecords(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	cl := NewMockClient(gomock.NewController(t))
+	c := &FranzConsumer{
+		client: cl,
+		acker:  newBatchAcker(cl, 1000),
+		queues: newPartitionQueues(0),
+		buffer: newBufferLimiter(cl, []string{"test"}, 4, 0),
+	}
+
+	cl.EXPECT().PollFetches(ctx).Return(testFetches("test", map[int32]int{0: 3, 1: 2}))
+	cl.EXPECT().PollFetches(nil).Return(nil).AnyTimes()
+	cl.EXPECT().PauseFetchTopics("test")
+
+	var positions []Position
+	for range 5 {
+		rec, err := c.Consume(ctx)
+		is.NoErr(err)
+		positions = append(positions, Position{Topic: rec.Topic, Partition: rec.Partition, Offset: rec.Offset})
+	}
+	is.Equal(c.buffer.records, 5) // read records stay buffered until acked
+
+	is.NoErr(c.Ack(ctx, positions[0]))
+	is.NoErr(c.Ack(ctx, positions[1]))
+	cl.EXPECT().ResumeFetchTopics("test")
+	is.NoErr(c.Ack(ctx, positions[2]))
+	is.Equal(c.buffer.records, 2)
+}
+
+func TestFranzConsumer_Consume_MaxBufferedRecords_Revoked(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	cl := NewMockClient(gomock.NewController(t))
+	c := &FranzConsumer{
+		client: cl,
+		acker:  newBatchAcker(cl, 1000),
+		queues: newPartitionQueues(0),
+		buffer: newBufferLimiter(cl, []string{"test"}, 4, 0),
+		logger: sdk.Logger(ctx),
+	}
+
+	cl.EXPECT().PollFetches(ctx).Return(testFetches("test", map[int32]int{0: 3, 1: 2}))
+	cl.EXPECT().PollFetches(nil).Return(nil).AnyTimes()
+	cl.EXPECT().PauseFetchTopics("test")
+
+	var positions []Position
+	for range 5 {
+		rec, err := c.Consume(ctx)
+		is.NoErr(err)
+		positions = append(positions, Position{Topic: rec.Topic, Partition: rec.Partition, Offset: rec.Offset})
+	}
+	is.Equal(c.buffer.records, 5)
+
+	// the unacknowledged reads of a lost partition are dropped right away,
+	// fetching is resumed before the consumer is polled again
+	cl.EXPECT().ResumeFetchTopics("test")
+	c.onPartitionsLost(ctx, nil, map[string][]int32{"test": {0}})
+	is.Equal(c.buffer.records, 2)
+	is.Equal(len(c.buffer.unacked), 2)
+
+	// acks of the dropped reads are ignored by the buffer
+	for _, pos := range positions {
+		if pos.Partition == 0 {
+			c.buffer.Acked(ctx, pos)
+		}
+	}
+	is.Equal(c.buffer.records, 2)
+}
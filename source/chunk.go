@@ -0,0 +1,198 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/conduitio/conduit-connector-kafka/common"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// maxPendingChunkedRecords is the number of incomplete chunked records kept
+// per partition. If more records are pending, the oldest one is dropped.
+const maxPendingChunkedRecords = 10
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// chunkAssembler reassembles records that were split into chunks by the
+// destination connector. The destination writes the chunks of a record in
+// order, so chunks of records whose first chunk was not read (e.g. the
+// consumer started in the middle of the record, or the first chunk was
+// deleted by retention) are dropped. Incomplete records are dropped once a
+// record that is not a chunk is read from the same partition, or more than
+// maxPendingChunkedRecords records are pending, otherwise they would keep the
+// offset of the partition from being committed. The zero value is ready to
+// use. It is not safe for concurrent use.
+type chunkAssembler struct {
+	// pending contains incomplete chunked records by chunk ID, grouped by
+	// topic and partition.
+	pending map[topicPartition]map[string]*chunkedRecord
+}
+
+type chunkedRecord struct {
+	id       string
+	chunks   [][]byte
+	received int
+	// firstOffset is the offset of the first chunk that was received.
+	firstOffset int64
+}
+
+// Drop removes the incomplete chunked records of the partitions, e.g. when
+// the partitions are revoked. Their chunks are fetched again if the
+// partitions are assigned again.
+func (a *chunkAssembler) Drop(partitions map[topicPartition]bool) {
+	for tp := range partitions {
+		delete(a.pending, tp)
+	}
+}
+
+// Add adds a fetched record to the assembler. Records that are not chunks are
+// returned as is. Chunks are buffered until all chunks of a record are
+// received, after which the reassembled record is returned. If more chunks are
+// needed or the chunk is dropped, nil is returned.
+func (a *chunkAssembler) Add(ctx context.Context, r *kgo.Record) (*kgo.Record, error) {
+	chunk, ok, err := common.ParseChunk(r.Headers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chunk at %s/%d offset %d: %w", r.Topic, r.Partition, r.Offset, err)
+	}
+	tp := topicPartition{topic: r.Topic, partition: r.Partition}
+	if !ok {
+		for _, cr := range a.pending[tp] {
+			a.evict(ctx, tp, cr, "a record that is not a chunk was read after it")
+		}
+		return r, nil
+	}
+
+	cr, ok := a.pending[tp][chunk.ID]
+	if !ok {
+		if chunk.Index > 0 {
+			sdk.Logger(ctx).Warn().
+				Str("topic", r.Topic).
+				Int32("partition", r.Partition).
+				Int64("offset", r.Offset).
+				Str("chunkID", chunk.ID).
+				Int("chunkIndex", chunk.Index).
+				Msg("dropping chunk of a record whose first chunk was not read")
+			return nil, nil
+		}
+		if len(a.pending[tp]) >= maxPendingChunkedRecords {
+			a.evict(ctx, tp, a.oldest(tp), "too many incomplete chunked records are pending")
+		}
+		if a.pending == nil {
+			a.pending = make(map[topicPartition]map[string]*chunkedRecord)
+		}
+		if a.pending[tp] == nil {
+			a.pending[tp] = make(map[string]*chunkedRecord)
+		}
+		cr = &chunkedRecord{
+			id:          chunk.ID,
+			chunks:      make([][]byte, chunk.Count),
+			firstOffset: r.Offset,
+		}
+		a.pending[tp][chunk.ID] = cr
+	}
+	if len(cr.chunks) != chunk.Count {
+		return nil, fmt.Errorf("chunk %s at %s/%d offset %d has count %d, expected %d", chunk.ID, r.Topic, r.Partition, r.Offset, chunk.Count, len(cr.chunks))
+	}
+	if cr.chunks[chunk.Index] != nil {
+		// Duplicate chunk, caused by the destination producing the record
+		// again. The chunk ID is derived from the record, so the content is
+		// the same.
+		return nil, nil
+	}
+	cr.chunks[chunk.Index] = r.Value
+	cr.received++
+	if cr.received < len(cr.chunks) {
+		return nil, nil
+	}
+
+	a.remove(tp, cr)
+
+	// Use the last chunk as the base of the reassembled record, so the
+	// offset of the reassembled record points past all of its chunks.
+	out := *r
+	out.Value = bytes.Join(cr.chunks, nil)
+	out.Headers = make([]kgo.RecordHeader, 0, len(r.Headers))
+	for _, h := range r.Headers {
+		if !common.IsChunkHeader(h.Key) {
+			out.Headers = append(out.Headers, h)
+		}
+	}
+	return &out, nil
+}
+
+// oldest returns the pending record of the partition with the lowest offset.
+func (a *chunkAssembler) oldest(tp topicPartition) *chunkedRecord {
+	var oldest *chunkedRecord
+	for _, cr := range a.pending[tp] {
+		if oldest == nil || cr.firstOffset < oldest.firstOffset {
+			oldest = cr
+		}
+	}
+	return oldest
+}
+
+// evict drops an incomplete chunked record and logs the dropped data.
+func (a *chunkAssembler) evict(ctx context.Context, tp topicPartition, cr *chunkedRecord, reason string) {
+	sdk.Logger(ctx).Warn().
+		Str("topic", tp.topic).
+		Int32("partition", tp.partition).
+		Int64("offset", cr.firstOffset).
+		Str("chunkID", cr.id).
+		Int("receivedChunks", cr.received).
+		Int("chunkCount", len(cr.chunks)).
+		Msgf("dropping incomplete chunked record, %s", reason)
+	a.remove(tp, cr)
+}
+
+// remove removes a chunked record from the pending records.
+func (a *chunkAssembler) remove(tp topicPartition, cr *chunkedRecord) {
+	delete(a.pending[tp], cr.id)
+	if len(a.pending[tp]) == 0 {
+		delete(a.pending, tp)
+	}
+}
+
+// CommitRecord returns the record that should be committed once r is
+// acknowledged. If chunks of another record are still pending in the same
+// partition, committing r would skip them, so a record pointing right before
+// the first pending chunk is returned instead.
+func (a *chunkAssembler) CommitRecord(r *kgo.Record) *kgo.Record {
+	pending := a.pending[topicPartition{topic: r.Topic, partition: r.Partition}]
+	if len(pending) == 0 {
+		return r
+	}
+
+	minOffset := r.Offset
+	for _, cr := range pending {
+		minOffset = min(minOffset, cr.firstOffset)
+	}
+	if minOffset > r.Offset {
+		return r
+	}
+	return &kgo.Record{
+		Topic:       r.Topic,
+		Partition:   r.Partition,
+		LeaderEpoch: r.LeaderEpoch,
+		Offset:      minOffset - 1,
+	}
+}
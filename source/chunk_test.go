@@ -0,0 +1,137 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/conduitio/conduit-connector-kafka/common"
+	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func newTestChunk(offset int64, id string, index, count int, value string) *kgo.Record {
+	return &kgo.Record{
+		Topic:     "test",
+		Partition: 0,
+		Offset:    offset,
+		Key:       []byte("key"),
+		Value:     []byte(value),
+		Headers: append(
+			[]kgo.RecordHeader{{Key: "foo", Value: []byte("bar")}},
+			common.Chunk{ID: id, Index: index, Count: count}.Headers()...,
+		),
+	}
+}
+
+func TestChunkAssembler_Add(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	newChunk := newTestChunk
+
+	var a chunkAssembler
+
+	// chunks of two records are interleaved
+	got, err := a.Add(ctx, newChunk(10, "a", 0, 2, "hello "))
+	is.NoErr(err)
+	is.True(got == nil)
+	got, err = a.Add(ctx, newChunk(11, "b", 0, 2, "one "))
+	is.NoErr(err)
+	is.True(got == nil)
+	got, err = a.Add(ctx, newChunk(12, "b", 1, 2, "two"))
+	is.NoErr(err)
+	is.Equal(string(got.Value), "one two")
+	is.Equal(got.Offset, int64(12))
+	is.Equal(got.Headers, []kgo.RecordHeader{{Key: "foo", Value: []byte("bar")}})
+
+	// "a" is still pending, committing "b" must not skip it
+	is.Equal(a.CommitRecord(got).Offset, int64(9))
+
+	// duplicate chunks are ignored
+	got, err = a.Add(ctx, newChunk(13, "a", 0, 2, "hello "))
+	is.NoErr(err)
+	is.True(got == nil)
+	got, err = a.Add(ctx, newChunk(14, "a", 1, 2, "world"))
+	is.NoErr(err)
+	is.Equal(string(got.Value), "hello world")
+	is.Equal(a.CommitRecord(got), got)
+
+	// records that are not chunked are returned as is
+	plain := &kgo.Record{Topic: "test", Offset: 15, Value: []byte("plain")}
+	got, err = a.Add(ctx, plain)
+	is.NoErr(err)
+	is.Equal(got, plain)
+	is.Equal(a.CommitRecord(got), got)
+}
+
+func TestChunkAssembler_Add_InvalidChunk(t *testing.T) {
+	is := is.New(t)
+
+	ctx := context.Background()
+
+	var a chunkAssembler
+	_, err := a.Add(ctx, &kgo.Record{
+		Headers: common.Chunk{ID: "a", Index: 0, Count: 2}.Headers()[:2], // missing count
+	})
+	is.True(err != nil)
+
+	_, err = a.Add(ctx, &kgo.Record{Headers: common.Chunk{ID: "a", Index: 0, Count: 2}.Headers()})
+	is.NoErr(err)
+	_, err = a.Add(ctx, &kgo.Record{Headers: common.Chunk{ID: "a", Index: 1, Count: 3}.Headers()})
+	is.True(err != nil) // count doesn't match
+}
+
+func TestChunkAssembler_Add_Evict(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var a chunkAssembler
+
+	// the first chunk was not read, e.g. the consumer started in the middle
+	// of the record
+	got, err := a.Add(ctx, newTestChunk(10, "a", 1, 2, "world"))
+	is.NoErr(err)
+	is.True(got == nil)
+	is.Equal(len(a.pending), 0)
+
+	// the record is not complete when a record that is not chunked is read
+	got, err = a.Add(ctx, newTestChunk(11, "b", 0, 2, "hello "))
+	is.NoErr(err)
+	is.True(got == nil)
+	plain := &kgo.Record{Topic: "test", Offset: 12, Value: []byte("plain")}
+	got, err = a.Add(ctx, plain)
+	is.NoErr(err)
+	is.Equal(got, plain)
+	is.Equal(a.CommitRecord(got), got)
+	got, err = a.Add(ctx, newTestChunk(13, "b", 1, 2, "world"))
+	is.NoErr(err)
+	is.True(got == nil) // dropped, the first chunk was evicted
+
+	// the oldest record is dropped if too many records are pending
+	offset := int64(20)
+	for i := range maxPendingChunkedRecords + 1 {
+		got, err = a.Add(ctx, newTestChunk(offset, fmt.Sprintf("c%d", i), 0, 2, "hello "))
+		is.NoErr(err)
+		is.True(got == nil)
+		offset++
+	}
+	is.Equal(len(a.pending[topicPartition{topic: "test"}]), maxPendingChunkedRecords)
+	got, err = a.Add(ctx, newTestChunk(offset, "c1", 1, 2, "world"))
+	is.NoErr(err)
+	is.Equal(string(got.Value), "hello world")
+	is.Equal(a.CommitRecord(got).Offset, int64(21)) // c2 at offset 22 is pending
+}
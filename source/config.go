@@ -20,6 +20,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"path"
+	"slices"
 
 	"github.com/conduitio/conduit-connector-kafka/common"
 	sdk "github.com/conduitio/conduit-connector-sdk"
@@ -39,8 +42,123 @@ type Config struct {
 	ReadFromBeginning bool `json:"readFromBeginning"`
 	// GroupID defines the consumer group id.
 	GroupID string `json:"groupID"`
+	// Partitions is a list of topic partitions the source reads from using
+	// direct assignment instead of a consumer group, e.g.
+	// "orders:0,1,5,payments:2". Partitions without a topic belong to the
+	// preceding topic. A partition can be followed by a start offset and an
+	// optional end offset, e.g. "orders:0@100-200" reads the offsets 100 to
+	// 200 (inclusive) of partition 0. Partitions without a start offset start
+	// at the beginning or end, depending on ReadFromBeginning.
+	Partitions []string `json:"partitions"`
+	// Filter is a [Go template](https://pkg.go.dev/text/template) executed for
+	// each Kafka record before it is decoded, with the fields Topic,
+	// Partition, Offset, Timestamp, Key and Headers. Records for which it
+	// doesn't render "true" are skipped, their offsets are still committed.
+	Filter string `json:"filter"`
+	// PartitionQueueSize is the number of fetched records buffered per
+	// partition before fetching the partition is paused. Buffered records are
+	// read round-robin across partitions. If 0, the default of 1000 is used.
+	PartitionQueueSize int `json:"partitionQueueSize"`
+	// MaxBufferedRecords is the maximum number of records buffered by the
+	// source, i.e. fetched records and records that were read but not
+	// acknowledged yet. Fetching is paused when the limit is reached and
+	// resumed once half of the records are acknowledged. If 0, the default of
+	// 10000 is used.
+	MaxBufferedRecords int `json:"maxBufferedRecords"`
+	// MaxBufferedBytes is the maximum number of bytes (keys, values and
+	// headers) of records buffered by the source, see MaxBufferedRecords. If
+	// 0, the number of buffered bytes is not limited.
+	MaxBufferedBytes int `json:"maxBufferedBytes"`
+	// OffsetMismatchPolicy determines what happens when the source is resumed
+	// from a position whose offsets differ from the offsets committed by the
+	// consumer group, e.g. because the connector stopped before the offsets
+	// of acknowledged records were committed. With "position" the source
+	// resumes at the offsets in the position, with "group" it resumes at the
+	// committed offsets and with "fail" the connector stops with an error.
+	OffsetMismatchPolicy string `json:"offsetMismatchPolicy" default:"position" validate:"inclusion=position|group|fail"`
 	// RetryGroupJoinErrors determines whether the connector will continually retry on group join errors.
 	RetryGroupJoinErrors bool `json:"retryGroupJoinErrors" default:"true"`
+
+	// AttachSchemas determines whether structured keys and values are decoded
+	// and their schemas attached to the records. Kafka Connect envelopes
+	// (JSON objects with the fields "schema" and "payload") are replaced by
+	// their payload. Data in the Confluent wire format is decoded using the
+	// schema registry, if one is configured. Other data is read as raw data.
+	// Only keys and values with the raw format are decoded this way.
+	AttachSchemas bool `json:"attachSchemas"`
+	// SchemaRegistryURL is the URL of a Confluent compatible schema registry,
+	// used to decode keys and values written with Avro or JSON schemas when
+	// attachSchemas is enabled.
+	SchemaRegistryURL string `json:"schemaRegistry.url"`
+	// SchemaRegistryUsername is the username used to authenticate to the
+	// schema registry with basic authentication.
+	SchemaRegistryUsername string `json:"schemaRegistry.username"`
+	// SchemaRegistryPassword is the password used to authenticate to the
+	// schema registry with basic authentication.
+	SchemaRegistryPassword string `json:"schemaRegistry.password"`
+
+	// KeyFormat is the format used to decode record keys into structured data.
+	// Raw keys are not decoded.
+	KeyFormat string `json:"keyFormat" default:"raw" validate:"inclusion=raw|string|json|avro-with-schema-file|protobuf-with-descriptor|msgpack"`
+	// KeySchemaFile is the path to the Avro schema file (avro-with-schema-file)
+	// or the protobuf file descriptor set (protobuf-with-descriptor) used to
+	// decode record keys.
+	KeySchemaFile string `json:"keySchemaFile"`
+	// KeyMessageName is the fully qualified name of the protobuf message used
+	// to decode record keys (protobuf-with-descriptor).
+	KeyMessageName string `json:"keyMessageName"`
+	// ValueFormat is the format used to decode record values into structured
+	// data. Raw values are not decoded.
+	ValueFormat string `json:"valueFormat" default:"raw" validate:"inclusion=raw|string|json|avro-with-schema-file|protobuf-with-descriptor|msgpack"`
+	// ValueSchemaFile is the path to the Avro schema file
+	// (avro-with-schema-file) or the protobuf file descriptor set
+	// (protobuf-with-descriptor) used to decode record values.
+	ValueSchemaFile string `json:"valueSchemaFile"`
+	// ValueMessageName is the fully qualified name of the protobuf message
+	// used to decode record values (protobuf-with-descriptor).
+	ValueMessageName string `json:"valueMessageName"`
+	// DecodeErrorPolicy determines what happens with keys and values that
+	// can't be decoded using the configured format or don't match their
+	// schema. With "raw" they are read as raw data and the error is stored in
+	// the record metadata, with "fail" the connector stops with an error.
+	DecodeErrorPolicy string `json:"decodeErrorPolicy" default:"raw" validate:"inclusion=raw|fail"`
+
+	// HeadersEncoding is the encoding of header values stored in the record
+	// metadata. Use base64 or hex for binary headers.
+	HeadersEncoding string `json:"headers.encoding" default:"string" validate:"inclusion=string|base64|hex"`
+	// HeadersInclude is a list of patterns (e.g. "trace-*") of header keys
+	// stored in the record metadata. If empty, all headers are included.
+	HeadersInclude []string `json:"headers.include"`
+	// HeadersExclude is a list of patterns of header keys that are not stored
+	// in the record metadata, it takes precedence over HeadersInclude.
+	HeadersExclude []string `json:"headers.exclude"`
+	// HeadersRepeated determines how headers with repeated keys are stored.
+	// With "last" or "first" only the last or first value is stored, with
+	// "join" the values are joined using HeadersJoinSeparator and with
+	// "indexed" the repeated values are stored under the key with the suffix
+	// ".1", ".2" etc.
+	HeadersRepeated string `json:"headers.repeated" default:"last" validate:"inclusion=last|first|join|indexed"`
+	// HeadersJoinSeparator is the separator used to join the values of
+	// repeated headers.
+	HeadersJoinSeparator string `json:"headers.joinSeparator" default:","`
+
+	// ClusterName is the name of the cluster configured by the top-level
+	// connection parameters (servers, SASL and TLS). If set, it is stored in
+	// the record metadata and positions. Required if Clusters is set.
+	ClusterName string `json:"clusterName"`
+	// Clusters contains additional clusters the source consumes the same
+	// topics from, keyed by the cluster name. Each cluster has its own
+	// servers, SASL and TLS configuration, other parameters are shared.
+	Clusters map[string]ClusterConfig `json:"clusters"`
+}
+
+// ClusterConfig contains the connection parameters of an additional cluster.
+type ClusterConfig struct {
+	// Servers is a list of Kafka bootstrap servers of the cluster.
+	Servers []string `json:"servers"`
+
+	common.ConfigSASL
+	common.ConfigTLS
 }
 
 // Validate executes manual validations beyond what is defined in struct tags.
@@ -50,8 +168,13 @@ func (c *Config) Validate(ctx context.Context) error {
 	if err != nil {
 		multierr = append(multierr, err)
 	}
+	if err := c.ValidateClientOptionsFor(common.ClientConsumer); err != nil {
+		multierr = append(multierr, err)
+	}
 	// validate and set the topics.
-	if len(c.Topic) == 0 && len(c.Topics) == 0 {
+	if len(c.Partitions) > 0 {
+		multierr = append(multierr, c.validatePartitions()...)
+	} else if len(c.Topic) == 0 && len(c.Topics) == 0 {
 		multierr = append(multierr, fmt.Errorf("required parameter missing: %q", "topics"))
 	}
 	if len(c.Topic) > 0 && len(c.Topics) > 0 {
@@ -63,5 +186,122 @@ func (c *Config) Validate(ctx context.Context) error {
 		c.Topics = make([]string, 1)
 		c.Topics[0] = c.Topic
 	}
+	if c.SchemaRegistryURL != "" {
+		u, err := url.Parse(c.SchemaRegistryURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			multierr = append(multierr, fmt.Errorf("invalid schema registry URL %q, expected an http or https URL", c.SchemaRegistryURL))
+		}
+	}
+	if c.SchemaRegistryURL == "" && c.SchemaRegistryUsername != "" {
+		multierr = append(multierr, fmt.Errorf(`"schemaRegistry.username" requires "schemaRegistry.url" to be set`))
+	}
+	for _, p := range append(slices.Clone(c.HeadersInclude), c.HeadersExclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			multierr = append(multierr, fmt.Errorf("invalid header pattern %q: %w", p, err))
+		}
+	}
+	if len(c.Clusters) > 0 && c.ClusterName == "" {
+		multierr = append(multierr, fmt.Errorf(`"clusterName" is required if "clusters" are configured`))
+	}
+	for name, cc := range c.Clusters {
+		if err := cc.Validate(); err != nil {
+			multierr = append(multierr, fmt.Errorf("invalid cluster %q: %w", name, err))
+		}
+		if name == c.ClusterName {
+			multierr = append(multierr, fmt.Errorf("cluster %q is configured twice, it is the name of the top-level cluster", name))
+		}
+	}
+	if c.PartitionQueueSize < 0 {
+		multierr = append(multierr, errors.New("partitionQueueSize can't be negative"))
+	}
+	if c.MaxBufferedRecords < 0 {
+		multierr = append(multierr, errors.New("maxBufferedRecords can't be negative"))
+	}
+	if c.MaxBufferedBytes < 0 {
+		multierr = append(multierr, errors.New("maxBufferedBytes can't be negative"))
+	}
+	if _, err := c.ParseFilter(); err != nil {
+		multierr = append(multierr, err)
+	}
+	multierr = append(multierr, validateFormat("key", c.KeyFormat, c.KeySchemaFile, c.KeyMessageName)...)
+	multierr = append(multierr, validateFormat("value", c.ValueFormat, c.ValueSchemaFile, c.ValueMessageName)...)
 	return errors.Join(multierr...)
 }
+
+// validateFormat checks that the files and names required by the key or
+// value format are set.
+func validateFormat(prefix, format, schemaFile, messageName string) []error {
+	var multierr []error
+	if (format == FormatAvro || format == FormatProtobuf) && schemaFile == "" {
+		multierr = append(multierr, fmt.Errorf("%s format %q requires %q to be set", prefix, format, prefix+"SchemaFile"))
+	}
+	if format == FormatProtobuf && messageName == "" {
+		multierr = append(multierr, fmt.Errorf("%s format %q requires %q to be set", prefix, format, prefix+"MessageName"))
+	}
+	return multierr
+}
+
+// validatePartitions validates the partition assignment and sets the topics
+// to the assigned topics.
+func (c *Config) validatePartitions() []error {
+	var multierr []error
+	a, err := c.PartitionAssignment()
+	if err != nil {
+		multierr = append(multierr, err)
+	}
+	if len(c.Topic) > 0 || len(c.Topics) > 0 {
+		multierr = append(multierr, fmt.Errorf(`can't provide both "partitions" and "topics" parameters, the topics are taken from the partitions`))
+	}
+	if c.GroupID != "" {
+		multierr = append(multierr, fmt.Errorf(`can't provide both "partitions" and "groupID" parameters, assigned partitions are read without a consumer group`))
+	}
+	if len(c.Clusters) > 0 {
+		multierr = append(multierr, fmt.Errorf(`can't provide both "partitions" and "clusters" parameters`))
+	}
+	if len(multierr) == 0 {
+		c.Topics = a.Topics()
+	}
+	return multierr
+}
+
+// PartitionAssignment returns the partitions configured in Partitions.
+func (c Config) PartitionAssignment() (PartitionAssignment, error) {
+	start := OffsetEnd
+	if c.ReadFromBeginning {
+		start = OffsetStart
+	}
+	return ParsePartitions(c.Partitions, start)
+}
+
+// Validate executes manual validations of the cluster configuration.
+func (c ClusterConfig) Validate() error {
+	var multierr []error
+	if len(c.Servers) == 0 {
+		multierr = append(multierr, fmt.Errorf("required parameter missing: %q", "servers"))
+	}
+	if err := c.ConfigSASL.Validate(); err != nil {
+		multierr = append(multierr, err)
+	}
+	if err := c.ConfigTLS.Validate(); err != nil {
+		multierr = append(multierr, err)
+	}
+	return errors.Join(multierr...)
+}
+
+// ClusterConfigs returns the configuration of each cluster the source
+// consumes from, keyed by the cluster name. The configuration of an
+// additional cluster is the top-level configuration with the servers, SASL
+// and TLS parameters of the cluster.
+func (c Config) ClusterConfigs() map[string]Config {
+	out := map[string]Config{c.ClusterName: c}
+	for name, cc := range c.Clusters {
+		cfg := c
+		cfg.Servers = cc.Servers
+		cfg.ConfigSASL = cc.ConfigSASL
+		cfg.ConfigTLS = cc.ConfigTLS
+		cfg.ClusterName = name
+		cfg.Clusters = nil
+		out[name] = cfg
+	}
+	return out
+}
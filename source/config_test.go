@@ -76,3 +76,184 @@ func TestConfig_ValidateTopics(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_ValidateSchemaRegistry(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{{
+		name:    "valid",
+		cfg:     Config{SchemaRegistryURL: "http://localhost:8081", SchemaRegistryUsername: "user"},
+		wantErr: "",
+	}, {
+		name:    "invalid URL",
+		cfg:     Config{SchemaRegistryURL: "localhost:8081"},
+		wantErr: `invalid schema registry URL "localhost:8081"`,
+	}, {
+		name:    "username without URL",
+		cfg:     Config{SchemaRegistryUsername: "user"},
+		wantErr: `"schemaRegistry.username" requires "schemaRegistry.url" to be set`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			tc.cfg.Topics = []string{"topic1"}
+			err := tc.cfg.Validate(context.Background())
+			if tc.wantErr != "" {
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tc.wantErr))
+			} else {
+				is.NoErr(err)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateClientOptions(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options map[string]string
+		wantErr string
+	}{{
+		name:    "consumer option",
+		options: map[string]string{"fetch.max.wait": "1s", "session.timeout": "10s"},
+	}, {
+		name:    "producer option",
+		options: map[string]string{"max.inflight.per.broker": "5"},
+		wantErr: `client option "max.inflight.per.broker" only applies to the producer, it can't be used in the consumer`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			cfg := Config{Topics: []string{"topic1"}}
+			cfg.ClientOptions = tc.options
+			err := cfg.Validate(context.Background())
+			if tc.wantErr != "" {
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tc.wantErr))
+			} else {
+				is.NoErr(err)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateClusters(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{{
+		name: "valid",
+		cfg: Config{
+			Topics:      []string{"topic1"},
+			ClusterName: "eu",
+			Clusters:    map[string]ClusterConfig{"us": {Servers: []string{"us:9092"}}},
+		},
+	}, {
+		name: "missing cluster name",
+		cfg: Config{
+			Topics:   []string{"topic1"},
+			Clusters: map[string]ClusterConfig{"us": {Servers: []string{"us:9092"}}},
+		},
+		wantErr: `"clusterName" is required if "clusters" are configured`,
+	}, {
+		name: "duplicate cluster name",
+		cfg: Config{
+			Topics:      []string{"topic1"},
+			ClusterName: "us",
+			Clusters:    map[string]ClusterConfig{"us": {Servers: []string{"us:9092"}}},
+		},
+		wantErr: `cluster "us" is configured twice`,
+	}, {
+		name: "missing servers",
+		cfg: Config{
+			Topics:      []string{"topic1"},
+			ClusterName: "eu",
+			Clusters:    map[string]ClusterConfig{"us": {}},
+		},
+		wantErr: `invalid cluster "us": required parameter missing: "servers"`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			err := tc.cfg.Validate(context.Background())
+			if tc.wantErr != "" {
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tc.wantErr))
+			} else {
+				is.NoErr(err)
+			}
+		})
+	}
+}
+
+func TestConfig_ClusterConfigs(t *testing.T) {
+	is := is.New(t)
+
+	cfg := Config{
+		Topics:      []string{"topic1"},
+		GroupID:     "group",
+		ClusterName: "eu",
+		Clusters: map[string]ClusterConfig{
+			"us": {Servers: []string{"us:9092"}},
+		},
+	}
+	cfg.Servers = []string{"eu:9092"}
+	cfg.ConfigSASL.Mechanism = "PLAIN"
+
+	got := cfg.ClusterConfigs()
+	is.Equal(len(got), 2)
+	is.Equal(got["eu"], cfg)
+
+	us := got["us"]
+	is.Equal(us.Servers, []string{"us:9092"})
+	is.Equal(us.ConfigSASL.Mechanism, "") // SASL is configured per cluster
+	is.Equal(us.ClusterName, "us")
+	is.Equal(us.Clusters, nil)
+	is.Equal(us.Topics, cfg.Topics)
+	is.Equal(us.GroupID, cfg.GroupID)
+}
+
+func TestConfig_ValidatePartitions(t *testing.T) {
+	testCases := []struct {
+		name       string
+		cfg        Config
+		wantErr    string
+		wantTopics []string
+	}{{
+		name:       "valid",
+		cfg:        Config{Partitions: []string{"orders:0", "1", "payments:0@5-10"}},
+		wantTopics: []string{"orders", "payments"},
+	}, {
+		name:    "invalid partition",
+		cfg:     Config{Partitions: []string{"0"}},
+		wantErr: `invalid partition "0": missing topic`,
+	}, {
+		name:    "topics and partitions",
+		cfg:     Config{Partitions: []string{"orders:0"}, Topics: []string{"orders"}},
+		wantErr: `can't provide both "partitions" and "topics" parameters`,
+	}, {
+		name:    "group ID and partitions",
+		cfg:     Config{Partitions: []string{"orders:0"}, GroupID: "group"},
+		wantErr: `can't provide both "partitions" and "groupID" parameters`,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			err := tc.cfg.Validate(context.Background())
+			if tc.wantErr != "" {
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tc.wantErr))
+			} else {
+				is.NoErr(err)
+				is.Equal(tc.cfg.Topics, tc.wantTopics)
+			}
+		})
+	}
+}
@@ -27,8 +27,10 @@ type Consumer interface {
 	// Consume returns the next message from the configured topic. Waits until a
 	// message is available or until the context is canceled.
 	Consume(context.Context) (*Record, error)
-	// Ack commits the offset to Kafka.
-	Ack(context.Context) error
+	// Ack acknowledges the record at the position, its offset is committed to
+	// Kafka once all records read before it in the same partition are
+	// acknowledged.
+	Ack(context.Context, Position) error
 	// Close this consumer and the associated resources.
 	Close(context.Context) error
 }
@@ -41,17 +41,17 @@ func (m *MockConsumer) EXPECT() *MockConsumerMockRecorder {
 }
 
 // Ack mocks base method.
-func (m *MockConsumer) Ack(arg0 context.Context) error {
+func (m *MockConsumer) Ack(arg0 context.Context, arg1 Position) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Ack", arg0)
+	ret := m.ctrl.Call(m, "Ack", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Ack indicates an expected call of Ack.
-func (mr *MockConsumerMockRecorder) Ack(arg0 any) *gomock.Call {
+func (mr *MockConsumerMockRecorder) Ack(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockConsumer)(nil).Ack), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockConsumer)(nil).Ack), arg0, arg1)
 }
 
 // Close mocks base method.
@@ -0,0 +1,78 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// FilterFn returns true if the record should be read by the source.
+type FilterFn func(*kgo.Record) (bool, error)
+
+// FilterData is the data the filter template is executed with.
+type FilterData struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+	// Key is the record key, it is empty if the key is null.
+	Key string
+	// Headers contains the record headers, if a key is repeated it contains
+	// the last value.
+	Headers map[string]string
+}
+
+// ParseFilter returns the function that filters records using the template in
+// Config.Filter, or nil if no filter is configured.
+func (c Config) ParseFilter() (FilterFn, error) {
+	if c.Filter == "" {
+		return nil, nil
+	}
+	t, err := template.New("filter").Funcs(sprig.FuncMap()).Parse(c.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("filter is not a valid Go template: %w", err)
+	}
+
+	return func(r *kgo.Record) (bool, error) {
+		data := FilterData{
+			Topic:     r.Topic,
+			Partition: r.Partition,
+			Offset:    r.Offset,
+			Timestamp: r.Timestamp,
+			Key:       string(r.Key),
+			Headers:   make(map[string]string, len(r.Headers)),
+		}
+		for _, h := range r.Headers {
+			data.Headers[h.Key] = string(h.Value)
+		}
+
+		var sb strings.Builder
+		if err := t.Execute(&sb, data); err != nil {
+			return false, fmt.Errorf("failed to execute filter template: %w", err)
+		}
+		ok, err := strconv.ParseBool(strings.TrimSpace(sb.String()))
+		if err != nil {
+			return false, fmt.Errorf("filter template rendered %q for the record at %s/%d offset %d, expected a boolean", sb.String(), r.Topic, r.Partition, r.Offset)
+		}
+		return ok, nil
+	}, nil
+}
@@ -0,0 +1,145 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/mock/gomock"
+)
+
+func TestConfig_ParseFilter(t *testing.T) {
+	rec := &kgo.Record{
+		Topic:     "orders",
+		Partition: 1,
+		Offset:    42,
+		Timestamp: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Key:       []byte("eu-123"),
+		Headers: []kgo.RecordHeader{
+			{Key: "type", Value: []byte("order")},
+			{Key: "type", Value: []byte("refund")},
+		},
+	}
+
+	testCases := []struct {
+		name    string
+		filter  string
+		want    bool
+		wantErr bool
+	}{
+		{name: "key prefix", filter: `{{ hasPrefix "eu-" .Key }}`, want: true},
+		{name: "header", filter: `{{ eq (index .Headers "type") "order" }}`, want: false}, // last value is used
+		{name: "partition", filter: `{{ or (eq .Partition 0) (eq .Partition 1) }}`, want: true},
+		{name: "timestamp", filter: `{{ .Timestamp.After (toDate "2006-01-02" "2024-12-31") }}`, want: true},
+		{name: "offset", filter: `{{ lt .Offset 10 }}`, want: false},
+		{name: "not a boolean", filter: `{{ .Key }}`, wantErr: true},
+		{name: "missing field", filter: `{{ .Value }}`, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			f, err := Config{Filter: tc.filter}.ParseFilter()
+			is.NoErr(err)
+
+			got, err := f(rec)
+			if tc.wantErr {
+				is.True(err != nil)
+				return
+			}
+			is.NoErr(err)
+			is.Equal(got, tc.want)
+		})
+	}
+
+	f, err := Config{}.ParseFilter()
+	is.New(t).NoErr(err)
+	is.New(t).True(f == nil)
+
+	_, err = Config{Filter: `{{ eq .Key`}.ParseFilter()
+	is.New(t).True(err != nil)
+}
+
+func TestFranzConsumer_Consume_Filter(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	records := []*kgo.Record{
+		{Topic: "test", Partition: 0, Offset: 0, Key: []byte("skip")},
+		{Topic: "test", Partition: 0, Offset: 1, Key: []byte("read")},
+		{Topic: "test", Partition: 0, Offset: 2, Key: []byte("skip")},
+		{Topic: "test", Partition: 0, Offset: 3, Key: []byte("read")},
+		{Topic: "test", Partition: 0, Offset: 4, Key: []byte("skip")},
+	}
+
+	cl := NewMockClient(gomock.NewController(t))
+	cl.EXPECT().
+		PollFetches(gomock.Any()).
+		Return(kgo.Fetches{{Topics: []kgo.FetchTopic{{
+			Topic:      "test",
+			Partitions: []kgo.FetchPartition{{Partition: 0, Records: records}},
+		}}}})
+	cl.EXPECT().PollFetches(nil).Return(nil).AnyTimes()
+
+	filter, err := Config{Filter: `{{ eq .Key "read" }}`}.ParseFilter()
+	is.NoErr(err)
+	c := &FranzConsumer{
+		client: cl,
+		acker:  newBatchAcker(cl, 1000),
+		queues: newPartitionQueues(0),
+		buffer: newBufferLimiter(cl, []string{"test"}, 0, 0),
+		filter: filter,
+	}
+
+	got, err := c.Consume(ctx)
+	is.NoErr(err)
+	is.Equal(got.Offset, int64(1))
+	is.NoErr(c.Ack(ctx, Position{Topic: "test", Offset: 1}))
+	got, err = c.Consume(ctx)
+	is.NoErr(err)
+	is.Equal(got.Offset, int64(3))
+
+	// skipped records are committed together with the records read before
+	// them, the last record was not consumed yet
+	is.NoErr(c.Ack(ctx, Position{Topic: "test", Offset: 3}))
+	cl.EXPECT().CommitRecords(gomock.Any(), records[3]).Return(nil)
+	is.NoErr(c.acker.Flush(ctx))
+}
+
+func TestBatchAcker_Skip(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	records := []*kgo.Record{
+		{Topic: "test", Offset: 0},
+		{Topic: "test", Offset: 1},
+		{Topic: "test", Offset: 2},
+	}
+
+	cl := NewMockClient(gomock.NewController(t))
+	a := newBatchAcker(cl, 3)
+
+	// skipped records without pending records count towards the batch
+	is.NoErr(a.Skip(ctx, records[0], records[0]))
+	a.Read(records[1], records[1])
+	is.NoErr(a.Skip(ctx, records[2], records[2])) // waits for records[1]
+
+	cl.EXPECT().CommitRecords(gomock.Any(), records[2]).Return(nil)
+	is.NoErr(a.Ack(ctx, Position{Topic: "test", Offset: 1}))
+	is.Equal(a.acked, 0)
+}
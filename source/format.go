@@ -0,0 +1,303 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	cschema "github.com/conduitio/conduit-commons/schema"
+	"github.com/goccy/go-json"
+	"github.com/hamba/avro/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Formats of keys and values, see Config.KeyFormat and Config.ValueFormat.
+const (
+	FormatRaw      = "raw"
+	FormatString   = "string"
+	FormatJSON     = "json"
+	FormatAvro     = "avro-with-schema-file"
+	FormatProtobuf = "protobuf-with-descriptor"
+	FormatMsgpack  = "msgpack"
+)
+
+// Policies for keys and values that can't be decoded, see
+// Config.DecodeErrorPolicy.
+const (
+	DecodeErrorPolicyRaw  = "raw"
+	DecodeErrorPolicyFail = "fail"
+)
+
+// formatValueField is the field containing decoded values that are not
+// objects (e.g. strings or JSON arrays), structured data needs to be a map.
+const formatValueField = "value"
+
+// FormatDecoder decodes keys or values into structured data.
+type FormatDecoder interface {
+	Decode([]byte) (opencdc.StructuredData, error)
+}
+
+// NewFormatDecoder returns a decoder for the format. The schema file is the
+// Avro schema for FormatAvro and the file descriptor set for
+// FormatProtobuf, in which case the message name is the fully qualified name
+// of the decoded message. FormatRaw has no decoder, data in that format is not
+// decoded.
+func NewFormatDecoder(format, schemaFile, messageName string) (FormatDecoder, error) {
+	switch format {
+	case FormatString:
+		return stringDecoder{}, nil
+	case FormatJSON:
+		return jsonDecoder{}, nil
+	case FormatAvro:
+		return newAvroDecoder(schemaFile)
+	case FormatProtobuf:
+		return newProtobufDecoder(schemaFile, messageName)
+	case FormatMsgpack:
+		return msgpackDecoder{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// wrapValue returns objects as structured data and wraps other values into
+// structured data with a single field.
+func wrapValue(v any) opencdc.StructuredData {
+	if m, ok := v.(map[string]any); ok {
+		return m
+	}
+	return opencdc.StructuredData{formatValueField: v}
+}
+
+type stringDecoder struct{}
+
+func (stringDecoder) Decode(b []byte) (opencdc.StructuredData, error) {
+	if !utf8.Valid(b) {
+		return nil, errors.New("data is not valid UTF-8")
+	}
+	return wrapValue(string(b)), nil
+}
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) Decode(b []byte) (opencdc.StructuredData, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber() // don't lose precision of large integers
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("data is not valid JSON: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("data is not valid JSON: unexpected data after the top-level value")
+	}
+	return wrapValue(jsonNumbers(v)), nil
+}
+
+// jsonNumbers replaces numbers with int64 values if they are integers that fit
+// into an int64, and with float64 values otherwise.
+func jsonNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, mv := range v {
+			v[k] = jsonNumbers(mv)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = jsonNumbers(item)
+		}
+	}
+	return v
+}
+
+// avroDecoder decodes data in the Avro binary encoding using the schema
+// loaded from a file.
+type avroDecoder struct {
+	schema avro.Schema
+	// record is used to decode records, it resolves unions the same way as
+	// the schema middleware does.
+	record cschema.Schema
+}
+
+func newAvroDecoder(schemaFile string) (avroDecoder, error) {
+	b, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return avroDecoder{}, fmt.Errorf("failed to read avro schema file: %w", err)
+	}
+	s, err := avro.ParseBytes(b)
+	if err != nil {
+		return avroDecoder{}, fmt.Errorf("failed to parse avro schema file %q: %w", schemaFile, err)
+	}
+	return avroDecoder{
+		schema: s,
+		record: cschema.Schema{Type: cschema.TypeAvro, Bytes: b},
+	}, nil
+}
+
+func (d avroDecoder) Decode(b []byte) (opencdc.StructuredData, error) {
+	if d.schema.Type() == avro.Record {
+		var sd opencdc.StructuredData
+		if err := d.record.Unmarshal(b, &sd); err != nil {
+			return nil, err
+		}
+		return sd, nil
+	}
+	var v any
+	if err := avro.Unmarshal(d.schema, b, &v); err != nil {
+		return nil, err
+	}
+	return wrapValue(v), nil
+}
+
+// protobufDecoder decodes protobuf messages using a message descriptor loaded
+// from a file descriptor set (e.g. created with protoc --include_imports
+// --descriptor_set_out).
+type protobufDecoder struct {
+	md protoreflect.MessageDescriptor
+}
+
+func newProtobufDecoder(descriptorFile, messageName string) (protobufDecoder, error) {
+	b, err := os.ReadFile(descriptorFile)
+	if err != nil {
+		return protobufDecoder{}, fmt.Errorf("failed to read protobuf descriptor file: %w", err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return protobufDecoder{}, fmt.Errorf("failed to parse protobuf descriptor file %q: %w", descriptorFile, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return protobufDecoder{}, fmt.Errorf("invalid protobuf descriptor file %q: %w", descriptorFile, err)
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return protobufDecoder{}, fmt.Errorf("failed to find message %q: %w", messageName, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return protobufDecoder{}, fmt.Errorf("%q is not a message", messageName)
+	}
+	return protobufDecoder{md: md}, nil
+}
+
+func (d protobufDecoder) Decode(b []byte) (opencdc.StructuredData, error) {
+	msg := dynamicpb.NewMessage(d.md)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return nil, err
+	}
+	return protobufMessage(msg), nil
+}
+
+// protobufMessage converts the message into a map keyed by field names. All
+// fields are included, unset messages are nil and unset fields of a oneof are
+// omitted. Enums are converted into the names of their values.
+func protobufMessage(m protoreflect.Message) map[string]any {
+	fields := m.Descriptor().Fields()
+	out := make(map[string]any, fields.Len())
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		name := string(fd.Name())
+		switch {
+		case fd.ContainingOneof() != nil && !m.Has(fd):
+			continue
+		case fd.IsList():
+			list := m.Get(fd).List()
+			items := make([]any, list.Len())
+			for j := range list.Len() {
+				items[j] = protobufValue(fd, list.Get(j))
+			}
+			out[name] = items
+		case fd.IsMap():
+			entries := make(map[string]any, m.Get(fd).Map().Len())
+			m.Get(fd).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				entries[k.String()] = protobufValue(fd.MapValue(), v)
+				return true
+			})
+			out[name] = entries
+		case fd.Message() != nil && !m.Has(fd):
+			out[name] = nil
+		default:
+			out[name] = protobufValue(fd, m.Get(fd))
+		}
+	}
+	return out
+}
+
+func protobufValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protobufMessage(v.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum()) // unknown values are kept as numbers
+	default:
+		return v.Interface()
+	}
+}
+
+type msgpackDecoder struct{}
+
+func (msgpackDecoder) Decode(b []byte) (opencdc.StructuredData, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	dec.UseLooseInterfaceDecoding(true) // decode integers as int64 and uint64
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (any, error) {
+		return d.DecodeUntypedMap() // keys are not necessarily strings
+	})
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return wrapValue(msgpackMaps(v)), nil
+}
+
+// msgpackMaps converts maps with non-string keys into maps with string keys,
+// so nested maps have the same type as the structured data.
+func msgpackMaps(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, mv := range v {
+			v[k] = msgpackMaps(mv)
+		}
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, mv := range v {
+			m[fmt.Sprint(k)] = msgpackMaps(mv)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = msgpackMaps(item)
+		}
+	}
+	return v
+}
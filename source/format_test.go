@@ -0,0 +1,164 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/hamba/avro/v2"
+	"github.com/matryer/is"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestFormatDecoder(t *testing.T) {
+	testCases := []struct {
+		name    string
+		format  string
+		data    []byte
+		want    opencdc.StructuredData
+		wantErr bool
+	}{
+		{name: "string", format: FormatString, data: []byte("foo"), want: opencdc.StructuredData{"value": "foo"}},
+		{name: "string invalid", format: FormatString, data: []byte{0xff}, wantErr: true},
+		{
+			name:   "json object",
+			format: FormatJSON,
+			data:   []byte(`{"id": 9007199254740993, "ratio": 0.5, "tags": ["a"], "nested": {"x": 1}}`),
+			want: opencdc.StructuredData{
+				"id":     int64(9007199254740993),
+				"ratio":  0.5,
+				"tags":   []any{"a"},
+				"nested": map[string]any{"x": int64(1)},
+			},
+		},
+		{name: "json array", format: FormatJSON, data: []byte(`[1, 2]`), want: opencdc.StructuredData{"value": []any{int64(1), int64(2)}}},
+		{name: "json invalid", format: FormatJSON, data: []byte(`{"id":`), wantErr: true},
+		{name: "json trailing data", format: FormatJSON, data: []byte(`{} {}`), wantErr: true},
+		{
+			name:   "msgpack",
+			format: FormatMsgpack,
+			data: func() []byte {
+				b, _ := msgpack.Marshal(map[string]any{"id": 1, "nested": map[int]string{1: "a"}})
+				return b
+			}(),
+			want: opencdc.StructuredData{"id": int64(1), "nested": map[string]any{"1": "a"}},
+		},
+		{name: "msgpack invalid", format: FormatMsgpack, data: []byte{0xc1}, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			dec, err := NewFormatDecoder(tc.format, "", "")
+			is.NoErr(err)
+
+			got, err := dec.Decode(tc.data)
+			if tc.wantErr {
+				is.True(err != nil)
+				return
+			}
+			is.NoErr(err)
+			is.Equal(got, tc.want)
+		})
+	}
+}
+
+func TestFormatDecoder_Avro(t *testing.T) {
+	is := is.New(t)
+
+	schema := `{"type": "record", "name": "Order", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "note", "type": ["null", "string"]}
+	]}`
+	file := filepath.Join(t.TempDir(), "order.avsc")
+	is.NoErr(os.WriteFile(file, []byte(schema), 0o600))
+
+	dec, err := NewFormatDecoder(FormatAvro, file, "")
+	is.NoErr(err)
+
+	b, err := avro.Marshal(avro.MustParse(schema), map[string]any{"id": int64(1), "note": "foo"})
+	is.NoErr(err)
+	got, err := dec.Decode(b)
+	is.NoErr(err)
+	is.Equal(got, opencdc.StructuredData{"id": int64(1), "note": "foo"})
+
+	_, err = dec.Decode([]byte{0x02, 0x04})
+	is.True(err != nil) // invalid union index
+
+	_, err = NewFormatDecoder(FormatAvro, filepath.Join(t.TempDir(), "missing.avsc"), "")
+	is.True(err != nil)
+}
+
+func TestFormatDecoder_Protobuf(t *testing.T) {
+	is := is.New(t)
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("order.proto"),
+		Package: proto.String("shop"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("NEW"), Number: proto.Int32(0)},
+				{Name: proto.String("DONE"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Order"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("id"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+				{Name: proto.String("tags"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()},
+				{Name: proto.String("status"), Number: proto.Int32(3), Type: descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum(), TypeName: proto.String(".shop.Status"), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+				{Name: proto.String("parent"), Number: proto.Int32(4), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".shop.Order"), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+			},
+		}},
+	}
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdp}})
+	is.NoErr(err)
+	file := filepath.Join(t.TempDir(), "order.desc")
+	is.NoErr(os.WriteFile(file, set, 0o600))
+
+	dec, err := NewFormatDecoder(FormatProtobuf, file, "shop.Order")
+	is.NoErr(err)
+
+	fd, err := protodesc.NewFile(fdp, nil)
+	is.NoErr(err)
+	md := fd.Messages().ByName("Order")
+	msg := dynamicpb.NewMessage(md)
+	msg.Set(md.Fields().ByName("id"), protoreflect.ValueOfInt64(1))
+	msg.Mutable(md.Fields().ByName("tags")).List().Append(protoreflect.ValueOfString("a"))
+	msg.Set(md.Fields().ByName("status"), protoreflect.ValueOfEnum(1))
+	b, err := proto.Marshal(msg)
+	is.NoErr(err)
+
+	got, err := dec.Decode(b)
+	is.NoErr(err)
+	is.Equal(got, opencdc.StructuredData{
+		"id":     int64(1),
+		"tags":   []any{"a"},
+		"status": "DONE",
+		"parent": nil,
+	})
+
+	_, err = NewFormatDecoder(FormatProtobuf, file, "shop.Missing")
+	is.True(err != nil)
+}
@@ -17,21 +17,54 @@
 package source
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 
+	"github.com/conduitio/conduit-connector-kafka/common"
 	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/rs/zerolog"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 )
 
 type FranzConsumer struct {
 	client Client
 	acker  *batchAcker
 
-	iter *kgo.FetchesRecordIter
+	queues *partitionQueues
+	buffer *bufferLimiter
+	chunks chunkAssembler
+	// polled is true if the client was polled since the last record was
+	// taken from the queues.
+	polled bool
+
+	// cluster is the name of the cluster, it is attached to consumed records.
+	cluster string
+	// filter is nil if all records are read.
+	filter FilterFn
+	// partitions tracks the offsets of assigned partitions, it is nil if the
+	// consumer reads from a consumer group.
+	partitions *partitionTracker
+	// offsets contains the offsets of the next records to read from each
+	// partition of the consumer group, it is unused if the consumer reads
+	// assigned partitions.
+	offsets PartitionOffsets
+	// reconciler is nil if the consumer reads assigned partitions.
+	reconciler *offsetReconciler
+	// logger is used in callbacks of the client.
+	logger *zerolog.Logger
+
+	// revoked contains the partitions of the consumer group that were
+	// revoked or lost since the consumer last dropped their state.
+	revoked  map[topicPartition]bool
+	revokedM sync.Mutex
 
 	retryGroupJoinErrors bool
 }
@@ -40,126 +73,652 @@ type FranzConsumer struct {
 type Client interface {
 	Close()
 	CommitRecords(ctx context.Context, rs ...*kgo.Record) error
+	CommitOffsetsSync(
+		ctx context.Context,
+		uncommitted map[string]map[int32]kgo.EpochOffset,
+		onDone func(*kgo.Client, *kmsg.OffsetCommitRequest, *kmsg.OffsetCommitResponse, error),
+	)
 	OptValue(opt any) any
+	PauseFetchPartitions(map[string][]int32) map[string][]int32
+	PauseFetchTopics(...string) []string
 	PollFetches(ctx context.Context) kgo.Fetches
+	ResumeFetchPartitions(map[string][]int32)
+	ResumeFetchTopics(...string)
 }
 
 var _ Consumer = (*FranzConsumer)(nil)
 
-func NewFranzConsumer(ctx context.Context, cfg Config) (*FranzConsumer, error) {
+// NewFranzConsumer creates a consumer that reads from the consumer group.
+// Offsets are the offsets of the next records to read stored in the position
+// the source is resumed from, they are reconciled with the offsets committed by
+// the group according to Config.OffsetMismatchPolicy. If offsets is nil, the
+// consumer starts at the committed offsets.
+func NewFranzConsumer(ctx context.Context, cfg Config, offsets PartitionOffsets) (*FranzConsumer, error) {
+	filter, err := cfg.ParseFilter()
+	if err != nil {
+		return nil, err
+	}
+	c := &FranzConsumer{
+		queues:               newPartitionQueues(cfg.PartitionQueueSize),
+		cluster:              cfg.ClusterName,
+		filter:               filter,
+		reconciler:           newOffsetReconciler(sdk.Logger(ctx), cfg.OffsetMismatchPolicy, offsets),
+		logger:               sdk.Logger(ctx),
+		retryGroupJoinErrors: cfg.RetryGroupJoinErrors,
+	}
+
 	opts := cfg.FranzClientOpts(sdk.Logger(ctx))
+	opts = append(opts, cfg.ClientOpts(common.ClientConsumer)...)
 	opts = append(opts, []kgo.Opt{
 		kgo.ConsumerGroup(cfg.GroupID),
 		kgo.ConsumeTopics(cfg.Topics...),
+		kgo.AdjustFetchOffsetsFn(c.reconciler.Adjust),
+		kgo.OnPartitionsRevoked(c.onPartitionsRevoked),
+		kgo.OnPartitionsLost(c.onPartitionsLost),
 	}...)
 
 	if !cfg.ReadFromBeginning {
 		opts = append(opts, kgo.ConsumeResetOffset(kgo.NewOffset().AtEnd()))
 	}
 	if cfg.GroupID != "" {
-		opts = append(opts, kgo.DisableAutoCommit()) // TODO research if we need to add OnPartitionsRevoked (see DisableAutoCommit doc)
+		// offsets are committed by the acker, including when partitions are
+		// revoked, see onPartitionsRevoked
+		opts = append(opts, kgo.DisableAutoCommit())
+	}
+
+	cl, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+	c.client = cl
+	c.acker = newBatchAcker(cl, 1000)
+	c.buffer = newBufferLimiter(cl, cfg.Topics, cfg.MaxBufferedRecords, cfg.MaxBufferedBytes)
+	return c, nil
+}
+
+// NewPartitionConsumer creates a consumer that reads the assigned partitions
+// without a consumer group. Offsets are not committed to Kafka, instead the
+// offsets of all assigned partitions are attached to consumed records (see
+// RecordPartitionOffsets). Start offsets are resolved to concrete offsets
+// first (see PartitionAssignment.resolveOffsets).
+func NewPartitionConsumer(ctx context.Context, cfg Config, a PartitionAssignment) (*FranzConsumer, error) {
+	a, err := resolvePartitionOffsets(ctx, cfg, a)
+	if err != nil {
+		return nil, err
 	}
 
+	offsets := make(map[string]map[int32]kgo.Offset, len(a))
+	for topic, partitions := range a {
+		offsets[topic] = make(map[int32]kgo.Offset, len(partitions))
+		for partition, r := range partitions {
+			offsets[topic][partition] = kgoOffset(r.Start)
+		}
+	}
+
+	opts := cfg.FranzClientOpts(sdk.Logger(ctx))
+	opts = append(opts, cfg.ClientOpts(common.ClientConsumer)...)
+	opts = append(opts, kgo.ConsumePartitions(offsets))
+	// used if records are deleted before they are read, e.g. by retention
+	opts = append(opts, kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+
+	filter, err := cfg.ParseFilter()
+	if err != nil {
+		return nil, err
+	}
 	cl, err := kgo.NewClient(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kafka client: %w", err)
 	}
 
+	tracker := newPartitionTracker(a)
+	if finished := tracker.finished(); len(finished) > 0 {
+		sdk.Logger(ctx).Info().Msg("some partitions were already read up to their end offset, they won't be read again")
+		cl.PauseFetchPartitions(finished)
+	}
+
 	return &FranzConsumer{
-		client:               cl,
-		acker:                newBatchAcker(cl, 1000),
-		iter:                 &kgo.FetchesRecordIter{}, // empty iterator is done
-		retryGroupJoinErrors: cfg.RetryGroupJoinErrors,
+		client:     cl,
+		queues:     newPartitionQueues(cfg.PartitionQueueSize),
+		buffer:     newBufferLimiter(cl, a.Topics(), cfg.MaxBufferedRecords, cfg.MaxBufferedBytes),
+		cluster:    cfg.ClusterName,
+		filter:     filter,
+		partitions: tracker,
 	}, nil
 }
 
+// resolvePartitionOffsets lists the start and end offsets of the assigned
+// partitions and resolves the start offsets of the assignment.
+func resolvePartitionOffsets(ctx context.Context, cfg Config, a PartitionAssignment) (PartitionAssignment, error) {
+	cl, err := kgo.NewClient(cfg.FranzClientOpts(sdk.Logger(ctx))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+	defer cl.Close()
+
+	adm := kadm.NewClient(cl)
+	starts, err := adm.ListStartOffsets(ctx, a.Topics()...)
+	if err == nil {
+		err = starts.Error()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list start offsets: %w", err)
+	}
+	ends, err := adm.ListEndOffsets(ctx, a.Topics()...)
+	if err == nil {
+		err = ends.Error()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list end offsets: %w", err)
+	}
+	return a.resolveOffsets(ctx, starts, ends)
+}
+
 func (c *FranzConsumer) Consume(ctx context.Context) (*Record, error) {
-	for c.iter.Done() {
-		fetches := c.client.PollFetches(ctx)
-		if err := fetches.Err(); err != nil {
-			var errGroupSession *kgo.ErrGroupSession
-			if c.retryGroupJoinErrors &&
-				(errors.As(err, &errGroupSession) || strings.Contains(err.Error(), "unable to join group session")) {
-				sdk.Logger(ctx).Warn().Err(err).Msgf("group session error, retrying")
-				return nil, sdk.ErrBackoffRetry
+	for {
+		c.dropRevoked(ctx)
+		switch {
+		case c.queues.Len() == 0:
+			// nothing buffered, wait for records
+			if err := c.poll(ctx, ctx); err != nil {
+				return nil, err
+			}
+			continue
+		case c.queues.RoundStart() && !c.polled:
+			// add records the client fetched in the meantime without waiting,
+			// so they take part in the next round
+			if err := c.poll(ctx, nil); err != nil { //nolint:staticcheck // a nil context doesn't wait for records
+				return nil, err
+			}
+			if c.queues.Len() == 0 {
+				continue // the queued records were dropped, their partitions were revoked
 			}
+		}
+
+		rec, drained := c.queues.Next()
+		c.buffer.Dequeued(rec)
+		c.polled = false
+		if drained && !c.finished(rec) {
+			c.client.ResumeFetchPartitions(map[string][]int32{rec.Topic: {rec.Partition}})
+		}
+		if c.partitions != nil {
+			if skip, finished := c.partitions.Skip(rec); skip {
+				if finished {
+					c.finishPartition(ctx, rec)
+				}
+				continue // past the end offset, fetched before the partition was paused
+			}
+		}
+		rec, err := c.chunks.Add(ctx, rec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reassemble chunked record: %w", err)
+		}
+		if rec == nil {
+			continue // chunk of a record that is not complete yet
+		}
+		if c.filter != nil {
+			ok, err := c.filter(rec)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				if err := c.skip(ctx, rec); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		if c.partitions != nil {
+			c.readPartition(ctx, rec)
+		} else {
+			commit := c.chunks.CommitRecord(rec)
+			c.acker.Read(rec, commit)
+			c.readGroup(rec, commit)
+		}
+		c.buffer.Read(rec)
+		if c.cluster != "" {
+			withCluster((*Record)(rec), c.cluster)
+		}
+		return (*Record)(rec), nil
+	}
+}
+
+// poll adds fetched records to the queues and pauses fetching partitions
+// with full queues. If pollCtx is nil, only records that were already fetched
+// are added.
+func (c *FranzConsumer) poll(ctx, pollCtx context.Context) error {
+	// resume fetching if the buffer was drained by records that were skipped
+	// instead of acknowledged, otherwise polling could wait forever
+	c.buffer.Update(ctx)
 
-			return nil, err
+	fetches := c.client.PollFetches(pollCtx)
+	if c.reconciler != nil {
+		if err := c.reconciler.Err(); err != nil {
+			return err // not a group session error, don't retry
 		}
-		if fetches.Empty() {
-			continue // no records, keep polling
+	}
+	c.polled = true
+	if err := fetches.Err(); err != nil {
+		var errGroupSession *kgo.ErrGroupSession
+		if c.retryGroupJoinErrors &&
+			(errors.As(err, &errGroupSession) || strings.Contains(err.Error(), "unable to join group session")) {
+			sdk.Logger(ctx).Warn().Err(err).Msgf("group session error, retrying")
+			return sdk.ErrBackoffRetry
+		}
+
+		return err
+	}
+	// the fetches can contain records of partitions that were revoked and
+	// assigned again in the meantime, drop the state of the revoked
+	// partitions first
+	c.dropRevoked(ctx)
+	if full := c.queues.Add(fetches); len(full) > 0 {
+		c.client.PauseFetchPartitions(full)
+	}
+	c.buffer.Fetched(fetches)
+	c.buffer.Update(ctx)
+	return nil
+}
+
+// onPartitionsRevoked is called by the client when partitions are revoked in
+// a rebalance. The offsets of acknowledged records are committed, so they
+// aren't read again by the next owner of the partitions.
+func (c *FranzConsumer) onPartitionsRevoked(ctx context.Context, _ *kgo.Client, revoked map[string][]int32) {
+	if err := c.acker.FlushRevoked(ctx, revoked); err != nil {
+		c.logger.Warn().Err(err).Msg("failed to commit offsets before partitions were revoked")
+	}
+	c.revoke(ctx, revoked)
+}
+
+// onPartitionsLost is called by the client when partitions are lost without
+// a rebalance, e.g. when the group session expired. Their offsets can't be
+// committed anymore.
+func (c *FranzConsumer) onPartitionsLost(ctx context.Context, _ *kgo.Client, lost map[string][]int32) {
+	c.revoke(ctx, lost)
+}
+
+// revoke marks the partitions as revoked. Their state is dropped by the
+// consume loop, the callbacks of the client run concurrently with it. The
+// unacknowledged reads are removed from the buffer right away, so fetching
+// is resumed even if the consume loop waits for records of a paused client.
+func (c *FranzConsumer) revoke(ctx context.Context, partitions map[string][]int32) {
+	c.logger.Info().Any("partitions", partitions).Msg("partitions revoked, dropping records that were not acknowledged")
+	tps := make(map[topicPartition]bool)
+	for topic, ps := range partitions {
+		for _, p := range ps {
+			tps[topicPartition{topic: topic, partition: p}] = true
 		}
-		c.iter = fetches.RecordIter()
-		c.acker.Records(fetches.Records()...)
-		break
 	}
-	return (*Record)(c.iter.Next()), nil
+	c.buffer.Revoked(ctx, tps)
+
+	c.revokedM.Lock()
+	defer c.revokedM.Unlock()
+	if c.revoked == nil {
+		c.revoked = make(map[topicPartition]bool)
+	}
+	for tp := range tps {
+		c.revoked[tp] = true
+	}
+}
+
+// dropRevoked drops the queued records, unacknowledged reads and incomplete
+// chunked records of revoked partitions. If the partitions are assigned
+// again, they are read from the committed offsets.
+func (c *FranzConsumer) dropRevoked(ctx context.Context) {
+	c.revokedM.Lock()
+	revoked := c.revoked
+	c.revoked = nil
+	c.revokedM.Unlock()
+	if len(revoked) == 0 {
+		return
+	}
+
+	records, full := c.queues.Drop(revoked)
+	for _, rec := range records {
+		c.buffer.Dequeued(rec)
+	}
+	if len(full) > 0 {
+		c.client.ResumeFetchPartitions(full)
+	}
+	// records read since the partitions were revoked
+	c.buffer.Revoked(ctx, revoked)
+	c.acker.Revoke(revoked)
+	c.chunks.Drop(revoked)
 }
 
-func (c *FranzConsumer) Ack(ctx context.Context) error {
-	return c.acker.Ack(ctx)
+// finished returns true if the partition of the record was read up to its end
+// offset, it stays paused.
+func (c *FranzConsumer) finished(rec *kgo.Record) bool {
+	return c.partitions != nil && c.partitions.Finished(rec.Topic, rec.Partition)
+}
+
+// skip handles a record skipped by the filter, its offset is committed as if
+// it was acknowledged.
+func (c *FranzConsumer) skip(ctx context.Context, rec *kgo.Record) error {
+	if c.partitions != nil {
+		// the offset is stored in the position of the next record
+		c.readPartition(ctx, rec)
+		return nil
+	}
+	commit := c.chunks.CommitRecord(rec)
+	c.trackOffset(commit)
+	return c.acker.Skip(ctx, rec, commit)
+}
+
+// readGroup tracks the offset of a record read from the consumer group and
+// attaches the offsets of all partitions to the record, so they are stored in
+// its position.
+func (c *FranzConsumer) readGroup(rec, commit *kgo.Record) {
+	c.trackOffset(commit)
+	withPartitionOffsets((*Record)(rec), c.offsets.clone())
+}
+
+// trackOffset stores the offset following the commit record as the offset of
+// the next record to read from the consumer group partition.
+func (c *FranzConsumer) trackOffset(commit *kgo.Record) {
+	if c.offsets == nil {
+		c.offsets = make(PartitionOffsets)
+	}
+	c.offsets.set(commit.Topic, commit.Partition, commit.Offset+1)
+}
+
+// readPartition tracks the offset of a record read from an assigned partition
+// and stops fetching the partition once it reaches its end offset.
+func (c *FranzConsumer) readPartition(ctx context.Context, rec *kgo.Record) {
+	if c.partitions.Read(rec, c.chunks.CommitRecord(rec)) {
+		c.finishPartition(ctx, rec)
+	}
+	withPartitionOffsets((*Record)(rec), c.partitions.Offsets())
+}
+
+// finishPartition stops fetching the partition of the record, which reached
+// its end offset.
+func (c *FranzConsumer) finishPartition(ctx context.Context, rec *kgo.Record) {
+	c.client.PauseFetchPartitions(map[string][]int32{rec.Topic: {rec.Partition}})
+	sdk.Logger(ctx).Info().
+		Str("topic", rec.Topic).
+		Int32("partition", rec.Partition).
+		Int64("offset", rec.Offset).
+		Msg("partition reached its end offset")
+	if c.partitions.Done() {
+		sdk.Logger(ctx).Info().Msg("all partitions with an end offset reached it")
+	}
+}
+
+func (c *FranzConsumer) Ack(ctx context.Context, pos Position) error {
+	c.buffer.Acked(ctx, pos)
+	if c.acker == nil {
+		return nil // offsets of assigned partitions are stored in positions
+	}
+	return c.acker.Ack(ctx, pos)
 }
 
 func (c *FranzConsumer) Close(ctx context.Context) error {
 	var multierr []error
 
-	if err := c.acker.Flush(ctx); err != nil {
-		multierr = append(multierr, err)
+	if c.acker != nil {
+		if err := c.acker.Flush(ctx); err != nil {
+			multierr = append(multierr, err)
+		}
 	}
 	c.client.Close()
 
 	return errors.Join(multierr...)
 }
 
-// batchAcker commits acks in batches.
+// batchAcker commits acks in batches. Acks are tracked by position, the
+// committed offset of a partition is the highest offset up to which all records
+// of the partition were acknowledged, so records are never committed before
+// they are processed, even if acks arrive out of order.
 type batchAcker struct {
 	client Client
 
-	batchSize     int
-	curBatchIndex int
+	batchSize int
+	// acked is the number of records acknowledged or skipped since the last
+	// flush.
+	acked int
 
-	records []*kgo.Record
+	partitions map[topicPartition]*partitionAcks
+	// revoked contains the number of reads of records of revoked partitions
+	// that were not acknowledged yet. Their acks are ignored, records read
+	// again after the partition is assigned again are acknowledged
+	// separately.
+	revoked map[recordOffset]int
 	m       sync.Mutex
+	// flushM serializes flushes, so offsets are committed in the order they
+	// were taken. It is held while committing, a.m is not.
+	flushM sync.Mutex
+}
+
+// partitionAcks contains the records of a partition that were read but not
+// committed yet.
+type partitionAcks struct {
+	// records contains the records in offset order, up to the first record
+	// that was not acknowledged yet.
+	records []ackRecord
+	// commit is the record that is committed in the next flush, nil if there
+	// is nothing new to commit.
+	commit *kgo.Record
+}
+
+type ackRecord struct {
+	// offset is the offset of the record that was read.
+	offset int64
+	// commit is the record that can be committed once the record and all
+	// records before it are acknowledged, see chunkAssembler.CommitRecord.
+	commit *kgo.Record
+	// acked is true if the record was acknowledged or skipped by the filter.
+	acked bool
 }
 
 func newBatchAcker(client Client, batchSize int) *batchAcker {
 	return &batchAcker{
-		client:        client,
-		batchSize:     batchSize,
-		curBatchIndex: 0,
-		records:       make([]*kgo.Record, 0, 10000), // prepare generous capacity
+		client:     client,
+		batchSize:  batchSize,
+		partitions: make(map[topicPartition]*partitionAcks),
+		revoked:    make(map[recordOffset]int),
 	}
 }
 
-func (a *batchAcker) Records(recs ...*kgo.Record) {
+// Read adds a record that was read and waits for its ack. Commit is the
+// record that is committed once it is acknowledged.
+func (a *batchAcker) Read(rec, commit *kgo.Record) {
 	a.m.Lock()
-	a.records = append(a.records, recs...)
+	defer a.m.Unlock()
+	pa := a.partition(rec)
+	pa.records = append(pa.records, ackRecord{offset: rec.Offset, commit: commit})
+}
+
+// Skip adds a record that was skipped by the filter. It is committed once all
+// records read before it are acknowledged.
+func (a *batchAcker) Skip(ctx context.Context, rec, commit *kgo.Record) error {
+	a.m.Lock()
+	pa := a.partition(rec)
+	pa.records = append(pa.records, ackRecord{offset: rec.Offset, commit: commit, acked: true})
+	pa.advance()
+	a.acked++
+	full := a.acked >= a.batchSize
 	a.m.Unlock()
+
+	if !full {
+		return nil
+	}
+	return a.Flush(ctx)
 }
 
-func (a *batchAcker) Ack(ctx context.Context) error {
-	a.curBatchIndex++
-	if a.curBatchIndex < a.batchSize {
+// Ack acknowledges the record at the position.
+func (a *batchAcker) Ack(ctx context.Context, pos Position) error {
+	a.m.Lock()
+	tp := topicPartition{topic: pos.Topic, partition: pos.Partition}
+	if key := (recordOffset{tp, pos.Offset}); a.revoked[key] > 0 {
+		// The record was read before the partition was revoked and possibly
+		// again after it was assigned again. The acks of both reads can't be
+		// told apart, the first one is ignored, so the record is committed
+		// only after it was acknowledged as often as it was read.
+		a.revoked[key]--
+		if a.revoked[key] == 0 {
+			delete(a.revoked, key)
+		}
+		a.m.Unlock()
+		return nil
+	}
+	pa, ok := a.partitions[tp]
+	if !ok {
+		a.m.Unlock()
+		return fmt.Errorf("received an ack for %s/%d offset %d, which was not read", pos.Topic, pos.Partition, pos.Offset)
+	}
+	i, ok := slices.BinarySearchFunc(pa.records, pos.Offset, func(r ackRecord, offset int64) int {
+		return cmp.Compare(r.offset, offset)
+	})
+	if !ok || pa.records[i].acked {
+		a.m.Unlock()
+		return fmt.Errorf("received an ack for %s/%d offset %d, which was not read or was already acknowledged", pos.Topic, pos.Partition, pos.Offset)
+	}
+	pa.records[i].acked = true
+	pa.advance()
+	a.acked++
+	full := a.acked >= a.batchSize
+	a.m.Unlock()
+
+	if !full {
 		return nil
 	}
 	// TODO flush on timeout
 	return a.Flush(ctx)
 }
 
+// Revoke drops the records of the partitions, their offsets are not
+// committed anymore. Partitions assigned again are read from the committed
+// offsets, so records can be read again.
+func (a *batchAcker) Revoke(partitions map[topicPartition]bool) {
+	a.m.Lock()
+	defer a.m.Unlock()
+	for tp := range partitions {
+		pa, ok := a.partitions[tp]
+		if !ok {
+			continue
+		}
+		for _, r := range pa.records {
+			if !r.acked {
+				a.revoked[recordOffset{tp, r.offset}]++
+			}
+		}
+		delete(a.partitions, tp)
+	}
+}
+
+// partition returns the acks of the record's partition. The caller needs to
+// hold the lock.
+func (a *batchAcker) partition(rec *kgo.Record) *partitionAcks {
+	tp := topicPartition{topic: rec.Topic, partition: rec.Partition}
+	pa, ok := a.partitions[tp]
+	if !ok {
+		pa = &partitionAcks{}
+		a.partitions[tp] = pa
+	}
+	return pa
+}
+
+// advance removes the acknowledged records at the start of the partition and
+// marks the last of them for commit.
+func (pa *partitionAcks) advance() {
+	i := 0
+	for i < len(pa.records) && pa.records[i].acked {
+		if commit := pa.records[i].commit; pa.commit == nil || commit.Offset > pa.commit.Offset {
+			pa.commit = commit
+		}
+		i++
+	}
+	pa.records = pa.records[i:]
+}
+
+// Flush commits the offsets of acknowledged records. The offsets are taken
+// under the lock and committed without holding it, so acks are not blocked by
+// the commit.
 func (a *batchAcker) Flush(ctx context.Context) error {
-	if a.curBatchIndex == 0 {
+	a.flushM.Lock()
+	defer a.flushM.Unlock()
+
+	a.m.Lock()
+	var commits []*kgo.Record
+	for _, pa := range a.partitions {
+		if pa.commit != nil {
+			commits = append(commits, pa.commit)
+			pa.commit = nil
+		}
+	}
+	a.acked = 0
+	a.m.Unlock()
+	if len(commits) == 0 {
 		return nil // nothing to flush
 	}
 
+	if err := a.client.CommitRecords(ctx, commits...); err != nil {
+		a.restore(commits)
+		return fmt.Errorf("failed to commit records: %w", err)
+	}
+	return nil
+}
+
+// restore marks records that failed to be committed for commit again, unless
+// a later record of the partition was acknowledged in the meantime or the
+// partition was revoked.
+func (a *batchAcker) restore(commits []*kgo.Record) {
 	a.m.Lock()
 	defer a.m.Unlock()
+	for _, commit := range commits {
+		pa, ok := a.partitions[topicPartition{topic: commit.Topic, partition: commit.Partition}]
+		if ok && pa.commit == nil {
+			pa.commit = commit
+		}
+	}
+}
 
-	err := a.client.CommitRecords(ctx, a.records[:a.curBatchIndex]...)
-	if err != nil {
-		return fmt.Errorf("failed to commit records: %w", err)
+// FlushRevoked commits the offsets of acknowledged records of partitions that
+// are being revoked. It is called by the client before the partitions are
+// revoked, so it uses CommitOffsetsSync, whose commit can't be canceled, and
+// doesn't wait for a flush in progress, which could be waiting for the
+// rebalance.
+func (a *batchAcker) FlushRevoked(ctx context.Context, revoked map[string][]int32) error {
+	a.m.Lock()
+	offsets := make(map[string]map[int32]kgo.EpochOffset)
+	for topic, partitions := range revoked {
+		for _, partition := range partitions {
+			pa, ok := a.partitions[topicPartition{topic: topic, partition: partition}]
+			if !ok || pa.commit == nil {
+				continue
+			}
+			if offsets[topic] == nil {
+				offsets[topic] = make(map[int32]kgo.EpochOffset)
+			}
+			offsets[topic][partition] = kgo.EpochOffset{
+				Epoch:  pa.commit.LeaderEpoch,
+				Offset: pa.commit.Offset + 1,
+			}
+			pa.commit = nil
+		}
+	}
+	a.m.Unlock()
+	if len(offsets) == 0 {
+		return nil // nothing to flush
 	}
 
-	a.records = a.records[a.curBatchIndex:]
-	a.curBatchIndex = 0
+	var err error
+	a.client.CommitOffsetsSync(ctx, offsets, func(_ *kgo.Client, _ *kmsg.OffsetCommitRequest, resp *kmsg.OffsetCommitResponse, commitErr error) {
+		if commitErr != nil {
+			err = commitErr
+			return
+		}
+		for _, t := range resp.Topics {
+			for _, p := range t.Partitions {
+				if perr := kerr.ErrorForCode(p.ErrorCode); perr != nil {
+					err = perr
+					return
+				}
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit offsets: %w", err)
+	}
 	return nil
 }
@@ -35,7 +35,7 @@ func TestFranzConsumer_Consume_FromBeginning(t *testing.T) {
 	test.CreateTopics(t, cfg.Servers, cfg.Topics)
 	test.Produce(t, cfg.Servers, cfg.Topics[0], records)
 
-	c, err := NewFranzConsumer(ctx, cfg)
+	c, err := NewFranzConsumer(ctx, cfg, nil)
 	is.NoErr(err)
 	defer func() {
 		err := c.Close(ctx)
@@ -63,7 +63,7 @@ func TestFranzConsumer_Consume_LastOffset(t *testing.T) {
 	test.CreateTopics(t, cfg.Servers, cfg.Topics)
 	test.Produce(t, cfg.Servers, cfg.Topics[0], records)
 
-	c, err := NewFranzConsumer(ctx, cfg)
+	c, err := NewFranzConsumer(ctx, cfg, nil)
 	is.NoErr(err)
 	defer func() {
 		err := c.Close(ctx)
@@ -101,7 +101,7 @@ func TestFranzConsumer_Consume_MultipleTopics(t *testing.T) {
 	test.Produce(t, cfg.Servers, cfg.Topics[0], records[0:3])
 	test.Produce(t, cfg.Servers, cfg.Topics[1], records[3:])
 
-	c, err := NewFranzConsumer(ctx, cfg)
+	c, err := NewFranzConsumer(ctx, cfg, nil)
 	is.NoErr(err)
 	defer func() {
 		err := c.Close(ctx)
@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 
 	kgo "github.com/twmb/franz-go/pkg/kgo"
+	kmsg "github.com/twmb/franz-go/pkg/kmsg"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -77,6 +78,42 @@ func (c *MockClientCloseCall) DoAndReturn(f func()) *MockClientCloseCall {
 	return c
 }
 
+// CommitOffsetsSync mocks base method.
+func (m *MockClient) CommitOffsetsSync(ctx context.Context, uncommitted map[string]map[int32]kgo.EpochOffset, onDone func(*kgo.Client, *kmsg.OffsetCommitRequest, *kmsg.OffsetCommitResponse, error)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CommitOffsetsSync", ctx, uncommitted, onDone)
+}
+
+// CommitOffsetsSync indicates an expected call of CommitOffsetsSync.
+func (mr *MockClientMockRecorder) CommitOffsetsSync(ctx, uncommitted, onDone any) *MockClientCommitOffsetsSyncCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitOffsetsSync", reflect.TypeOf((*MockClient)(nil).CommitOffsetsSync), ctx, uncommitted, onDone)
+	return &MockClientCommitOffsetsSyncCall{Call: call}
+}
+
+// MockClientCommitOffsetsSyncCall wrap *gomock.Call
+type MockClientCommitOffsetsSyncCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClientCommitOffsetsSyncCall) Return() *MockClientCommitOffsetsSyncCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClientCommitOffsetsSyncCall) Do(f func(context.Context, map[string]map[int32]kgo.EpochOffset, func(*kgo.Client, *kmsg.OffsetCommitRequest, *kmsg.OffsetCommitResponse, error))) *MockClientCommitOffsetsSyncCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClientCommitOffsetsSyncCall) DoAndReturn(f func(context.Context, map[string]map[int32]kgo.EpochOffset, func(*kgo.Client, *kmsg.OffsetCommitRequest, *kmsg.OffsetCommitResponse, error))) *MockClientCommitOffsetsSyncCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // CommitRecords mocks base method.
 func (m *MockClient) CommitRecords(ctx context.Context, rs ...*kgo.Record) error {
 	m.ctrl.T.Helper()
@@ -158,6 +195,86 @@ func (c *MockClientOptValueCall) DoAndReturn(f func(any) any) *MockClientOptValu
 	return c
 }
 
+// PauseFetchPartitions mocks base method.
+func (m *MockClient) PauseFetchPartitions(arg0 map[string][]int32) map[string][]int32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseFetchPartitions", arg0)
+	ret0, _ := ret[0].(map[string][]int32)
+	return ret0
+}
+
+// PauseFetchPartitions indicates an expected call of PauseFetchPartitions.
+func (mr *MockClientMockRecorder) PauseFetchPartitions(arg0 any) *MockClientPauseFetchPartitionsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseFetchPartitions", reflect.TypeOf((*MockClient)(nil).PauseFetchPartitions), arg0)
+	return &MockClientPauseFetchPartitionsCall{Call: call}
+}
+
+// MockClientPauseFetchPartitionsCall wrap *gomock.Call
+type MockClientPauseFetchPartitionsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClientPauseFetchPartitionsCall) Return(arg0 map[string][]int32) *MockClientPauseFetchPartitionsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClientPauseFetchPartitionsCall) Do(f func(map[string][]int32) map[string][]int32) *MockClientPauseFetchPartitionsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClientPauseFetchPartitionsCall) DoAndReturn(f func(map[string][]int32) map[string][]int32) *MockClientPauseFetchPartitionsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PauseFetchTopics mocks base method.
+func (m *MockClient) PauseFetchTopics(arg0 ...string) []string {
+	m.ctrl.T.Helper()
+	varargs := []any{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PauseFetchTopics", varargs...)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// PauseFetchTopics indicates an expected call of PauseFetchTopics.
+func (mr *MockClientMockRecorder) PauseFetchTopics(arg0 ...any) *MockClientPauseFetchTopicsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseFetchTopics", reflect.TypeOf((*MockClient)(nil).PauseFetchTopics), arg0...)
+	return &MockClientPauseFetchTopicsCall{Call: call}
+}
+
+// MockClientPauseFetchTopicsCall wrap *gomock.Call
+type MockClientPauseFetchTopicsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClientPauseFetchTopicsCall) Return(arg0 []string) *MockClientPauseFetchTopicsCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClientPauseFetchTopicsCall) Do(f func(...string) []string) *MockClientPauseFetchTopicsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClientPauseFetchTopicsCall) DoAndReturn(f func(...string) []string) *MockClientPauseFetchTopicsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // PollFetches mocks base method.
 func (m *MockClient) PollFetches(ctx context.Context) kgo.Fetches {
 	m.ctrl.T.Helper()
@@ -195,3 +312,79 @@ func (c *MockClientPollFetchesCall) DoAndReturn(f func(context.Context) kgo.Fetc
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// ResumeFetchPartitions mocks base method.
+func (m *MockClient) ResumeFetchPartitions(arg0 map[string][]int32) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResumeFetchPartitions", arg0)
+}
+
+// ResumeFetchPartitions indicates an expected call of ResumeFetchPartitions.
+func (mr *MockClientMockRecorder) ResumeFetchPartitions(arg0 any) *MockClientResumeFetchPartitionsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeFetchPartitions", reflect.TypeOf((*MockClient)(nil).ResumeFetchPartitions), arg0)
+	return &MockClientResumeFetchPartitionsCall{Call: call}
+}
+
+// MockClientResumeFetchPartitionsCall wrap *gomock.Call
+type MockClientResumeFetchPartitionsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClientResumeFetchPartitionsCall) Return() *MockClientResumeFetchPartitionsCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClientResumeFetchPartitionsCall) Do(f func(map[string][]int32)) *MockClientResumeFetchPartitionsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClientResumeFetchPartitionsCall) DoAndReturn(f func(map[string][]int32)) *MockClientResumeFetchPartitionsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// ResumeFetchTopics mocks base method.
+func (m *MockClient) ResumeFetchTopics(arg0 ...string) {
+	m.ctrl.T.Helper()
+	varargs := []any{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "ResumeFetchTopics", varargs...)
+}
+
+// ResumeFetchTopics indicates an expected call of ResumeFetchTopics.
+func (mr *MockClientMockRecorder) ResumeFetchTopics(arg0 ...any) *MockClientResumeFetchTopicsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeFetchTopics", reflect.TypeOf((*MockClient)(nil).ResumeFetchTopics), arg0...)
+	return &MockClientResumeFetchTopicsCall{Call: call}
+}
+
+// MockClientResumeFetchTopicsCall wrap *gomock.Call
+type MockClientResumeFetchTopicsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClientResumeFetchTopicsCall) Return() *MockClientResumeFetchTopicsCall {
+	c.Call = c.Call.Return()
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClientResumeFetchTopicsCall) Do(f func(...string)) *MockClientResumeFetchTopicsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClientResumeFetchTopicsCall) DoAndReturn(f func(...string)) *MockClientResumeFetchTopicsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
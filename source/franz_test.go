@@ -29,6 +29,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/matryer/is"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 	"github.com/twmb/franz-go/pkg/sasl"
 	"go.uber.org/mock/gomock"
 )
@@ -58,7 +59,7 @@ func TestFranzConsumer_Opts(t *testing.T) {
 		GroupID: "test-group-id",
 	}
 
-	c, err := NewFranzConsumer(context.Background(), cfg)
+	c, err := NewFranzConsumer(context.Background(), cfg, nil)
 	is.NoErr(err)
 
 	is.Equal(c.client.OptValue(kgo.ConsumeTopics), map[string]*regexp.Regexp{cfg.Topics[0]: nil})
@@ -100,7 +101,8 @@ func Test_FranzConsumer_Consume_Success(t *testing.T) {
 	c := &FranzConsumer{
 		client:               cl,
 		acker:                newBatchAcker(cl, 1000),
-		iter:                 &kgo.FetchesRecordIter{},
+		queues:               newPartitionQueues(0),
+		buffer:               newBufferLimiter(cl, []string{"test"}, 0, 0),
 		retryGroupJoinErrors: true,
 	}
 
@@ -131,10 +133,199 @@ func Test_FranzConsumer_Consume_RetryGroupJoinError(t *testing.T) {
 	c := &FranzConsumer{
 		client:               cl,
 		acker:                newBatchAcker(cl, 1000),
-		iter:                 &kgo.FetchesRecordIter{},
+		queues:               newPartitionQueues(0),
+		buffer:               newBufferLimiter(cl, []string{"test"}, 0, 0),
 		retryGroupJoinErrors: true,
 	}
 
 	_, err := c.Consume(ctx)
 	is.True(errors.Is(err, sdk.ErrBackoffRetry))
 }
+
+func TestBatchAcker_Ack_OutOfOrder(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	records := []*kgo.Record{
+		{Topic: "test", Partition: 0, Offset: 0},
+		{Topic: "test", Partition: 0, Offset: 1},
+		{Topic: "test", Partition: 0, Offset: 2},
+		{Topic: "test", Partition: 1, Offset: 0},
+	}
+	cl := NewMockClient(gomock.NewController(t))
+	a := newBatchAcker(cl, 1000)
+	for _, r := range records {
+		a.Read(r, r)
+	}
+
+	// offset 1 is acked before offset 0, offset 2 is not acked (e.g. nacked)
+	is.NoErr(a.Ack(ctx, Position{Topic: "test", Partition: 0, Offset: 1}))
+	is.NoErr(a.Flush(ctx)) // nothing to commit, offset 0 was not acked
+
+	is.NoErr(a.Ack(ctx, Position{Topic: "test", Partition: 1, Offset: 0}))
+	is.NoErr(a.Ack(ctx, Position{Topic: "test", Partition: 0, Offset: 0}))
+	cl.EXPECT().
+		CommitRecords(gomock.Any(), gomock.InAnyOrder([]*kgo.Record{records[1], records[3]})).
+		Return(nil)
+	is.NoErr(a.Flush(ctx))
+
+	// acks of records that were not read or were already acked fail
+	is.True(a.Ack(ctx, Position{Topic: "test", Partition: 0, Offset: 1}) != nil)
+	is.True(a.Ack(ctx, Position{Topic: "test", Partition: 0, Offset: 5}) != nil)
+	is.True(a.Ack(ctx, Position{Topic: "test", Partition: 2, Offset: 0}) != nil)
+}
+
+func TestBatchAcker_Flush(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	records := []*kgo.Record{
+		{Topic: "test", Partition: 0, Offset: 0},
+		{Topic: "test", Partition: 0, Offset: 1},
+	}
+	cl := NewMockClient(gomock.NewController(t))
+	a := newBatchAcker(cl, 1000)
+	for _, r := range records {
+		a.Read(r, r)
+	}
+	is.NoErr(a.Ack(ctx, Position{Topic: "test", Partition: 0, Offset: 0}))
+
+	// acks are not blocked by a commit in progress, a failed commit is
+	// retried in the next flush
+	cl.EXPECT().
+		CommitRecords(gomock.Any(), records[0]).
+		DoAndReturn(func(context.Context, ...*kgo.Record) error {
+			is.NoErr(a.Ack(ctx, Position{Topic: "test", Partition: 0, Offset: 1}))
+			return errors.New("commit failed")
+		})
+	is.True(a.Flush(ctx) != nil)
+
+	cl.EXPECT().CommitRecords(gomock.Any(), records[1]).Return(nil)
+	is.NoErr(a.Flush(ctx))
+	is.NoErr(a.Flush(ctx)) // nothing to commit
+}
+
+func TestFranzConsumer_Consume_PartitionAssignedAgain(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	fetch := func(offsets ...int64) kgo.Fetches {
+		records := make([]*kgo.Record, len(offsets))
+		for i, offset := range offsets {
+			records[i] = &kgo.Record{Topic: "test", Partition: 0, Offset: offset, Value: []byte("value")}
+		}
+		return kgo.Fetches{{Topics: []kgo.FetchTopic{{
+			Topic:      "test",
+			Partitions: []kgo.FetchPartition{{Partition: 0, Records: records}},
+		}}}}
+	}
+	consume := func(c *FranzConsumer, wantOffset int64) {
+		is.Helper()
+		rec, err := c.Consume(ctx)
+		is.NoErr(err)
+		is.Equal(rec.Offset, wantOffset)
+	}
+	ack := func(c *FranzConsumer, offset int64) {
+		is.Helper()
+		is.NoErr(c.Ack(ctx, Position{Topic: "test", Partition: 0, Offset: offset}))
+	}
+
+	// the partition is read up to offset 2, after it is assigned again it is
+	// read from the committed offset 1
+	fetches := []kgo.Fetches{fetch(0, 1, 2)}
+	cl := NewMockClient(gomock.NewController(t))
+	cl.EXPECT().PollFetches(gomock.Any()).DoAndReturn(func(context.Context) kgo.Fetches {
+		if len(fetches) == 0 {
+			return nil
+		}
+		f := fetches[0]
+		fetches = fetches[1:]
+		return f
+	}).AnyTimes()
+	c := &FranzConsumer{
+		client: cl,
+		acker:  newBatchAcker(cl, 1000),
+		queues: newPartitionQueues(0),
+		buffer: newBufferLimiter(cl, []string{"test"}, 0, 0),
+		logger: sdk.Logger(ctx),
+	}
+
+	consume(c, 0)
+	consume(c, 1)
+	ack(c, 0)
+
+	// offset 0 is committed before the partition is revoked, offset 1 was
+	// read but not acknowledged and offset 2 is still queued
+	cl.EXPECT().
+		CommitOffsetsSync(gomock.Any(), map[string]map[int32]kgo.EpochOffset{"test": {0: {Offset: 1}}}, gomock.Any()).
+		Do(func(_ context.Context, _ map[string]map[int32]kgo.EpochOffset, onDone func(*kgo.Client, *kmsg.OffsetCommitRequest, *kmsg.OffsetCommitResponse, error)) {
+			onDone(nil, nil, &kmsg.OffsetCommitResponse{}, nil)
+		})
+	c.onPartitionsRevoked(ctx, nil, map[string][]int32{"test": {0}})
+	fetches = append(fetches, fetch(1, 2))
+
+	consume(c, 1)
+	consume(c, 2)
+
+	// the ack of offset 1 read before the partition was revoked is ignored
+	ack(c, 1)
+	ack(c, 1)
+	ack(c, 2)
+	is.True(c.Ack(ctx, Position{Topic: "test", Partition: 0, Offset: 2}) != nil) // already acked
+
+	cl.EXPECT().CommitRecords(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, rs ...*kgo.Record) error {
+			is.Equal(len(rs), 1)
+			is.Equal(rs[0].Offset, int64(2))
+			return nil
+		})
+	is.NoErr(c.acker.Flush(ctx))
+
+	// nothing is buffered anymore
+	is.Equal(c.buffer.records, 0)
+	is.Equal(c.buffer.bytes, 0)
+	is.Equal(c.queues.Len(), 0)
+}
+
+func TestFranzConsumer_Consume_RevokedDuringPoll(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	fetch := func(offsets ...int64) kgo.Fetches {
+		records := make([]*kgo.Record, len(offsets))
+		for i, offset := range offsets {
+			records[i] = &kgo.Record{Topic: "test", Partition: 0, Offset: offset, Value: []byte("value")}
+		}
+		return kgo.Fetches{{Topics: []kgo.FetchTopic{{
+			Topic:      "test",
+			Partitions: []kgo.FetchPartition{{Partition: 0, Records: records}},
+		}}}}
+	}
+
+	cl := NewMockClient(gomock.NewController(t))
+	c := &FranzConsumer{
+		client: cl,
+		acker:  newBatchAcker(cl, 1000),
+		queues: newPartitionQueues(0),
+		buffer: newBufferLimiter(cl, []string{"test"}, 0, 0),
+		logger: sdk.Logger(ctx),
+	}
+
+	cl.EXPECT().PollFetches(ctx).Return(fetch(0, 1))
+	rec, err := c.Consume(ctx)
+	is.NoErr(err)
+	is.Equal(rec.Offset, int64(0))
+
+	// the partition is revoked while polling without waiting, its queued
+	// record is dropped and the partition is read again once it is assigned
+	// again
+	cl.EXPECT().PollFetches(nil).DoAndReturn(func(context.Context) kgo.Fetches {
+		c.revoke(ctx, map[string][]int32{"test": {0}})
+		return nil
+	})
+	cl.EXPECT().PollFetches(ctx).Return(fetch(0, 1))
+	rec, err = c.Consume(ctx)
+	is.NoErr(err)
+	is.Equal(rec.Offset, int64(0))
+	is.Equal(c.queues.Len(), 1)
+}
@@ -0,0 +1,120 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"path"
+	"strconv"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Encodings of header values, see Config.HeadersEncoding.
+const (
+	HeaderEncodingString = "string"
+	HeaderEncodingBase64 = "base64"
+	HeaderEncodingHex    = "hex"
+)
+
+// Strategies for headers with repeated keys, see Config.HeadersRepeated.
+const (
+	HeaderRepeatedLast    = "last"
+	HeaderRepeatedFirst   = "first"
+	HeaderRepeatedJoin    = "join"
+	HeaderRepeatedIndexed = "indexed"
+)
+
+// HeaderDecoder converts record headers into metadata fields. The zero value
+// includes all headers as strings and keeps the last value of repeated keys.
+type HeaderDecoder struct {
+	encoding  string
+	include   []string
+	exclude   []string
+	repeated  string
+	separator string
+}
+
+func NewHeaderDecoder(cfg Config) HeaderDecoder {
+	return HeaderDecoder{
+		encoding:  cfg.HeadersEncoding,
+		include:   cfg.HeadersInclude,
+		exclude:   cfg.HeadersExclude,
+		repeated:  cfg.HeadersRepeated,
+		separator: cfg.HeadersJoinSeparator,
+	}
+}
+
+// Decode stores the included headers in the metadata, the keys of the
+// metadata fields are the header keys with the prefix.
+func (d HeaderDecoder) Decode(headers []kgo.RecordHeader, metadata opencdc.Metadata, prefix string) {
+	counts := make(map[string]int, len(headers))
+	for _, h := range headers {
+		if !d.includes(h.Key) {
+			continue
+		}
+		key := prefix + h.Key
+		value := d.encode(h.Value)
+
+		counts[h.Key]++
+		n := counts[h.Key]
+		switch {
+		case n == 1:
+			metadata[key] = value
+		case d.repeated == HeaderRepeatedFirst:
+			// keep the first value
+		case d.repeated == HeaderRepeatedJoin:
+			metadata[key] += d.separator + value
+		case d.repeated == HeaderRepeatedIndexed:
+			metadata[key+"."+strconv.Itoa(n-1)] = value
+		default: // HeaderRepeatedLast
+			metadata[key] = value
+		}
+	}
+}
+
+// includes returns true if the key matches an include pattern (or no include
+// patterns are configured) and doesn't match any exclude pattern.
+func (d HeaderDecoder) includes(key string) bool {
+	included := len(d.include) == 0
+	for _, p := range d.include {
+		if ok, _ := path.Match(p, key); ok {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, p := range d.exclude {
+		if ok, _ := path.Match(p, key); ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (d HeaderDecoder) encode(v []byte) string {
+	switch d.encoding {
+	case HeaderEncodingBase64:
+		return base64.StdEncoding.EncodeToString(v)
+	case HeaderEncodingHex:
+		return hex.EncodeToString(v)
+	default: // HeaderEncodingString
+		return string(v)
+	}
+}
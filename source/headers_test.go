@@ -0,0 +1,85 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestHeaderDecoder_Decode(t *testing.T) {
+	headers := []kgo.RecordHeader{
+		{Key: "trace-id", Value: []byte{0x01, 0xff}},
+		{Key: "tag", Value: []byte("a")},
+		{Key: "tag", Value: []byte("b")},
+		{Key: "tag", Value: []byte("c")},
+		{Key: "internal", Value: []byte("x")},
+	}
+
+	testCases := []struct {
+		name string
+		cfg  Config
+		want opencdc.Metadata
+	}{{
+		name: "zero value",
+		cfg:  Config{},
+		want: opencdc.Metadata{"h.trace-id": "\x01\xff", "h.tag": "c", "h.internal": "x"},
+	}, {
+		name: "base64",
+		cfg:  Config{HeadersEncoding: HeaderEncodingBase64, HeadersInclude: []string{"trace-*"}},
+		want: opencdc.Metadata{"h.trace-id": "Af8="},
+	}, {
+		name: "hex",
+		cfg:  Config{HeadersEncoding: HeaderEncodingHex, HeadersExclude: []string{"tag", "internal"}},
+		want: opencdc.Metadata{"h.trace-id": "01ff"},
+	}, {
+		name: "include and exclude",
+		cfg:  Config{HeadersInclude: []string{"t*"}, HeadersExclude: []string{"trace-*"}},
+		want: opencdc.Metadata{"h.tag": "c"},
+	}, {
+		name: "first",
+		cfg:  Config{HeadersRepeated: HeaderRepeatedFirst, HeadersInclude: []string{"tag"}},
+		want: opencdc.Metadata{"h.tag": "a"},
+	}, {
+		name: "join",
+		cfg:  Config{HeadersRepeated: HeaderRepeatedJoin, HeadersJoinSeparator: ";", HeadersInclude: []string{"tag"}},
+		want: opencdc.Metadata{"h.tag": "a;b;c"},
+	}, {
+		name: "indexed",
+		cfg:  Config{HeadersRepeated: HeaderRepeatedIndexed, HeadersInclude: []string{"tag"}},
+		want: opencdc.Metadata{"h.tag": "a", "h.tag.1": "b", "h.tag.2": "c"},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			got := opencdc.Metadata{}
+			NewHeaderDecoder(tc.cfg).Decode(headers, got, "h.")
+			is.Equal(got, tc.want)
+		})
+	}
+}
+
+func TestConfig_ValidateHeaderPatterns(t *testing.T) {
+	is := is.New(t)
+	cfg := Config{Topics: []string{"topic1"}, HeadersInclude: []string{"trace-*"}, HeadersExclude: []string{"[a-"}}
+	err := cfg.Validate(context.Background())
+	is.True(err != nil)
+	is.Equal(err.Error(), `invalid header pattern "[a-": syntax error in pattern`)
+}
@@ -0,0 +1,180 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+)
+
+type (
+	clusterKey        struct{}
+	clusterOffsetsKey struct{}
+)
+
+// withCluster stores the name of the cluster the record was read from in the
+// record context. The context of consumed records is unused by franz-go.
+func withCluster(rec *Record, cluster string) {
+	ctx := rec.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rec.Context = context.WithValue(ctx, clusterKey{}, cluster)
+}
+
+// RecordCluster returns the name of the cluster the record was read from, or
+// an empty string if the cluster has no name.
+func RecordCluster(rec *Record) string {
+	if rec.Context == nil {
+		return ""
+	}
+	cluster, _ := rec.Context.Value(clusterKey{}).(string)
+	return cluster
+}
+
+// RecordClusterOffsets returns the offsets of the next records to read from
+// the consumer group of each cluster at the time the record was consumed,
+// keyed by cluster name. It returns nil if the record wasn't consumed by a
+// MultiClusterConsumer.
+func RecordClusterOffsets(rec *Record) map[string]PartitionOffsets {
+	if rec.Context == nil {
+		return nil
+	}
+	offsets, _ := rec.Context.Value(clusterOffsetsKey{}).(map[string]PartitionOffsets)
+	return offsets
+}
+
+func withClusterOffsets(rec *Record, offsets map[string]PartitionOffsets) {
+	ctx := rec.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rec.Context = context.WithValue(ctx, clusterOffsetsKey{}, offsets)
+}
+
+// MultiClusterConsumer consumes records from multiple clusters, each cluster
+// has its own consumer that commits offsets to the cluster independently.
+// Records are returned in the order they are consumed across all clusters.
+// The offsets of all clusters are attached to each record (see
+// RecordClusterOffsets).
+type MultiClusterConsumer struct {
+	consumers map[string]Consumer
+	records   chan clusterRecord
+	// offsets contains the offsets of the next records to read from each
+	// cluster, keyed by cluster name. It is only accessed in Consume.
+	offsets map[string]PartitionOffsets
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type clusterRecord struct {
+	cluster string
+	rec     *Record
+	err     error
+}
+
+var _ Consumer = (*MultiClusterConsumer)(nil)
+
+// NewMultiClusterConsumer starts consuming from the consumers, keyed by the
+// cluster name. Offsets are the offsets stored in the position the source is
+// resumed from, keyed by cluster name, they are attached to records until a
+// record is consumed from the cluster.
+func NewMultiClusterConsumer(ctx context.Context, consumers map[string]Consumer, offsets map[string]PartitionOffsets) *MultiClusterConsumer {
+	// consumers outlive the context of the caller, they are stopped in Close
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	c := &MultiClusterConsumer{
+		consumers: consumers,
+		records:   make(chan clusterRecord),
+		offsets:   make(map[string]PartitionOffsets, len(consumers)),
+		cancel:    cancel,
+	}
+	for name := range consumers {
+		if offsets[name] != nil {
+			c.offsets[name] = offsets[name]
+		}
+	}
+	for name, consumer := range consumers {
+		c.wg.Add(1)
+		go c.consume(ctx, name, consumer)
+	}
+	return c
+}
+
+func (c *MultiClusterConsumer) consume(ctx context.Context, cluster string, consumer Consumer) {
+	defer c.wg.Done()
+	for {
+		rec, err := consumer.Consume(ctx)
+		if ctx.Err() != nil {
+			return // closing
+		}
+		select {
+		case c.records <- clusterRecord{cluster: cluster, rec: rec, err: err}:
+		case <-ctx.Done():
+			return
+		}
+		if err != nil && !errors.Is(err, sdk.ErrBackoffRetry) {
+			return // the error stops the connector
+		}
+	}
+}
+
+func (c *MultiClusterConsumer) Consume(ctx context.Context) (*Record, error) {
+	select {
+	case r := <-c.records:
+		if r.err != nil {
+			return nil, fmt.Errorf("cluster %q: %w", r.cluster, r.err)
+		}
+		if offsets := RecordPartitionOffsets(r.rec); offsets != nil {
+			c.offsets[r.cluster] = offsets
+		}
+		// the offsets of each cluster are cloned by its consumer for every
+		// record, so only the outer map needs to be copied
+		withClusterOffsets(r.rec, maps.Clone(c.offsets))
+		return r.rec, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Ack forwards the ack to the consumer of the cluster in the position.
+func (c *MultiClusterConsumer) Ack(ctx context.Context, pos Position) error {
+	consumer, ok := c.consumers[pos.Cluster]
+	if !ok {
+		return fmt.Errorf("received an ack for unknown cluster %q", pos.Cluster)
+	}
+	if err := consumer.Ack(ctx, pos); err != nil {
+		return fmt.Errorf("cluster %q: %w", pos.Cluster, err)
+	}
+	return nil
+}
+
+func (c *MultiClusterConsumer) Close(ctx context.Context) error {
+	c.cancel()
+	c.wg.Wait()
+
+	var multierr []error
+	for name, consumer := range c.consumers {
+		if err := consumer.Close(ctx); err != nil {
+			multierr = append(multierr, fmt.Errorf("cluster %q: %w", name, err))
+		}
+	}
+	return errors.Join(multierr...)
+}
@@ -0,0 +1,151 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRecordCluster(t *testing.T) {
+	is := is.New(t)
+
+	rec := &Record{}
+	is.Equal(RecordCluster(rec), "")
+
+	withCluster(rec, "eu")
+	is.Equal(RecordCluster(rec), "eu")
+}
+
+func TestMultiClusterConsumer(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	newConsumer := func(cluster string) *MockConsumer {
+		rec := &Record{Topic: "test", Value: []byte(cluster)}
+		withCluster(rec, cluster)
+
+		c := NewMockConsumer(ctrl)
+		consumed := false
+		c.EXPECT().Consume(gomock.Any()).DoAndReturn(func(ctx context.Context) (*Record, error) {
+			if !consumed {
+				consumed = true
+				return rec, nil
+			}
+			<-ctx.Done() // block until the consumer is closed
+			return nil, ctx.Err()
+		}).MinTimes(1)
+		c.EXPECT().Ack(gomock.Any(), Position{Topic: "test", Cluster: cluster}).Return(nil)
+		c.EXPECT().Close(gomock.Any()).Return(nil)
+		return c
+	}
+
+	underTest := NewMultiClusterConsumer(ctx, map[string]Consumer{
+		"eu": newConsumer("eu"),
+		"us": newConsumer("us"),
+	}, nil)
+
+	got := map[string]bool{}
+	for range 2 {
+		rec, err := underTest.Consume(ctx)
+		is.NoErr(err)
+		is.Equal(string(rec.Value), RecordCluster(rec))
+		got[RecordCluster(rec)] = true
+	}
+	is.Equal(got, map[string]bool{"eu": true, "us": true})
+
+	// acks are routed to the consumer of the cluster in the position
+	is.NoErr(underTest.Ack(ctx, Position{Topic: "test", Cluster: "us"}))
+	is.NoErr(underTest.Ack(ctx, Position{Topic: "test", Cluster: "eu"}))
+	is.True(underTest.Ack(ctx, Position{Topic: "test", Cluster: "asia"}) != nil) // unknown cluster
+
+	is.NoErr(underTest.Close(ctx))
+}
+
+func TestMultiClusterConsumer_Error(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	wantErr := errors.New("test error")
+	c := NewMockConsumer(gomock.NewController(t))
+	c.EXPECT().Consume(gomock.Any()).Return(nil, wantErr)
+	c.EXPECT().Close(gomock.Any()).Return(nil)
+
+	underTest := NewMultiClusterConsumer(ctx, map[string]Consumer{"eu": c}, nil)
+	_, err := underTest.Consume(ctx)
+	is.True(errors.Is(err, wantErr))
+	is.Equal(err.Error(), `cluster "eu": test error`)
+
+	is.NoErr(underTest.Close(ctx))
+}
+
+func TestMultiClusterConsumer_Offsets(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	// the consumer of each cluster returns records from the channel
+	newConsumer := func(records chan *Record) *MockConsumer {
+		c := NewMockConsumer(ctrl)
+		c.EXPECT().Consume(gomock.Any()).DoAndReturn(func(ctx context.Context) (*Record, error) {
+			select {
+			case rec := <-records:
+				return rec, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}).MinTimes(1)
+		c.EXPECT().Close(gomock.Any()).Return(nil)
+		return c
+	}
+	newRecord := func(offsets PartitionOffsets) *Record {
+		rec := &Record{Topic: "test"}
+		withPartitionOffsets(rec, offsets)
+		return rec
+	}
+
+	eu, us := make(chan *Record), make(chan *Record)
+	underTest := NewMultiClusterConsumer(ctx, map[string]Consumer{
+		"eu": newConsumer(eu),
+		"us": newConsumer(us),
+	}, map[string]PartitionOffsets{
+		"eu":      {"test": {0: 3}},
+		"removed": {"test": {0: 7}}, // cluster removed from the config
+	})
+
+	// offsets of clusters without consumed records are taken from the position
+	us <- newRecord(PartitionOffsets{"test": {0: 5}})
+	rec, err := underTest.Consume(ctx)
+	is.NoErr(err)
+	is.Equal(RecordClusterOffsets(rec), map[string]PartitionOffsets{
+		"eu": {"test": {0: 3}},
+		"us": {"test": {0: 5}},
+	})
+
+	eu <- newRecord(PartitionOffsets{"test": {0: 4, 1: 1}})
+	rec, err = underTest.Consume(ctx)
+	is.NoErr(err)
+	is.Equal(RecordClusterOffsets(rec), map[string]PartitionOffsets{
+		"eu": {"test": {0: 4, 1: 1}},
+		"us": {"test": {0: 5}},
+	})
+
+	is.NoErr(underTest.Close(ctx))
+}
@@ -0,0 +1,117 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Policies for offsets in the position that differ from the offsets committed
+// by the consumer group, see Config.OffsetMismatchPolicy.
+const (
+	OffsetMismatchPolicyPosition = "position"
+	OffsetMismatchPolicyGroup    = "group"
+	OffsetMismatchPolicyFail     = "fail"
+)
+
+// offsetReconciler compares the offsets stored in the position the source is
+// resumed from with the offsets committed by the consumer group, once the
+// group is joined for the first time. Mismatches are handled according to the
+// offset mismatch policy. It is safe for concurrent use.
+type offsetReconciler struct {
+	policy string
+	logger *zerolog.Logger
+
+	m sync.Mutex
+	// offsets contains the offsets of the next records to read stored in the
+	// position, it is nil once the offsets were reconciled.
+	offsets PartitionOffsets
+	// err is the mismatch error if the policy is "fail".
+	err error
+}
+
+func newOffsetReconciler(logger *zerolog.Logger, policy string, offsets PartitionOffsets) *offsetReconciler {
+	return &offsetReconciler{
+		policy:  policy,
+		logger:  logger,
+		offsets: offsets,
+	}
+}
+
+// Adjust is called by franz-go with the offsets fetched for the partitions
+// assigned to the consumer (see kgo.AdjustFetchOffsetsFn) and returns the
+// offsets the consumer starts at. Offsets are only reconciled after the first
+// assignment, partitions assigned later could have been consumed by other
+// members of the group in the meantime, so their committed offsets are used.
+func (r *offsetReconciler) Adjust(_ context.Context, committed map[string]map[int32]kgo.Offset) (map[string]map[int32]kgo.Offset, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.offsets == nil {
+		return committed, nil
+	}
+	offsets := r.offsets
+	r.offsets = nil
+
+	var mismatches []string
+	for _, topic := range slices.Sorted(maps.Keys(committed)) {
+		for _, partition := range slices.Sorted(maps.Keys(committed[topic])) {
+			pos, ok := offsets[topic][partition]
+			if !ok {
+				continue // nothing was read from the partition
+			}
+			// offsets of partitions without a committed offset are negative
+			group := committed[topic][partition].EpochOffset().Offset
+			if group == pos {
+				continue
+			}
+			mismatches = append(mismatches, fmt.Sprintf("%s/%d (position %d, group %d)", topic, partition, pos, group))
+			r.logger.Warn().
+				Str("topic", topic).
+				Int32("partition", partition).
+				Int64("positionOffset", pos).
+				Int64("groupOffset", group).
+				Str("offsetMismatchPolicy", r.policy).
+				Msg("offset in the position differs from the offset committed by the consumer group")
+
+			if r.policy == OffsetMismatchPolicyPosition {
+				// clear the epoch, the committed epoch doesn't belong to the
+				// offset in the position
+				committed[topic][partition] = kgo.NewOffset().At(pos).WithEpoch(-1)
+			}
+		}
+	}
+
+	if len(mismatches) > 0 && r.policy == OffsetMismatchPolicyFail {
+		r.err = fmt.Errorf("offsets in the position differ from the offsets committed by the consumer group: %s", strings.Join(mismatches, ", "))
+		return nil, r.err
+	}
+	return committed, nil
+}
+
+// Err returns the mismatch error if the policy is "fail" and the offsets
+// didn't match.
+func (r *offsetReconciler) Err() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.err
+}
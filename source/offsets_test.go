@@ -0,0 +1,67 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/rs/zerolog"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestOffsetReconciler_Adjust(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	r := newOffsetReconciler(&logger, OffsetMismatchPolicyPosition, PartitionOffsets{
+		"foo": {0: 10, 1: 20},
+	})
+	got, err := r.Adjust(ctx, map[string]map[int32]kgo.Offset{
+		"foo": {
+			0: kgo.NewOffset().At(5).WithEpoch(3), // mismatch
+			1: kgo.NewOffset().At(20),             // match
+			2: kgo.NewOffset().At(7),              // not in the position
+		},
+		"bar": {0: kgo.NewOffset().AtStart()},
+	})
+	is.NoErr(err)
+	is.Equal(got["foo"][0].EpochOffset(), kgo.EpochOffset{Epoch: -1, Offset: 10})
+	is.Equal(got["foo"][1].EpochOffset().Offset, int64(20))
+	is.Equal(got["foo"][2].EpochOffset().Offset, int64(7))
+	is.Equal(got["bar"][0], kgo.NewOffset().AtStart())
+
+	// partitions assigned later keep their committed offsets
+	got, err = r.Adjust(ctx, map[string]map[int32]kgo.Offset{
+		"foo": {0: kgo.NewOffset().At(5)},
+	})
+	is.NoErr(err)
+	is.Equal(got["foo"][0].EpochOffset().Offset, int64(5))
+}
+
+func TestOffsetReconciler_Adjust_Fail(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	logger := zerolog.Nop()
+
+	r := newOffsetReconciler(&logger, OffsetMismatchPolicyFail, PartitionOffsets{"foo": {0: 10}})
+	_, err := r.Adjust(ctx, map[string]map[int32]kgo.Offset{
+		"foo": {0: kgo.NewOffset().AtStart()}, // nothing committed
+	})
+	is.True(err != nil)
+	is.Equal(r.Err(), err)
+}
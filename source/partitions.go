@@ -0,0 +1,371 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Special offsets of partitions, they match the offsets used by the Kafka
+// ListOffsets API.
+const (
+	// OffsetEnd is the offset of the next record written to a partition.
+	OffsetEnd int64 = -1
+	// OffsetStart is the offset of the first record in a partition.
+	OffsetStart int64 = -2
+	// offsetNone is the end offset of partitions that are read indefinitely.
+	offsetNone int64 = -1
+)
+
+// PartitionRange is the range of offsets read from an assigned partition.
+type PartitionRange struct {
+	// Start is the offset of the first record read, OffsetStart or
+	// OffsetEnd. Consumers resolve OffsetStart and OffsetEnd to concrete
+	// offsets before reading.
+	Start int64
+	// End is the offset of the last record read (inclusive), or -1 if the
+	// partition is read indefinitely.
+	End int64
+}
+
+// PartitionAssignment contains the ranges of offsets read from each assigned
+// partition, keyed by topic and partition.
+type PartitionAssignment map[string]map[int32]PartitionRange
+
+// PartitionOffsets contains an offset for each partition, keyed by topic and
+// partition.
+type PartitionOffsets map[string]map[int32]int64
+
+// ParsePartitions parses the assignment configured in Config.Partitions.
+// Partitions without a start offset start at defaultStart.
+func ParsePartitions(partitions []string, defaultStart int64) (PartitionAssignment, error) {
+	out := make(PartitionAssignment)
+	var topic string
+	for _, p := range partitions {
+		p = strings.TrimSpace(p)
+		if i := strings.LastIndex(p, ":"); i >= 0 {
+			topic, p = p[:i], p[i+1:]
+			if topic == "" {
+				return nil, fmt.Errorf("invalid partition %q: empty topic", p)
+			}
+		}
+		if topic == "" {
+			return nil, fmt.Errorf("invalid partition %q: missing topic, expected <topic>:<partition>", p)
+		}
+
+		partition, r, err := parsePartitionRange(p, defaultStart)
+		if err != nil {
+			return nil, fmt.Errorf("invalid partition %q of topic %q: %w", p, topic, err)
+		}
+		if out[topic] == nil {
+			out[topic] = make(map[int32]PartitionRange)
+		}
+		if _, ok := out[topic][partition]; ok {
+			return nil, fmt.Errorf("partition %d of topic %q is assigned twice", partition, topic)
+		}
+		out[topic][partition] = r
+	}
+	return out, nil
+}
+
+// parsePartitionRange parses a partition with an optional offset range, e.g.
+// "0", "0@100" or "0@100-200".
+func parsePartitionRange(s string, defaultStart int64) (int32, PartitionRange, error) {
+	r := PartitionRange{Start: defaultStart, End: offsetNone}
+	s, offsets, hasOffsets := strings.Cut(s, "@")
+
+	partition, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || partition < 0 {
+		return 0, r, fmt.Errorf("expected a partition number")
+	}
+	if !hasOffsets {
+		return int32(partition), r, nil
+	}
+
+	start, end, hasEnd := strings.Cut(offsets, "-")
+	r.Start, err = strconv.ParseInt(start, 10, 64)
+	if err != nil || r.Start < 0 {
+		return 0, r, fmt.Errorf("invalid start offset %q", start)
+	}
+	if hasEnd {
+		r.End, err = strconv.ParseInt(end, 10, 64)
+		if err != nil || r.End < r.Start {
+			return 0, r, fmt.Errorf("invalid end offset %q, expected an offset not lower than the start offset", end)
+		}
+	}
+	return int32(partition), r, nil
+}
+
+// Topics returns the sorted topics of the assigned partitions.
+func (a PartitionAssignment) Topics() []string {
+	return slices.Sorted(maps.Keys(a))
+}
+
+// WithOffsets returns the assignment with the start offsets replaced by the
+// offsets, which need to contain the same partitions as the assignment.
+func (a PartitionAssignment) WithOffsets(offsets PartitionOffsets) (PartitionAssignment, error) {
+	if !a.matches(offsets) {
+		return nil, fmt.Errorf("the old position contains different partitions (%v) than the connector configuration (%v), please check if the partitions changed since the last run", offsets.String(), a.String())
+	}
+	out := make(PartitionAssignment, len(a))
+	for topic, partitions := range a {
+		out[topic] = make(map[int32]PartitionRange, len(partitions))
+		for partition, r := range partitions {
+			r.Start = offsets[topic][partition]
+			out[topic][partition] = r
+		}
+	}
+	return out, nil
+}
+
+// resolveOffsets returns the assignment with the start offsets resolved using
+// the listed start and end offsets of the partitions. OffsetStart and
+// OffsetEnd are resolved to the first offset and the offset of the next
+// record written to the partition, so positions contain concrete offsets even
+// if no record of a partition was read yet. Start offsets before the first
+// offset (i.e. the records were deleted, e.g. by retention) are reset to the
+// first offset, start offsets past the end offset fail.
+func (a PartitionAssignment) resolveOffsets(ctx context.Context, starts, ends kadm.ListedOffsets) (PartitionAssignment, error) {
+	out := make(PartitionAssignment, len(a))
+	for topic, partitions := range a {
+		out[topic] = make(map[int32]PartitionRange, len(partitions))
+		for partition, r := range partitions {
+			start, ok := starts.Lookup(topic, partition)
+			end, ok2 := ends.Lookup(topic, partition)
+			if !ok || !ok2 {
+				return nil, fmt.Errorf("partition %d of topic %q doesn't exist", partition, topic)
+			}
+			switch {
+			case r.Start == OffsetStart:
+				r.Start = start.Offset
+			case r.Start == OffsetEnd:
+				r.Start = end.Offset
+			case r.Start < start.Offset:
+				sdk.Logger(ctx).Warn().
+					Str("topic", topic).
+					Int32("partition", partition).
+					Int64("offset", r.Start).
+					Int64("startOffset", start.Offset).
+					Msg("start offset was deleted, starting at the first offset of the partition")
+				r.Start = start.Offset
+			case r.Start > end.Offset:
+				return nil, fmt.Errorf("start offset %d of partition %d of topic %q is past the end offset %d", r.Start, partition, topic, end.Offset)
+			}
+			out[topic][partition] = r
+		}
+	}
+	return out, nil
+}
+
+func (a PartitionAssignment) matches(offsets PartitionOffsets) bool {
+	if len(a) != len(offsets) {
+		return false
+	}
+	for topic, partitions := range a {
+		if len(partitions) != len(offsets[topic]) {
+			return false
+		}
+		for partition := range partitions {
+			if _, ok := offsets[topic][partition]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String returns the assigned partitions in the format of Config.Partitions
+// without offsets, e.g. "orders:0,1,payments:2".
+func (a PartitionAssignment) String() string {
+	offsets := make(PartitionOffsets, len(a))
+	for topic, partitions := range a {
+		offsets[topic] = make(map[int32]int64, len(partitions))
+		for partition := range partitions {
+			offsets[topic][partition] = 0
+		}
+	}
+	return offsets.String()
+}
+
+// String returns the partitions in the format of Config.Partitions without
+// offsets, e.g. "orders:0,1,payments:2".
+func (o PartitionOffsets) String() string {
+	var sb strings.Builder
+	for _, topic := range slices.Sorted(maps.Keys(o)) {
+		for i, partition := range slices.Sorted(maps.Keys(o[topic])) {
+			if sb.Len() > 0 {
+				sb.WriteByte(',')
+			}
+			if i == 0 {
+				sb.WriteString(topic)
+				sb.WriteByte(':')
+			}
+			sb.WriteString(strconv.Itoa(int(partition)))
+		}
+	}
+	return sb.String()
+}
+
+// clone returns a deep copy of the offsets.
+func (o PartitionOffsets) clone() PartitionOffsets {
+	out := make(PartitionOffsets, len(o))
+	for topic, partitions := range o {
+		out[topic] = maps.Clone(partitions)
+	}
+	return out
+}
+
+// set sets the offset of the partition.
+func (o PartitionOffsets) set(topic string, partition int32, offset int64) {
+	if o[topic] == nil {
+		o[topic] = make(map[int32]int64)
+	}
+	o[topic][partition] = offset
+}
+
+type partitionOffsetsKey struct{}
+
+// RecordPartitionOffsets returns the offsets of the next records to read from
+// each partition after the record was read, i.e. each assigned partition or
+// each partition of the consumer group that was read so far.
+func RecordPartitionOffsets(rec *Record) PartitionOffsets {
+	if rec.Context == nil {
+		return nil
+	}
+	offsets, _ := rec.Context.Value(partitionOffsetsKey{}).(PartitionOffsets)
+	return offsets
+}
+
+func withPartitionOffsets(rec *Record, offsets PartitionOffsets) {
+	ctx := rec.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rec.Context = context.WithValue(ctx, partitionOffsetsKey{}, offsets)
+}
+
+// partitionTracker keeps track of the offsets read from assigned partitions
+// and of the partitions that reached their end offset. It is not safe for
+// concurrent use.
+type partitionTracker struct {
+	ranges PartitionAssignment
+	// next contains the offset of the next record to read from each
+	// partition.
+	next PartitionOffsets
+	// remaining is the number of partitions with an end offset that did not
+	// reach it yet.
+	remaining int
+}
+
+func newPartitionTracker(a PartitionAssignment) *partitionTracker {
+	t := &partitionTracker{
+		ranges: a,
+		next:   make(PartitionOffsets, len(a)),
+	}
+	for topic, partitions := range a {
+		t.next[topic] = make(map[int32]int64, len(partitions))
+		for partition, r := range partitions {
+			t.next[topic][partition] = r.Start
+			if r.End != offsetNone {
+				t.remaining++
+			}
+		}
+	}
+	return t
+}
+
+// finished returns the partitions that were already read up to their end
+// offset, e.g. when the source is resumed after reading all records.
+func (t *partitionTracker) finished() map[string][]int32 {
+	out := make(map[string][]int32)
+	for topic, partitions := range t.ranges {
+		for partition, r := range partitions {
+			if r.End != offsetNone && r.Start > r.End {
+				out[topic] = append(out[topic], partition)
+				t.remaining--
+			}
+		}
+	}
+	return out
+}
+
+// Skip returns true if the record is past the end offset of its partition.
+// The record at the end offset doesn't necessarily exist (e.g. it was removed
+// by compaction or is a transaction marker), so the first record past the
+// end offset marks the partition as finished if it isn't yet, in that case
+// finished is true.
+func (t *partitionTracker) Skip(r *kgo.Record) (skip, finished bool) {
+	end := t.ranges[r.Topic][r.Partition].End
+	if end == offsetNone || r.Offset <= end {
+		return false, false
+	}
+	if t.Finished(r.Topic, r.Partition) {
+		return true, false
+	}
+	t.next.set(r.Topic, r.Partition, end+1)
+	t.remaining--
+	return true, true
+}
+
+// Read records that the record was read and that the records up to and
+// including the commit record can be committed (see
+// chunkAssembler.CommitRecord). It returns true if the partition reached its
+// end offset.
+func (t *partitionTracker) Read(r, commit *kgo.Record) bool {
+	t.next.set(commit.Topic, commit.Partition, commit.Offset+1)
+	end := t.ranges[r.Topic][r.Partition].End
+	if end == offsetNone || r.Offset < end {
+		return false
+	}
+	t.remaining--
+	return true
+}
+
+// Finished returns true if the partition was read up to its end offset.
+func (t *partitionTracker) Finished(topic string, partition int32) bool {
+	end := t.ranges[topic][partition].End
+	return end != offsetNone && t.next[topic][partition] > end
+}
+
+// Offsets returns a copy of the offsets of the next records to read.
+func (t *partitionTracker) Offsets() PartitionOffsets {
+	return t.next.clone()
+}
+
+// Done returns true if all partitions with an end offset reached it.
+func (t *partitionTracker) Done() bool {
+	return t.remaining == 0
+}
+
+// kgoOffset converts the offset into a franz-go offset.
+func kgoOffset(offset int64) kgo.Offset {
+	switch offset {
+	case OffsetStart:
+		return kgo.NewOffset().AtStart()
+	case OffsetEnd:
+		return kgo.NewOffset().AtEnd()
+	default:
+		return kgo.NewOffset().At(offset)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParsePartitions(t *testing.T) {
+	testCases := []struct {
+		name       string
+		partitions []string
+		want       PartitionAssignment
+		wantErr    bool
+	}{{
+		name:       "partitions of multiple topics",
+		partitions: []string{"orders:0", "1", "5", "payments:2"},
+		want: PartitionAssignment{
+			"orders": {
+				0: {Start: OffsetStart, End: offsetNone},
+				1: {Start: OffsetStart, End: offsetNone},
+				5: {Start: OffsetStart, End: offsetNone},
+			},
+			"payments": {2: {Start: OffsetStart, End: offsetNone}},
+		},
+	}, {
+		name:       "offsets",
+		partitions: []string{"orders:0@100-200", "1@5"},
+		want: PartitionAssignment{
+			"orders": {
+				0: {Start: 100, End: 200},
+				1: {Start: 5, End: offsetNone},
+			},
+		},
+	}, {
+		name:       "topic with colon",
+		partitions: []string{"a:b:0"},
+		want:       PartitionAssignment{"a:b": {0: {Start: OffsetStart, End: offsetNone}}},
+	}, {
+		name:       "missing topic",
+		partitions: []string{"0"},
+		wantErr:    true,
+	}, {
+		name:       "invalid partition",
+		partitions: []string{"orders:x"},
+		wantErr:    true,
+	}, {
+		name:       "end before start",
+		partitions: []string{"orders:0@200-100"},
+		wantErr:    true,
+	}, {
+		name:       "duplicate partition",
+		partitions: []string{"orders:0", "0"},
+		wantErr:    true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			got, err := ParsePartitions(tc.partitions, OffsetStart)
+			if tc.wantErr {
+				is.True(err != nil)
+				return
+			}
+			is.NoErr(err)
+			is.Equal(got, tc.want)
+		})
+	}
+}
+
+func TestPartitionAssignment_WithOffsets(t *testing.T) {
+	is := is.New(t)
+
+	a := PartitionAssignment{"orders": {
+		0: {Start: 100, End: 200},
+		1: {Start: OffsetEnd, End: offsetNone},
+	}}
+	is.Equal(a.String(), "orders:0,1")
+
+	got, err := a.WithOffsets(PartitionOffsets{"orders": {0: 150, 1: 7}})
+	is.NoErr(err)
+	is.Equal(got, PartitionAssignment{"orders": {
+		0: {Start: 150, End: 200},
+		1: {Start: 7, End: offsetNone},
+	}})
+
+	_, err = a.WithOffsets(PartitionOffsets{"orders": {0: 150, 2: 7}})
+	is.True(err != nil) // different partitions
+}
+
+func TestPartitionAssignment_ResolveOffsets(t *testing.T) {
+	starts := kadm.ListedOffsets{"orders": {0: {Topic: "orders", Partition: 0, Offset: 10}}}
+	ends := kadm.ListedOffsets{"orders": {0: {Topic: "orders", Partition: 0, Offset: 20}}}
+
+	testCases := []struct {
+		name      string
+		start     int64
+		wantStart int64
+		wantErr   bool
+	}{
+		{name: "start", start: OffsetStart, wantStart: 10},
+		{name: "end", start: OffsetEnd, wantStart: 20},
+		{name: "in range", start: 15, wantStart: 15},
+		{name: "at end", start: 20, wantStart: 20},
+		{name: "deleted", start: 5, wantStart: 10},
+		{name: "past end", start: 21, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+
+			a := PartitionAssignment{"orders": {0: {Start: tc.start, End: offsetNone}}}
+			got, err := a.resolveOffsets(context.Background(), starts, ends)
+			if tc.wantErr {
+				is.True(err != nil)
+				return
+			}
+			is.NoErr(err)
+			is.Equal(got, PartitionAssignment{"orders": {0: {Start: tc.wantStart, End: offsetNone}}})
+		})
+	}
+
+	// unknown partitions fail
+	a := PartitionAssignment{"orders": {1: {Start: OffsetStart, End: offsetNone}}}
+	_, err := a.resolveOffsets(context.Background(), starts, ends)
+	is.New(t).True(err != nil)
+}
+
+func TestFranzConsumer_Consume_Partitions(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	a := PartitionAssignment{"test": {
+		0: {Start: 1, End: 2},
+		1: {Start: OffsetStart, End: offsetNone},
+	}}
+	records := []*kgo.Record{
+		{Topic: "test", Partition: 0, Offset: 1},
+		{Topic: "test", Partition: 1, Offset: 0},
+		{Topic: "test", Partition: 0, Offset: 2},
+		{Topic: "test", Partition: 0, Offset: 3}, // past the end offset
+		{Topic: "test", Partition: 1, Offset: 1},
+	}
+
+	cl := NewMockClient(gomock.NewController(t))
+	cl.EXPECT().
+		PollFetches(gomock.Any()).
+		Return(kgo.Fetches{{Topics: []kgo.FetchTopic{{
+			Topic: "test",
+			Partitions: []kgo.FetchPartition{
+				{Partition: 0, Records: []*kgo.Record{records[0], records[2], records[3]}},
+				{Partition: 1, Records: []*kgo.Record{records[1], records[4]}},
+			},
+		}}}})
+	cl.EXPECT().PollFetches(nil).Return(nil).AnyTimes()
+	cl.EXPECT().PauseFetchPartitions(map[string][]int32{"test": {0}})
+
+	c := &FranzConsumer{
+		client:     cl,
+		queues:     newPartitionQueues(0),
+		buffer:     newBufferLimiter(cl, []string{"test"}, 0, 0),
+		partitions: newPartitionTracker(a),
+	}
+
+	// records are read round-robin across partitions
+	want := []PartitionOffsets{
+		{"test": {0: 2, 1: OffsetStart}},
+		{"test": {0: 2, 1: 1}},
+		{"test": {0: 3, 1: 1}},
+		{"test": {0: 3, 1: 2}},
+	}
+	for _, wantOffsets := range want {
+		got, err := c.Consume(ctx)
+		is.NoErr(err)
+		is.True(got.Offset < 3)
+		is.Equal(RecordPartitionOffsets(got), wantOffsets)
+	}
+	is.True(c.partitions.Done())
+	is.NoErr(c.Ack(ctx, Position{Topic: "test", Offset: 1})) // offsets are not committed
+}
+
+func TestFranzConsumer_Consume_Partitions_MissingEndOffset(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	a := PartitionAssignment{"test": {
+		0: {Start: 1, End: 2},
+		1: {Start: OffsetStart, End: offsetNone},
+	}}
+	records := []*kgo.Record{
+		{Topic: "test", Partition: 0, Offset: 1},
+		{Topic: "test", Partition: 1, Offset: 0},
+		{Topic: "test", Partition: 0, Offset: 3}, // no record at the end offset
+		{Topic: "test", Partition: 0, Offset: 4},
+		{Topic: "test", Partition: 1, Offset: 1},
+	}
+
+	cl := NewMockClient(gomock.NewController(t))
+	cl.EXPECT().
+		PollFetches(gomock.Any()).
+		Return(kgo.Fetches{{Topics: []kgo.FetchTopic{{
+			Topic: "test",
+			Partitions: []kgo.FetchPartition{
+				{Partition: 0, Records: []*kgo.Record{records[0], records[2], records[3]}},
+				{Partition: 1, Records: []*kgo.Record{records[1], records[4]}},
+			},
+		}}}})
+	cl.EXPECT().PollFetches(nil).Return(nil).AnyTimes()
+	cl.EXPECT().PauseFetchPartitions(map[string][]int32{"test": {0}})
+
+	c := &FranzConsumer{
+		client:     cl,
+		queues:     newPartitionQueues(0),
+		buffer:     newBufferLimiter(cl, []string{"test"}, 0, 0),
+		partitions: newPartitionTracker(a),
+	}
+
+	want := []PartitionOffsets{
+		{"test": {0: 2, 1: OffsetStart}},
+		{"test": {0: 2, 1: 1}},
+		{"test": {0: 3, 1: 2}},
+	}
+	for _, wantOffsets := range want {
+		got, err := c.Consume(ctx)
+		is.NoErr(err)
+		is.True(got.Offset < 3)
+		is.Equal(RecordPartitionOffsets(got), wantOffsets)
+	}
+	is.True(c.partitions.Done())
+}
+
+func TestPartitionTracker_Finished(t *testing.T) {
+	is := is.New(t)
+
+	tr := newPartitionTracker(PartitionAssignment{"test": {
+		0: {Start: 3, End: 2},
+		1: {Start: 1, End: 2},
+	}})
+	is.Equal(tr.finished(), map[string][]int32{"test": {0}})
+	is.True(!tr.Done())
+}
@@ -26,6 +26,22 @@ type Position struct {
 	Topic     string
 	Partition int32
 	Offset    int64
+	// Cluster is the name of the cluster the record was read from, it is
+	// empty if the cluster has no name.
+	Cluster string `json:",omitempty"`
+	// Partitions contains the offsets of the next records to read from each
+	// partition. If GroupID is empty, the source reads the partitions assigned
+	// with the "partitions" option and the offsets OffsetStart and OffsetEnd
+	// mean that no record was read from the partition yet. Otherwise it
+	// contains the partitions of the consumer group read so far, the offsets
+	// are reconciled with the committed offsets when the source is resumed.
+	Partitions PartitionOffsets `json:",omitempty"`
+	// Clusters contains the offsets of the next records to read from each
+	// partition of the consumer groups, keyed by cluster name. It is only
+	// set if the source reads from multiple clusters, in that case Partitions
+	// is empty. It contains the offsets of all clusters, not only the one the
+	// record was read from.
+	Clusters map[string]PartitionOffsets `json:",omitempty"`
 }
 
 func ParseSDKPosition(sdkPos opencdc.Position) (Position, error) {
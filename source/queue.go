@@ -0,0 +1,169 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"slices"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// defaultPartitionQueueSize is the default of Config.PartitionQueueSize.
+const defaultPartitionQueueSize = 1000
+
+// partitionQueues buffers fetched records in one queue per partition and
+// returns them round-robin, so a partition with a large fetch doesn't delay
+// the other partitions. The order of records within a partition is kept.
+// Partitions with full queues are reported, so fetching them can be paused
+// until their queues drain. It is not safe for concurrent use.
+type partitionQueues struct {
+	// size is the number of records in a queue at which it is full.
+	size int
+
+	queues map[topicPartition]*partitionQueue
+	// ring contains the queues with records in round-robin order, next is
+	// the index of the queue the next record is taken from.
+	ring []*partitionQueue
+	next int
+	// len is the number of records in all queues.
+	len int
+}
+
+type partitionQueue struct {
+	tp      topicPartition
+	records []*kgo.Record
+	// full is true if the queue was reported as full and was not reported as
+	// drained yet.
+	full bool
+}
+
+func newPartitionQueues(size int) *partitionQueues {
+	if size == 0 {
+		size = defaultPartitionQueueSize
+	}
+	return &partitionQueues{
+		size:   size,
+		queues: make(map[topicPartition]*partitionQueue),
+	}
+}
+
+// Len returns the number of records in all queues.
+func (q *partitionQueues) Len() int {
+	return q.len
+}
+
+// RoundStart returns true if the next record is taken from the first queue,
+// i.e. all partitions with records had their turn.
+func (q *partitionQueues) RoundStart() bool {
+	return q.next == 0
+}
+
+// Add adds the fetched records to the queues of their partitions and returns
+// the partitions whose queues became full.
+func (q *partitionQueues) Add(fetches kgo.Fetches) map[string][]int32 {
+	var full map[string][]int32
+	fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+		if len(p.Records) == 0 {
+			return
+		}
+		tp := topicPartition{topic: p.Topic, partition: p.Partition}
+		pq, ok := q.queues[tp]
+		if !ok {
+			pq = &partitionQueue{tp: tp}
+			q.queues[tp] = pq
+		}
+		if len(pq.records) == 0 {
+			q.ring = append(q.ring, pq)
+		}
+		pq.records = append(pq.records, p.Records...)
+		q.len += len(p.Records)
+
+		if !pq.full && len(pq.records) >= q.size {
+			pq.full = true
+			if full == nil {
+				full = make(map[string][]int32)
+			}
+			full[tp.topic] = append(full[tp.topic], tp.partition)
+		}
+	})
+	return full
+}
+
+// Next returns the next record round-robin across partitions, or nil if all
+// queues are empty. If the queue of the record's partition was full and
+// drained to half of its size, drained is true.
+func (q *partitionQueues) Next() (rec *kgo.Record, drained bool) {
+	if len(q.ring) == 0 {
+		return nil, false
+	}
+
+	pq := q.ring[q.next]
+	rec = pq.records[0]
+	pq.records[0] = nil // allow the record to be garbage collected
+	pq.records = pq.records[1:]
+	q.len--
+
+	if len(pq.records) == 0 {
+		// remove the empty queue from the ring, the next queue moves to the
+		// current index
+		q.ring = append(q.ring[:q.next], q.ring[q.next+1:]...)
+		pq.records = nil
+	} else {
+		q.next++
+	}
+	if q.next >= len(q.ring) {
+		q.next = 0 // start the next round
+	}
+
+	if pq.full && len(pq.records) <= q.size/2 {
+		pq.full = false
+		drained = true
+	}
+	return rec, drained
+}
+
+// Drop removes the queues of the partitions and returns their records, e.g.
+// when the partitions are revoked. It also returns the dropped partitions
+// whose queues were full, fetching them needs to be resumed.
+func (q *partitionQueues) Drop(partitions map[topicPartition]bool) (records []*kgo.Record, full map[string][]int32) {
+	for tp := range partitions {
+		pq, ok := q.queues[tp]
+		if !ok {
+			continue
+		}
+		delete(q.queues, tp)
+		if pq.full {
+			if full == nil {
+				full = make(map[string][]int32)
+			}
+			full[tp.topic] = append(full[tp.topic], tp.partition)
+		}
+		if len(pq.records) == 0 {
+			continue // not in the ring
+		}
+		records = append(records, pq.records...)
+		q.len -= len(pq.records)
+
+		i := slices.Index(q.ring, pq)
+		q.ring = append(q.ring[:i], q.ring[i+1:]...)
+		if i < q.next {
+			q.next-- // keep the position in the current round
+		}
+	}
+	if q.next >= len(q.ring) {
+		q.next = 0
+	}
+	return records, full
+}
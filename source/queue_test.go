@@ -0,0 +1,125 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/mock/gomock"
+)
+
+func testFetches(topic string, records map[int32]int) kgo.Fetches {
+	ft := kgo.FetchTopic{Topic: topic}
+	for partition := range int32(len(records)) {
+		fp := kgo.FetchPartition{Partition: partition}
+		for i := range records[partition] {
+			fp.Records = append(fp.Records, &kgo.Record{
+				Topic:     topic,
+				Partition: partition,
+				Offset:    int64(i),
+				Value:     []byte(fmt.Sprintf("%d/%d", partition, i)),
+			})
+		}
+		ft.Partitions = append(ft.Partitions, fp)
+	}
+	return kgo.Fetches{{Topics: []kgo.FetchTopic{ft}}}
+}
+
+func TestPartitionQueues(t *testing.T) {
+	is := is.New(t)
+
+	q := newPartitionQueues(4)
+	full := q.Add(testFetches("test", map[int32]int{0: 5, 1: 1, 2: 2}))
+	is.Equal(full, map[string][]int32{"test": {0}})
+	is.Equal(q.Len(), 8)
+
+	var got []string
+	var drained []string
+	for q.Len() > 0 {
+		rec, ok := q.Next()
+		got = append(got, string(rec.Value))
+		if ok {
+			drained = append(drained, string(rec.Value))
+		}
+	}
+	is.Equal(got, []string{"0/0", "1/0", "2/0", "0/1", "2/1", "0/2", "0/3", "0/4"})
+	is.Equal(drained, []string{"0/2"}) // 2 records left, half of the queue size
+
+	rec, _ := q.Next()
+	is.True(rec == nil)
+	is.True(q.RoundStart())
+}
+
+func TestPartitionQueues_Drop(t *testing.T) {
+	is := is.New(t)
+
+	q := newPartitionQueues(4)
+	q.Add(testFetches("test", map[int32]int{0: 5, 1: 2, 2: 2}))
+	rec, _ := q.Next()
+	is.Equal(string(rec.Value), "0/0")
+	rec, _ = q.Next()
+	is.Equal(string(rec.Value), "1/0")
+
+	records, full := q.Drop(map[topicPartition]bool{{"test", 0}: true, {"test", 3}: true})
+	is.Equal(len(records), 4)
+	is.Equal(full, map[string][]int32{"test": {0}}) // needs to be resumed
+	is.Equal(q.Len(), 3)
+
+	var got []string
+	for q.Len() > 0 {
+		rec, _ := q.Next()
+		got = append(got, string(rec.Value))
+	}
+	// the round continues with the partition after the dropped one
+	is.Equal(got, []string{"2/0", "1/1", "2/1"})
+}
+
+func TestFranzConsumer_Consume_RoundRobin(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	cl := NewMockClient(gomock.NewController(t))
+	cl.EXPECT().PollFetches(ctx).Return(testFetches("test", map[int32]int{0: 3, 1: 1}))
+	cl.EXPECT().PauseFetchPartitions(map[string][]int32{"test": {0}})
+	// records fetched while the first round is read join the next round
+	cl.EXPECT().PollFetches(nil).Return(kgo.Fetches{{Topics: []kgo.FetchTopic{{
+		Topic: "test",
+		Partitions: []kgo.FetchPartition{{
+			Partition: 1,
+			Records:   []*kgo.Record{{Topic: "test", Partition: 1, Offset: 1, Value: []byte("1/1")}},
+		}},
+	}}}})
+	cl.EXPECT().PollFetches(nil).Return(nil).AnyTimes()
+	cl.EXPECT().ResumeFetchPartitions(map[string][]int32{"test": {0}})
+
+	c := &FranzConsumer{
+		client: cl,
+		acker:  newBatchAcker(cl, 1000),
+		queues: newPartitionQueues(2),
+		buffer: newBufferLimiter(cl, []string{"test"}, 0, 0),
+	}
+
+	var got []string
+	for range 5 {
+		rec, err := c.Consume(ctx)
+		is.NoErr(err)
+		got = append(got, string(rec.Value))
+	}
+	is.Equal(got, []string{"0/0", "1/0", "0/1", "1/1", "0/2"})
+}
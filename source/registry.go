@@ -0,0 +1,153 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// Schema types returned by the schema registry.
+const (
+	registryTypeAvro     = "AVRO"
+	registryTypeJSON     = "JSON"
+	registryTypeProtobuf = "PROTOBUF"
+)
+
+// registryHeaderSize is the size of the header of the Confluent wire format,
+// a magic byte (always 0) followed by the schema ID as a big-endian uint32.
+// See https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#wire-format.
+const registryHeaderSize = 5
+
+// registryNotFoundTTL is how long a schema ID unknown to the schema registry
+// is cached. A producer can register the schema after the ID was looked up,
+// so unknown IDs are looked up again once the TTL expired.
+const registryNotFoundTTL = time.Minute
+
+// errSchemaNotFound is returned by the registry client if the schema registry
+// doesn't know the schema ID.
+var errSchemaNotFound = errors.New("schema not found")
+
+// registrySchema is a schema fetched from the schema registry.
+type registrySchema struct {
+	ID     int
+	Type   string
+	Schema string
+	// notFoundUntil is set if the schema registry didn't know the schema ID,
+	// the ID is looked up again after this time.
+	notFoundUntil time.Time
+}
+
+// registryClient fetches schemas by ID from a Confluent compatible schema
+// registry. Schemas are immutable, so fetched schemas are cached. Unknown
+// schema IDs are cached for registryNotFoundTTL, data referencing an unknown
+// ID is most likely not in the wire format and would otherwise result in a
+// request per record.
+type registryClient struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+	cache    *lru.Cache[int, registrySchema]
+}
+
+func newRegistryClient(url, username, password string, timeout time.Duration) (*registryClient, error) {
+	cache, err := lru.New[int, registrySchema](schemaCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &registryClient{
+		url:      strings.TrimSuffix(url, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: timeout},
+		cache:    cache,
+	}, nil
+}
+
+// registrySchemaID returns the schema ID of data in the Confluent wire format.
+// It returns false if the data is not in the wire format. Note that any data
+// starting with a zero byte looks like the wire format, it's only confirmed
+// once the schema is found and the data can be decoded with it.
+func registrySchemaID(b []byte) (int, bool) {
+	if len(b) < registryHeaderSize || b[0] != 0 {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint32(b[1:registryHeaderSize])), true
+}
+
+// Schema returns the schema with the ID. It returns errSchemaNotFound if the
+// schema registry doesn't know the ID.
+func (c *registryClient) Schema(ctx context.Context, id int) (registrySchema, error) {
+	if s, ok := c.cache.Get(id); ok {
+		if s.notFoundUntil.IsZero() {
+			return s, nil
+		}
+		if time.Now().Before(s.notFoundUntil) {
+			return registrySchema{}, fmt.Errorf("failed to fetch schema %d: %w", id, errSchemaNotFound)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+"/schemas/ids/"+strconv.Itoa(id), nil)
+	if err != nil {
+		return registrySchema{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return registrySchema{}, fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return registrySchema{}, fmt.Errorf("failed to read schema %d: %w", id, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		c.cache.Add(id, registrySchema{ID: id, notFoundUntil: time.Now().Add(registryNotFoundTTL)})
+		return registrySchema{}, fmt.Errorf("failed to fetch schema %d: %w", id, errSchemaNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return registrySchema{}, fmt.Errorf("failed to fetch schema %d: schema registry responded with %s: %s", id, resp.Status, body)
+	}
+
+	var out struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return registrySchema{}, fmt.Errorf("failed to decode schema %d: %w", id, err)
+	}
+	s := registrySchema{ID: id, Type: out.SchemaType, Schema: out.Schema}
+	if s.Type == "" {
+		s.Type = registryTypeAvro // the registry omits the type of Avro schemas
+	}
+	c.cache.Add(id, s)
+	return s, nil
+}
@@ -0,0 +1,254 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	cschema "github.com/conduitio/conduit-commons/schema"
+	sdk "github.com/conduitio/conduit-connector-sdk"
+	"github.com/conduitio/conduit-connector-sdk/kafkaconnect"
+	sdkschema "github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/goccy/go-json"
+	"github.com/hamba/avro/v2"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// schemaCacheSize is the number of schemas cached by the SchemaDecoder and the
+// registry client.
+const schemaCacheSize = 1000
+
+// ErrDecode is returned by SchemaDecoder.Decode if structured data doesn't
+// match its schema, e.g. the payload of a Kafka Connect envelope. It is handled
+// according to Config.DecodeErrorPolicy.
+var ErrDecode = errors.New("data doesn't match its schema")
+
+// SchemaDecoder decodes structured keys and values and registers their
+// schemas in the schema service, so they can be attached to records. It
+// supports Kafka Connect envelopes (JSON objects with the fields "schema" and
+// "payload") and, if a schema registry is configured, data in the Confluent
+// wire format with Avro and JSON schemas.
+type SchemaDecoder struct {
+	// registry is nil if no schema registry is configured.
+	registry *registryClient
+	// schemas contains the schemas used to decode data, keyed by subject and
+	// the schema the data was written with.
+	schemas *lru.Cache[string, *decodingSchema]
+	// create registers a schema, it's sdkschema.Create by default.
+	create func(ctx context.Context, typ cschema.Type, subject string, bytes []byte) (cschema.Schema, error)
+}
+
+// decodingSchema is an Avro schema and the corresponding registered schema.
+// Both are empty if the schema the data was written with is not supported.
+type decodingSchema struct {
+	avro   avro.Schema
+	schema cschema.Schema
+}
+
+func NewSchemaDecoder(cfg Config) (*SchemaDecoder, error) {
+	cache, err := lru.New[string, *decodingSchema](schemaCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	d := &SchemaDecoder{schemas: cache, create: sdkschema.Create}
+	if cfg.SchemaRegistryURL != "" {
+		d.registry, err = newRegistryClient(
+			cfg.SchemaRegistryURL,
+			cfg.SchemaRegistryUsername,
+			cfg.SchemaRegistryPassword,
+			cfg.RequestTimeout,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Decode decodes the data and returns it as structured data together with
+// the schema registered under the subject. Data that is not structured or
+// written with an unsupported schema is returned as raw data without a
+// schema. If the data doesn't match its schema, an error wrapping ErrDecode is
+// returned.
+func (d *SchemaDecoder) Decode(ctx context.Context, subject string, b []byte) (opencdc.Data, *cschema.Schema, error) {
+	if id, ok := registrySchemaID(b); ok && d.registry != nil {
+		return d.decodeRegistry(ctx, subject, id, b)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		return d.decodeKafkaConnect(ctx, subject, b)
+	}
+	return opencdc.RawData(b), nil, nil
+}
+
+// decodeRegistry decodes data in the Confluent wire format. Data referencing
+// a schema unknown to the registry, or that can't be decoded with the schema,
+// is not in the wire format and is returned as raw data.
+func (d *SchemaDecoder) decodeRegistry(ctx context.Context, subject string, id int, b []byte) (opencdc.Data, *cschema.Schema, error) {
+	rs, err := d.registry.Schema(ctx, id)
+	switch {
+	case errors.Is(err, errSchemaNotFound):
+		sdk.Logger(ctx).Debug().Int("schemaID", id).Str("subject", subject).
+			Msg("schema not found, data is not in the wire format and is read as raw data")
+		return opencdc.RawData(b), nil, nil
+	case err != nil:
+		return nil, nil, err
+	}
+	ds, err := d.schema(ctx, subject, "registry:"+strconv.Itoa(id), func() (avro.Schema, error) {
+		switch rs.Type {
+		case registryTypeAvro:
+			return avro.Parse(rs.Schema)
+		case registryTypeJSON:
+			return jsonAvroSchema([]byte(rs.Schema))
+		default:
+			return nil, fmt.Errorf("%s schemas are not supported", rs.Type)
+		}
+	})
+	if err != nil || ds.avro == nil {
+		return opencdc.RawData(b), nil, err
+	}
+
+	body := b[registryHeaderSize:]
+	if rs.Type == registryTypeAvro {
+		if err := checkAvro(ds.avro, body); err != nil {
+			return notWireFormat(ctx, subject, id, b, err), nil, nil
+		}
+		var sd opencdc.StructuredData
+		if err := ds.schema.Unmarshal(body, &sd); err != nil {
+			return notWireFormat(ctx, subject, id, b, err), nil, nil
+		}
+		return sd, &ds.schema, nil
+	}
+
+	sd, err := decodeJSON(ds.avro, body)
+	if err != nil {
+		return notWireFormat(ctx, subject, id, b, err), nil, nil
+	}
+	return sd, &ds.schema, nil
+}
+
+// checkAvro checks that b contains exactly one value encoded with the Avro
+// schema. avro.Unmarshal doesn't report truncated data or trailing bytes,
+// which is likely for data that only looks like the wire format.
+func checkAvro(s avro.Schema, b []byte) error {
+	r := avro.NewReader(nil, 0).Reset(b)
+	var v any
+	r.ReadVal(s, &v)
+	if r.Error != nil {
+		return r.Error
+	}
+	if r.Peek(); r.Error == nil {
+		return errors.New("unexpected trailing bytes")
+	}
+	return nil
+}
+
+// notWireFormat logs that the data couldn't be decoded with the schema it
+// references and returns it as raw data.
+func notWireFormat(ctx context.Context, subject string, id int, b []byte, err error) opencdc.Data {
+	sdk.Logger(ctx).Debug().Err(err).Int("schemaID", id).Str("subject", subject).
+		Msg("failed to decode data with schema, data is not in the wire format and is read as raw data")
+	return opencdc.RawData(b)
+}
+
+func (d *SchemaDecoder) decodeKafkaConnect(ctx context.Context, subject string, b []byte) (opencdc.Data, *cschema.Schema, error) {
+	var env map[string]json.RawMessage
+	if err := json.Unmarshal(b, &env); err != nil || len(env) != 2 || env["payload"] == nil ||
+		env["schema"] == nil || string(env["schema"]) == "null" {
+		return opencdc.RawData(b), nil, nil // not an envelope
+	}
+
+	ds, err := d.schema(ctx, subject, "kafka-connect:"+string(env["schema"]), func() (avro.Schema, error) {
+		var s kafkaconnect.Schema
+		if err := json.Unmarshal(env["schema"], &s); err != nil {
+			return nil, fmt.Errorf("invalid kafka connect schema: %w", err)
+		}
+		return connectAvroSchema(&s)
+	})
+	if err != nil || ds.avro == nil {
+		return opencdc.RawData(b), nil, err
+	}
+
+	sd, err := decodeJSON(ds.avro, env["payload"])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: failed to decode kafka connect payload: %w", ErrDecode, err)
+	}
+	return sd, &ds.schema, nil
+}
+
+// schema returns the schema for data written with the schema identified by
+// origin. If the schema isn't cached yet, it is built and registered under
+// the subject. Schemas that can't be built are cached as unsupported.
+func (d *SchemaDecoder) schema(
+	ctx context.Context,
+	subject string,
+	origin string,
+	build func() (avro.Schema, error),
+) (*decodingSchema, error) {
+	key := subject + "\x00" + origin
+	if ds, ok := d.schemas.Get(key); ok {
+		return ds, nil
+	}
+
+	as, err := build()
+	if err == nil && as.Type() != avro.Record {
+		err = fmt.Errorf("only records are supported, got %s", as.Type())
+	}
+	if err != nil {
+		sdk.Logger(ctx).Warn().Err(err).
+			Str("subject", subject).
+			Msg("schema is not supported, data written with this schema is read as raw data")
+		ds := &decodingSchema{}
+		d.schemas.Add(key, ds)
+		return ds, nil
+	}
+
+	b, err := json.Marshal(as)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal avro schema: %w", err)
+	}
+	sch, err := d.create(ctx, cschema.TypeAvro, subject, b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register schema for subject %q: %w", subject, err)
+	}
+
+	ds := &decodingSchema{avro: as, schema: sch}
+	d.schemas.Add(key, ds)
+	return ds, nil
+}
+
+// decodeJSON decodes the JSON object into structured data matching the Avro
+// record schema.
+func decodeJSON(s avro.Schema, b []byte) (opencdc.StructuredData, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber() // don't lose precision of large integers
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	out, err := avroValue(s, v)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := out.(map[string]any)
+	if !ok {
+		return nil, errors.New("expected an object")
+	}
+	return m, nil
+}
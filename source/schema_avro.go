@@ -0,0 +1,601 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/conduitio/conduit-connector-sdk/kafkaconnect"
+	"github.com/goccy/go-json"
+	"github.com/hamba/avro/v2"
+)
+
+// Names of logical Kafka Connect types, see
+// https://kafka.apache.org/documentation/#connect_schemas and
+// https://debezium.io/documentation/reference/stable/connectors/postgresql.html#postgresql-temporal-types.
+const (
+	connectDecimal        = "org.apache.kafka.connect.data.Decimal"
+	connectDate           = "org.apache.kafka.connect.data.Date"
+	connectTime           = "org.apache.kafka.connect.data.Time"
+	connectTimestamp      = "org.apache.kafka.connect.data.Timestamp"
+	connectMicroTime      = "io.debezium.time.MicroTime"
+	connectMicroTimestamp = "io.debezium.time.MicroTimestamp"
+
+	connectDecimalScale     = "scale"
+	connectDecimalPrecision = "connect.decimal.precision"
+)
+
+// defaultDecimalPrecision is used for decimals without a precision, Avro
+// requires a precision while Kafka Connect doesn't.
+const defaultDecimalPrecision = 38
+
+// avroRecords keeps track of the records defined in an Avro schema. Avro
+// requires names of records to be unique within a schema, a record that is
+// used multiple times is defined once and referenced afterward.
+type avroRecords struct {
+	defined map[string]avroRecord
+	names   map[string]int
+}
+
+type avroRecord struct {
+	// origin is the schema the record was converted from, it's used to check
+	// if a record with the same name is the same record.
+	origin any
+	schema *avro.RecordSchema
+}
+
+func newAvroRecords() avroRecords {
+	return avroRecords{
+		defined: make(map[string]avroRecord),
+		names:   make(map[string]int),
+	}
+}
+
+// get returns a reference to an already defined record with the same name
+// and origin, or nil.
+func (r avroRecords) get(name string, origin any) avro.Schema {
+	if rec, ok := r.defined[name]; ok && reflect.DeepEqual(rec.origin, origin) {
+		return avro.NewRefSchema(rec.schema)
+	}
+	return nil
+}
+
+// name returns a unique name by appending a counter to names that were
+// already used.
+func (r avroRecords) name(name string) string {
+	r.names[name]++
+	if n := r.names[name]; n > 1 {
+		return name + strconv.Itoa(n)
+	}
+	return name
+}
+
+func (r avroRecords) define(name string, origin any, fields []*avro.Field, opts ...avro.SchemaOption) (*avro.RecordSchema, error) {
+	rec, err := avro.NewRecordSchema(name, "", fields, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := r.defined[name]; !ok {
+		r.defined[name] = avroRecord{origin: origin, schema: rec}
+	}
+	return rec, nil
+}
+
+// avroName converts a (possibly qualified) name into a valid Avro name.
+func avroName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		var sb strings.Builder
+		for j, r := range p {
+			switch {
+			case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+				sb.WriteRune(r)
+			case r >= '0' && r <= '9':
+				if j == 0 {
+					sb.WriteRune('_')
+				}
+				sb.WriteRune(r)
+			default:
+				sb.WriteRune('_')
+			}
+		}
+		if sb.Len() == 0 {
+			sb.WriteRune('_')
+		}
+		parts[i] = sb.String()
+	}
+	return strings.Join(parts, ".")
+}
+
+// nullable wraps the schema into a union with null.
+func nullable(s avro.Schema) (avro.Schema, error) {
+	return avro.NewUnionSchema([]avro.Schema{avro.NewNullSchema(), s})
+}
+
+// connectAvroSchema converts a kafka connect schema into an Avro schema.
+// Logical Kafka Connect and Debezium types are converted into the matching
+// Avro logical types. Maps with non-string keys and field names that aren't
+// valid Avro names are not supported.
+func connectAvroSchema(s *kafkaconnect.Schema) (avro.Schema, error) {
+	return connectAvroBuilder{records: newAvroRecords()}.build(s)
+}
+
+type connectAvroBuilder struct {
+	records avroRecords
+}
+
+func (b connectAvroBuilder) build(s *kafkaconnect.Schema) (avro.Schema, error) {
+	schema, err := b.buildType(s)
+	if err != nil {
+		return nil, err
+	}
+	if !s.Optional {
+		return schema, nil
+	}
+	return nullable(schema)
+}
+
+func (b connectAvroBuilder) buildType(s *kafkaconnect.Schema) (avro.Schema, error) {
+	switch s.Name {
+	case connectDecimal:
+		scale, err := strconv.Atoi(s.Parameters[connectDecimalScale])
+		if err != nil {
+			return nil, fmt.Errorf("invalid decimal scale %q: %w", s.Parameters[connectDecimalScale], err)
+		}
+		precision := max(defaultDecimalPrecision, scale)
+		if p, ok := s.Parameters[connectDecimalPrecision]; ok {
+			precision, err = strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid decimal precision %q: %w", p, err)
+			}
+		}
+		return avro.NewPrimitiveSchema(avro.Bytes, avro.NewDecimalLogicalSchema(precision, scale)), nil
+	case connectDate:
+		return avro.NewPrimitiveSchema(avro.Int, avro.NewPrimitiveLogicalSchema(avro.Date)), nil
+	case connectTime:
+		return avro.NewPrimitiveSchema(avro.Int, avro.NewPrimitiveLogicalSchema(avro.TimeMillis)), nil
+	case connectTimestamp:
+		return avro.NewPrimitiveSchema(avro.Long, avro.NewPrimitiveLogicalSchema(avro.TimestampMillis)), nil
+	case connectMicroTime:
+		return avro.NewPrimitiveSchema(avro.Long, avro.NewPrimitiveLogicalSchema(avro.TimeMicros)), nil
+	case connectMicroTimestamp:
+		return avro.NewPrimitiveSchema(avro.Long, avro.NewPrimitiveLogicalSchema(avro.TimestampMicros)), nil
+	}
+
+	switch s.Type {
+	case kafkaconnect.TypeBoolean:
+		return avro.NewPrimitiveSchema(avro.Boolean, nil), nil
+	case kafkaconnect.TypeInt8, kafkaconnect.TypeInt16, kafkaconnect.TypeInt32:
+		return avro.NewPrimitiveSchema(avro.Int, nil), nil
+	case kafkaconnect.TypeInt64:
+		return avro.NewPrimitiveSchema(avro.Long, nil), nil
+	case kafkaconnect.TypeFloat:
+		return avro.NewPrimitiveSchema(avro.Float, nil), nil
+	case kafkaconnect.TypeDouble:
+		return avro.NewPrimitiveSchema(avro.Double, nil), nil
+	case kafkaconnect.TypeBytes:
+		return avro.NewPrimitiveSchema(avro.Bytes, nil), nil
+	case kafkaconnect.TypeString:
+		return avro.NewPrimitiveSchema(avro.String, nil), nil
+	case kafkaconnect.TypeArray:
+		if s.Items == nil {
+			return nil, errors.New("array schema without items")
+		}
+		items, err := b.build(s.Items)
+		if err != nil {
+			return nil, err
+		}
+		return avro.NewArraySchema(items), nil
+	case kafkaconnect.TypeMap:
+		if s.Keys == nil || s.Values == nil {
+			return nil, errors.New("map schema without keys or values")
+		}
+		if s.Keys.Type != kafkaconnect.TypeString {
+			return nil, fmt.Errorf("maps with %s keys are not supported", s.Keys.Type)
+		}
+		values, err := b.build(s.Values)
+		if err != nil {
+			return nil, err
+		}
+		return avro.NewMapSchema(values), nil
+	case kafkaconnect.TypeStruct:
+		// records are compared without the field name and optionality, those
+		// belong to the enclosing field
+		origin := *s
+		origin.Field, origin.Optional = "", false
+
+		name := "Record"
+		if s.Name != "" {
+			name = avroName(s.Name)
+		}
+		if ref := b.records.get(name, origin); ref != nil {
+			return ref, nil
+		}
+		// reserve the name before building nested records, so the outer
+		// record gets the name without a counter
+		name = b.records.name(name)
+
+		fields := make([]*avro.Field, len(s.Fields))
+		for i := range s.Fields {
+			f := &s.Fields[i]
+			typ, err := b.build(f)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Field, err)
+			}
+			var opts []avro.SchemaOption
+			if f.Optional {
+				opts = append(opts, avro.WithDefault(nil))
+			}
+			if f.Doc != "" {
+				opts = append(opts, avro.WithDoc(f.Doc))
+			}
+			fields[i], err = avro.NewField(f.Field, typ, opts...)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Field, err)
+			}
+		}
+		var opts []avro.SchemaOption
+		if s.Doc != "" {
+			opts = append(opts, avro.WithDoc(s.Doc))
+		}
+		return b.records.define(name, origin, fields, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported type %q", s.Type)
+	}
+}
+
+// jsonSchema contains the parts of a JSON schema that can be converted into
+// an Avro schema.
+type jsonSchema struct {
+	Type                 any                    `json:"type"`
+	Title                string                 `json:"title"`
+	Description          string                 `json:"description"`
+	Format               string                 `json:"format"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	Items                *jsonSchema            `json:"items"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+
+	// Unsupported keywords, they are only decoded to detect them.
+	Ref   string `json:"$ref"`
+	OneOf []any  `json:"oneOf"`
+	AnyOf []any  `json:"anyOf"`
+	AllOf []any  `json:"allOf"`
+}
+
+// UnmarshalJSON handles boolean schemas (e.g. "additionalProperties": false),
+// which are treated like missing schemas.
+func (s *jsonSchema) UnmarshalJSON(b []byte) error {
+	if string(b) == "true" || string(b) == "false" {
+		return nil
+	}
+	type plain jsonSchema
+	return json.Unmarshal(b, (*plain)(s))
+}
+
+// jsonAvroSchema converts a JSON schema into an Avro schema. Objects with
+// properties are converted into records with fields sorted by name, objects
+// with only additional properties into maps. Properties that are not required
+// are nullable. References and composite schemas (oneOf, anyOf, allOf) are
+// not supported.
+func jsonAvroSchema(b []byte) (avro.Schema, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return jsonAvroBuilder{records: newAvroRecords()}.build(&s, false)
+}
+
+type jsonAvroBuilder struct {
+	records avroRecords
+}
+
+func (b jsonAvroBuilder) build(s *jsonSchema, optional bool) (avro.Schema, error) {
+	if s == nil {
+		return nil, errors.New("missing schema")
+	}
+	if s.Ref != "" || s.OneOf != nil || s.AnyOf != nil || s.AllOf != nil {
+		return nil, errors.New("references and composite schemas are not supported")
+	}
+
+	var types []string
+	switch t := s.Type.(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		for _, v := range t {
+			if str, ok := v.(string); ok {
+				types = append(types, str)
+			}
+		}
+	}
+	if i := slices.Index(types, "null"); i >= 0 {
+		types = slices.Delete(types, i, i+1)
+		optional = true
+	}
+	if len(types) != 1 {
+		return nil, fmt.Errorf("expected a single type, got %v", s.Type)
+	}
+
+	schema, err := b.buildType(s, types[0])
+	if err != nil {
+		return nil, err
+	}
+	if !optional {
+		return schema, nil
+	}
+	return nullable(schema)
+}
+
+func (b jsonAvroBuilder) buildType(s *jsonSchema, typ string) (avro.Schema, error) {
+	switch typ {
+	case "boolean":
+		return avro.NewPrimitiveSchema(avro.Boolean, nil), nil
+	case "integer":
+		return avro.NewPrimitiveSchema(avro.Long, nil), nil
+	case "number":
+		return avro.NewPrimitiveSchema(avro.Double, nil), nil
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return avro.NewPrimitiveSchema(avro.Long, avro.NewPrimitiveLogicalSchema(avro.TimestampMillis)), nil
+		case "date":
+			return avro.NewPrimitiveSchema(avro.Int, avro.NewPrimitiveLogicalSchema(avro.Date)), nil
+		}
+		return avro.NewPrimitiveSchema(avro.String, nil), nil
+	case "array":
+		items, err := b.build(s.Items, false)
+		if err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+		return avro.NewArraySchema(items), nil
+	case "object":
+		if len(s.Properties) == 0 {
+			values, err := b.build(s.AdditionalProperties, false)
+			if err != nil {
+				return nil, fmt.Errorf("additional properties: %w", err)
+			}
+			return avro.NewMapSchema(values), nil
+		}
+
+		name := "Record"
+		if s.Title != "" {
+			name = avroName(s.Title)
+		}
+		name = b.records.name(name)
+
+		names := make([]string, 0, len(s.Properties))
+		for k := range s.Properties {
+			names = append(names, k)
+		}
+		slices.Sort(names)
+
+		fields := make([]*avro.Field, len(names))
+		for i, k := range names {
+			p := s.Properties[k]
+			optional := !slices.Contains(s.Required, k)
+			typ, err := b.build(p, optional)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: %w", k, err)
+			}
+			var opts []avro.SchemaOption
+			if typ.Type() == avro.Union {
+				opts = append(opts, avro.WithDefault(nil))
+			}
+			if p.Description != "" {
+				opts = append(opts, avro.WithDoc(p.Description))
+			}
+			fields[i], err = avro.NewField(k, typ, opts...)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: %w", k, err)
+			}
+		}
+		var opts []avro.SchemaOption
+		if s.Description != "" {
+			opts = append(opts, avro.WithDoc(s.Description))
+		}
+		return b.records.define(name, nil, fields, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported type %q", typ)
+	}
+}
+
+// avroValue converts a value decoded from JSON (using json.Number for
+// numbers) into the Go type the Avro schema decodes to, e.g. int64 for
+// longs, time.Time for timestamps and *big.Rat for decimals. Bytes are
+// expected to be base64 encoded, like in Kafka Connect envelopes.
+func avroValue(s avro.Schema, v any) (any, error) {
+	switch s := s.(type) {
+	case *avro.RefSchema:
+		return avroValue(s.Schema(), v)
+	case *avro.UnionSchema:
+		if !s.Nullable() {
+			return nil, errors.New("only nullable unions are supported")
+		}
+		if v == nil {
+			return nil, nil
+		}
+		_, typ := s.Indices()
+		return avroValue(s.Types()[typ], v)
+	}
+
+	if v == nil {
+		return nil, errors.New("value is required")
+	}
+	switch s := s.(type) {
+	case *avro.RecordSchema:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an object, got %T", v)
+		}
+		out := make(map[string]any, len(s.Fields()))
+		for _, f := range s.Fields() {
+			fv, err := avroValue(f.Type(), m[f.Name()])
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", f.Name(), err)
+			}
+			out[f.Name()] = fv
+		}
+		return out, nil
+	case *avro.ArraySchema:
+		a, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an array, got %T", v)
+		}
+		out := make([]any, len(a))
+		for i, item := range a {
+			iv, err := avroValue(s.Items(), item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			out[i] = iv
+		}
+		return out, nil
+	case *avro.MapSchema:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an object, got %T", v)
+		}
+		out := make(map[string]any, len(m))
+		for k, mv := range m {
+			cv, err := avroValue(s.Values(), mv)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k, err)
+			}
+			out[k] = cv
+		}
+		return out, nil
+	case *avro.PrimitiveSchema:
+		return avroPrimitiveValue(s, v)
+	default:
+		return nil, fmt.Errorf("unsupported schema type %q", s.Type())
+	}
+}
+
+func avroPrimitiveValue(s *avro.PrimitiveSchema, v any) (any, error) {
+	if ls := s.Logical(); ls != nil {
+		switch ls.Type() {
+		case avro.Date:
+			if str, ok := v.(string); ok {
+				return time.Parse(time.DateOnly, str)
+			}
+			days, err := jsonInt(v, 32)
+			if err != nil {
+				return nil, err
+			}
+			return time.Unix(0, 0).UTC().AddDate(0, 0, int(days)), nil
+		case avro.TimeMillis:
+			ms, err := jsonInt(v, 32)
+			return time.Duration(ms) * time.Millisecond, err
+		case avro.TimeMicros:
+			us, err := jsonInt(v, 64)
+			return time.Duration(us) * time.Microsecond, err
+		case avro.TimestampMillis, avro.TimestampMicros:
+			if str, ok := v.(string); ok {
+				return time.Parse(time.RFC3339Nano, str)
+			}
+			i, err := jsonInt(v, 64)
+			if err != nil {
+				return nil, err
+			}
+			if ls.Type() == avro.TimestampMillis {
+				return time.UnixMilli(i).UTC(), nil
+			}
+			return time.UnixMicro(i).UTC(), nil
+		case avro.Decimal:
+			b, err := jsonBytes(v)
+			if err != nil {
+				return nil, err
+			}
+			scale := ls.(*avro.DecimalLogicalSchema).Scale()
+			denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+			return new(big.Rat).SetFrac(fromTwosComplement(b), denom), nil
+		}
+	}
+
+	switch s.Type() {
+	case avro.Boolean:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean, got %T", v)
+		}
+		return b, nil
+	case avro.Int:
+		i, err := jsonInt(v, 32)
+		return int(i), err
+	case avro.Long:
+		return jsonInt(v, 64)
+	case avro.Float:
+		f, err := jsonFloat(v)
+		return float32(f), err
+	case avro.Double:
+		return jsonFloat(v)
+	case avro.Bytes:
+		return jsonBytes(v)
+	case avro.String:
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %T", v)
+		}
+		return str, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %q", s.Type())
+	}
+}
+
+func jsonInt(v any, bits int) (int64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("expected a number, got %T", v)
+	}
+	i, err := strconv.ParseInt(n.String(), 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("expected an int%d, got %v", bits, n)
+	}
+	return i, nil
+}
+
+func jsonFloat(v any) (float64, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("expected a number, got %T", v)
+	}
+	return n.Float64()
+}
+
+func jsonBytes(v any) ([]byte, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected base64 encoded bytes, got %T", v)
+	}
+	return base64.StdEncoding.DecodeString(str)
+}
+
+// fromTwosComplement decodes a big-endian two's complement integer.
+func fromTwosComplement(b []byte) *big.Int {
+	i := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		i.Sub(i, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+	}
+	return i
+}
@@ -0,0 +1,277 @@
+// Copyright © 2025 Meroxa, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package source
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-kafka/common"
+	sdkschema "github.com/conduitio/conduit-connector-sdk/schema"
+	"github.com/goccy/go-json"
+	"github.com/hamba/avro/v2"
+	"github.com/matryer/is"
+)
+
+const testConnectEnvelope = `{
+  "schema": {
+    "type": "struct",
+    "name": "shop.Envelope",
+    "fields": [
+      {"field": "before", "type": "struct", "name": "shop.Order", "optional": true, "fields": [
+        {"field": "id", "type": "int32"},
+        {"field": "note", "type": "string", "optional": true}
+      ]},
+      {"field": "after", "type": "struct", "name": "shop.Order", "optional": true, "fields": [
+        {"field": "id", "type": "int32"},
+        {"field": "note", "type": "string", "optional": true}
+      ]},
+      {"field": "amount", "type": "bytes", "name": "org.apache.kafka.connect.data.Decimal", "version": 1,
+        "parameters": {"scale": "2", "connect.decimal.precision": "10"}},
+      {"field": "ts", "type": "int64", "name": "org.apache.kafka.connect.data.Timestamp", "version": 1},
+      {"field": "tags", "type": "array", "items": {"type": "string"}},
+      {"field": "attrs", "type": "map", "keys": {"type": "string"}, "values": {"type": "int64"}},
+      {"field": "op", "type": "string"}
+    ]
+  },
+  "payload": {
+    "before": null,
+    "after": {"id": 1, "note": "foo"},
+    "amount": "MDk=",
+    "ts": 1700000000123,
+    "tags": ["a"],
+    "attrs": {"x": 9007199254740993},
+    "op": "c"
+  }
+}`
+
+func TestSchemaDecoder_KafkaConnect(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	d, err := NewSchemaDecoder(Config{})
+	is.NoErr(err)
+
+	got, sch, err := d.Decode(ctx, t.Name(), []byte(testConnectEnvelope))
+	is.NoErr(err)
+	is.True(sch != nil)
+	is.Equal(sch.Subject, t.Name())
+
+	want := opencdc.StructuredData{
+		"before": nil,
+		"after":  map[string]any{"id": 1, "note": "foo"},
+		"amount": big.NewRat(12345, 100),
+		"ts":     time.UnixMilli(1700000000123).UTC(),
+		"tags":   []any{"a"},
+		"attrs":  map[string]any{"x": int64(9007199254740993)},
+		"op":     "c",
+	}
+	is.Equal(got, want)
+
+	// the record used twice is defined once and referenced afterward,
+	// otherwise the registered schema would be invalid
+	registered, err := sdkschema.Get(ctx, sch.Subject, sch.Version)
+	is.NoErr(err)
+	as, err := avro.ParseBytes(registered.Bytes)
+	is.NoErr(err)
+	fields := as.(*avro.RecordSchema).Fields()
+	is.Equal(fields[0].Type().(*avro.UnionSchema).Types()[1].Type(), avro.Record)
+	is.Equal(fields[1].Type().(*avro.UnionSchema).Types()[1].Type(), avro.Ref)
+
+	// the schema is registered only once
+	_, again, err := d.Decode(ctx, t.Name(), []byte(testConnectEnvelope))
+	is.NoErr(err)
+	is.Equal(again.Version, sch.Version)
+}
+
+func TestSchemaDecoder_RawData(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{name: "raw", data: "foo"},
+		{name: "json object", data: `{"id": 1}`},
+		{name: "envelope without schema", data: `{"schema": null, "payload": {"id": 1}}`},
+		{name: "unsupported schema", data: `{"schema": {"type": "map", "keys": {"type": "int32"}, "values": {"type": "string"}}, "payload": {}}`},
+		{name: "not a struct", data: `{"schema": {"type": "string"}, "payload": "foo"}`},
+		{name: "wire format without registry", data: "\x00\x00\x00\x00\x01foo"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			d, err := NewSchemaDecoder(Config{})
+			is.NoErr(err)
+
+			got, sch, err := d.Decode(context.Background(), t.Name(), []byte(tc.data))
+			is.NoErr(err)
+			is.Equal(got, opencdc.RawData(tc.data))
+			is.True(sch == nil)
+		})
+	}
+}
+
+func TestSchemaDecoder_Registry(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	avroSchema := `{"type": "record", "name": "Order", "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "day", "type": {"type": "int", "logicalType": "date"}}
+	]}`
+	jsonSchema := `{"type": "object", "title": "order", "properties": {
+		"id": {"type": "integer"},
+		"createdAt": {"type": "string", "format": "date-time"},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"attrs": {"type": "object", "additionalProperties": {"type": "number"}}
+	}, "required": ["id"]}`
+	schemas := map[string]map[string]string{
+		"1": {"schema": avroSchema},
+		"2": {"schema": jsonSchema, "schemaType": registryTypeJSON},
+		"3": {"schema": `syntax = "proto3"; message Order {}`, "schemaType": registryTypeProtobuf},
+	}
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		user, pass, _ := r.BasicAuth()
+		if user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		s, ok := schemas[r.URL.Path[len("/schemas/ids/"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(s)
+	}))
+	defer srv.Close()
+
+	d, err := NewSchemaDecoder(Config{
+		Config:                 common.Config{RequestTimeout: time.Second},
+		SchemaRegistryURL:      srv.URL + "/",
+		SchemaRegistryUsername: "user",
+		SchemaRegistryPassword: "pass",
+	})
+	is.NoErr(err)
+
+	wireFormat := func(id int, body []byte) []byte {
+		return append(binary.BigEndian.AppendUint32([]byte{0}, uint32(id)), body...)
+	}
+
+	// avro
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	body, err := avro.Marshal(avro.MustParse(avroSchema), map[string]any{"id": int64(1), "day": day})
+	is.NoErr(err)
+	for range 2 {
+		got, sch, err := d.Decode(ctx, "avro.payload", wireFormat(1, body))
+		is.NoErr(err)
+		is.Equal(sch.Subject, "avro.payload")
+		is.Equal(got, opencdc.StructuredData{"id": int64(1), "day": day})
+	}
+	is.Equal(requests, 1) // schemas are cached
+
+	// json schema
+	got, sch, err := d.Decode(ctx, "json.payload", wireFormat(2, []byte(
+		`{"id": 2, "createdAt": "2024-01-02T03:04:05Z", "tags": ["a"], "attrs": {"x": 1.5}}`,
+	)))
+	is.NoErr(err)
+	is.True(sch != nil)
+	is.Equal(got, opencdc.StructuredData{
+		"id":        int64(2),
+		"createdAt": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"tags":      []any{"a"},
+		"attrs":     map[string]any{"x": 1.5},
+	})
+
+	// protobuf is not supported
+	data := wireFormat(3, []byte{0x08, 0x01})
+	got, sch, err = d.Decode(ctx, "proto.payload", data)
+	is.NoErr(err)
+	is.True(sch == nil)
+	is.Equal(got, opencdc.RawData(data))
+
+	// data referencing a missing schema is not in the wire format
+	data = wireFormat(4, []byte("raw"))
+	requests = 0
+	for range 2 {
+		got, sch, err = d.Decode(ctx, "missing.payload", data)
+		is.NoErr(err)
+		is.True(sch == nil)
+		is.Equal(got, opencdc.RawData(data))
+	}
+	is.Equal(requests, 1) // missing schemas are cached
+
+	// the schema can be registered later, missing schemas are looked up
+	// again once the TTL expired
+	missing, ok := d.registry.cache.Peek(4)
+	is.True(ok)
+	missing.notFoundUntil = time.Now()
+	d.registry.cache.Add(4, missing)
+	schemas["4"] = map[string]string{"schema": avroSchema}
+	got, sch, err = d.Decode(ctx, "missing.payload", wireFormat(4, body))
+	is.NoErr(err)
+	is.True(sch != nil)
+	is.Equal(got, opencdc.StructuredData{"id": int64(1), "day": day})
+	is.Equal(requests, 2)
+
+	// data that can't be decoded with the schema is not in the wire format
+	for _, data := range [][]byte{
+		wireFormat(1, nil),                // truncated
+		wireFormat(1, append(body, 0x01)), // trailing bytes
+		wireFormat(2, []byte("not json")),
+	} {
+		got, sch, err = d.Decode(ctx, "invalid.payload", data)
+		is.NoErr(err)
+		is.True(sch == nil)
+		is.Equal(got, opencdc.RawData(data))
+	}
+
+	// other registry errors fail
+	d, err = NewSchemaDecoder(Config{
+		Config:            common.Config{RequestTimeout: time.Second},
+		SchemaRegistryURL: srv.URL,
+	})
+	is.NoErr(err)
+	_, _, err = d.Decode(ctx, "avro.payload", wireFormat(1, body))
+	is.True(err != nil)
+}
+
+func TestFromTwosComplement(t *testing.T) {
+	testCases := []struct {
+		in   []byte
+		want int64
+	}{
+		{in: []byte{0x00}, want: 0},
+		{in: []byte{0x7F}, want: 127},
+		{in: []byte{0x00, 0x80}, want: 128},
+		{in: []byte{0xFF}, want: -1},
+		{in: []byte{0x80}, want: -128},
+		{in: []byte{0xFF, 0x7F}, want: -129},
+	}
+	for _, tc := range testCases {
+		t.Run(strconv.FormatInt(tc.want, 10), func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(fromTwosComplement(tc.in).Int64(), tc.want)
+		})
+	}
+}
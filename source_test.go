@@ -15,16 +15,24 @@
 package kafka
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/conduitio/conduit-commons/opencdc"
+	"github.com/conduitio/conduit-connector-kafka/common"
+	"github.com/conduitio/conduit-connector-kafka/destination"
 	"github.com/conduitio/conduit-connector-kafka/source"
 	"github.com/conduitio/conduit-connector-kafka/test"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/matryer/is"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kfake"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"go.uber.org/mock/gomock"
 )
@@ -60,6 +68,10 @@ func TestSource_Read(t *testing.T) {
 		{Key: "header-a", Value: []byte("value-a")},
 		{Key: "header-b", Value: []byte{0, 1, 2}},
 	}
+	rec.Partition = 2
+	rec.Offset = 42
+	rec.LeaderEpoch = 3
+	rec.ProducerID = -1 // not written by an idempotent producer
 	want := opencdc.Record{
 		Position: source.Position{
 			GroupID:   "",
@@ -73,6 +85,11 @@ func TestSource_Read(t *testing.T) {
 			opencdc.MetadataCreatedAt:  strconv.FormatInt(rec.Timestamp.UnixNano(), 10),
 			"kafka.header.header-a":    "value-a",
 			"kafka.header.header-b":    string([]byte{0, 1, 2}),
+			MetadataKafkaPartition:     "2",
+			MetadataKafkaOffset:        "42",
+			MetadataKafkaLeaderEpoch:   "3",
+			MetadataKafkaTimestampType: "create",
+			MetadataKafkaKeyPresent:    "true",
 		},
 		Key: opencdc.RawData(rec.Key),
 		Payload: opencdc.Change{
@@ -94,3 +111,592 @@ func TestSource_Read(t *testing.T) {
 	want.Metadata[opencdc.MetadataReadAt] = got.Metadata[opencdc.MetadataReadAt]
 	is.Equal(cmp.Diff(want, got, cmpopts.IgnoreUnexported(opencdc.Record{})), "")
 }
+
+func TestSource_Ack(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	pos := source.Position{GroupID: "group", Topic: "foo", Partition: 2, Offset: 42}
+	consumerMock := source.NewMockConsumer(gomock.NewController(t))
+	consumerMock.EXPECT().Ack(ctx, pos).Return(nil)
+
+	underTest := Source{consumer: consumerMock}
+	is.NoErr(underTest.Ack(ctx, pos.ToSDKPosition()))
+	is.True(underTest.Ack(ctx, opencdc.Position("invalid")) != nil)
+}
+
+func TestSetRecordMetadata(t *testing.T) {
+	is := is.New(t)
+
+	rec := &source.Record{
+		Partition:     1,
+		Offset:        7,
+		LeaderEpoch:   -1,
+		ProducerID:    1000,
+		ProducerEpoch: 2,
+	}
+	got := opencdc.Metadata{}
+	setRecordMetadata(got, rec)
+	is.Equal(got, opencdc.Metadata{
+		MetadataKafkaPartition:     "1",
+		MetadataKafkaOffset:        "7",
+		MetadataKafkaTimestampType: "create",
+		MetadataKafkaProducerID:    "1000",
+		MetadataKafkaProducerEpoch: "2",
+		MetadataKafkaKeyPresent:    "false",
+	})
+}
+
+func TestSource_Read_AttachSchemas(t *testing.T) {
+	is := is.New(t)
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	rec := test.GenerateFranzRecords(0, 0, "foo")[0]
+	rec.Key = []byte("raw-key")
+	rec.Value = []byte(`{"schema": {"type": "struct", "fields": [{"field": "id", "type": "int64"}]}, "payload": {"id": 1}}`)
+
+	consumerMock := source.NewMockConsumer(ctrl)
+	consumerMock.
+		EXPECT().
+		Consume(gomock.Any()).
+		Return((*source.Record)(rec), nil)
+
+	cfg := test.ParseConfigMap[source.Config](t, test.SourceConfigMap(t, false, false))
+	cfg.AttachSchemas = true
+	decoder, err := source.NewSchemaDecoder(cfg)
+	is.NoErr(err)
+
+	underTest := Source{consumer: consumerMock, config: cfg, decoder: decoder}
+	got, err := underTest.Read(ctx)
+	is.NoErr(err)
+
+	is.Equal(got.Key, opencdc.RawData("raw-key"))
+	_, err = got.Metadata.GetKeySchemaSubject()
+	is.True(err != nil) // raw keys have no schema
+
+	is.Equal(got.Payload.After, opencdc.StructuredData{"id": int64(1)})
+	subject, err := got.Metadata.GetPayloadSchemaSubject()
+	is.NoErr(err)
+	is.Equal(subject, rec.Topic+".payload")
+	_, err = got.Metadata.GetPayloadSchemaVersion()
+	is.NoErr(err)
+}
+
+func TestSource_Read_Format(t *testing.T) {
+	testCases := []struct {
+		name         string
+		value        string
+		policy       string
+		wantValue    opencdc.Data
+		wantMetadata string
+		wantErr      bool
+	}{{
+		name:      "decoded",
+		value:     `{"id": 1}`,
+		policy:    source.DecodeErrorPolicyRaw,
+		wantValue: opencdc.StructuredData{"id": int64(1)},
+	}, {
+		name:         "raw on error",
+		value:        "not json",
+		policy:       source.DecodeErrorPolicyRaw,
+		wantValue:    opencdc.RawData("not json"),
+		wantMetadata: "data is not valid JSON",
+	}, {
+		name:    "fail on error",
+		value:   "not json",
+		policy:  source.DecodeErrorPolicyFail,
+		wantErr: true,
+	}, {
+		name:      "tombstone",
+		value:     "",
+		policy:    source.DecodeErrorPolicyFail,
+		wantValue: opencdc.RawData{},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			ctrl := gomock.NewController(t)
+
+			rec := test.GenerateFranzRecords(0, 0, "foo")[0]
+			rec.Value = []byte(tc.value)
+
+			consumerMock := source.NewMockConsumer(ctrl)
+			consumerMock.
+				EXPECT().
+				Consume(gomock.Any()).
+				Return((*source.Record)(rec), nil)
+
+			cfg := test.ParseConfigMap[source.Config](t, test.SourceConfigMap(t, false, false))
+			cfg.DecodeErrorPolicy = tc.policy
+			valueDecoder, err := source.NewFormatDecoder(source.FormatJSON, "", "")
+			is.NoErr(err)
+
+			underTest := Source{consumer: consumerMock, config: cfg, valueDecoder: valueDecoder}
+			got, err := underTest.Read(context.Background())
+			if tc.wantErr {
+				is.True(err != nil)
+				return
+			}
+			is.NoErr(err)
+			is.Equal(got.Key, opencdc.RawData(rec.Key)) // raw format
+			is.Equal(got.Payload.After, tc.wantValue)
+			is.True(strings.HasPrefix(got.Metadata[MetadataKafkaValueDecodeError], tc.wantMetadata))
+			_, ok := got.Metadata[MetadataKafkaValueDecodeError]
+			is.Equal(ok, tc.wantMetadata != "")
+		})
+	}
+}
+
+func TestSource_Read_AttachSchemas_DecodeError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{{
+		name:   "raw on error",
+		policy: source.DecodeErrorPolicyRaw,
+	}, {
+		name:    "fail on error",
+		policy:  source.DecodeErrorPolicyFail,
+		wantErr: true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			ctrl := gomock.NewController(t)
+
+			// the payload doesn't match the schema of the envelope
+			rec := test.GenerateFranzRecords(0, 0, "foo")[0]
+			rec.Value = []byte(`{"schema": {"type": "struct", "fields": [{"field": "id", "type": "int64"}]}, "payload": {"id": "foo"}}`)
+
+			consumerMock := source.NewMockConsumer(ctrl)
+			consumerMock.
+				EXPECT().
+				Consume(gomock.Any()).
+				Return((*source.Record)(rec), nil)
+
+			cfg := test.ParseConfigMap[source.Config](t, test.SourceConfigMap(t, false, false))
+			cfg.AttachSchemas = true
+			cfg.DecodeErrorPolicy = tc.policy
+			decoder, err := source.NewSchemaDecoder(cfg)
+			is.NoErr(err)
+
+			underTest := Source{consumer: consumerMock, config: cfg, decoder: decoder}
+			got, err := underTest.Read(context.Background())
+			if tc.wantErr {
+				is.True(errors.Is(err, source.ErrDecode))
+				return
+			}
+			is.NoErr(err)
+			is.Equal(got.Payload.After, opencdc.RawData(rec.Value))
+			is.True(strings.Contains(got.Metadata[MetadataKafkaValueDecodeError], "kafka connect payload"))
+			_, err = got.Metadata.GetPayloadSchemaSubject()
+			is.True(err != nil) // raw data has no schema
+		})
+	}
+}
+
+func TestSource_Read_ChunkedRecord(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(1, "test"),
+	)
+	is.NoErr(err)
+	t.Cleanup(cluster.Close)
+
+	records := []opencdc.Record{
+		{Key: opencdc.RawData("small"), Payload: opencdc.Change{After: opencdc.RawData("foo")}},
+		{Key: opencdc.RawData("large"), Payload: opencdc.Change{After: opencdc.RawData(strings.Repeat("x", 5000))}},
+	}
+
+	p, err := destination.NewFranzProducer(ctx, destination.Config{
+		Config:                common.Config{Servers: cluster.ListenAddrs()},
+		Topic:                 "test",
+		Acks:                  "all",
+		BatchBytes:            1024,
+		OversizedRecordPolicy: "chunk",
+	})
+	is.NoErr(err)
+	defer p.Close(ctx)
+
+	n, err := p.Produce(ctx, records)
+	is.NoErr(err)
+	is.Equal(n, len(records))
+
+	cfg := source.Config{
+		Config:            common.Config{Servers: cluster.ListenAddrs()},
+		Topics:            []string{"test"},
+		GroupID:           "test-group",
+		ReadFromBeginning: true,
+	}
+	c, err := source.NewFranzConsumer(ctx, cfg, nil)
+	is.NoErr(err)
+	underTest := Source{consumer: c, config: cfg}
+	defer func() { is.NoErr(underTest.Teardown(ctx)) }()
+
+	for _, want := range records {
+		got, err := underTest.Read(ctx)
+		is.NoErr(err)
+		is.Equal(got.Key, want.Key)
+		is.Equal(got.Payload.After.Bytes(), want.Bytes())
+		for k := range got.Metadata {
+			is.True(!strings.HasPrefix(k, MetadataKafkaHeaderPrefix+"conduit.chunk.")) // chunk headers are removed
+		}
+		is.NoErr(underTest.Ack(ctx, got.Position))
+	}
+}
+
+func TestSource_Read_ChunkedRecord_NoKey(t *testing.T) {
+	testCases := []struct {
+		name      string
+		overrides map[string]string
+	}{
+		{name: "default partitioner"},
+		{name: "round-robin partitioner", overrides: map[string]string{"test": "partitioner=round-robin"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			ctx := context.Background()
+
+			cluster, err := kfake.NewCluster(
+				kfake.NumBrokers(1),
+				kfake.SeedTopics(6, "test"),
+			)
+			is.NoErr(err)
+			t.Cleanup(cluster.Close)
+
+			// chunks are larger than the 64 KiB after which the default
+			// partitioner switches partitions
+			want := opencdc.Record{
+				Key:     opencdc.RawData(nil),
+				Payload: opencdc.Change{After: opencdc.RawData(strings.Repeat("x", 1<<20))},
+			}
+			p, err := destination.NewFranzProducer(ctx, destination.Config{
+				Config:                common.Config{Servers: cluster.ListenAddrs()},
+				Topic:                 "test",
+				Acks:                  "all",
+				BatchBytes:            100 << 10,
+				OversizedRecordPolicy: "chunk",
+				TopicOverrides:        tc.overrides,
+			})
+			is.NoErr(err)
+			defer p.Close(ctx)
+
+			n, err := p.Produce(ctx, []opencdc.Record{want})
+			is.NoErr(err)
+			is.Equal(n, 1)
+
+			cfg := source.Config{
+				Config:            common.Config{Servers: cluster.ListenAddrs()},
+				Topics:            []string{"test"},
+				GroupID:           "test-group",
+				ReadFromBeginning: true,
+				KeyFormat:         source.FormatRaw,
+				ValueFormat:       source.FormatRaw,
+			}
+			c, err := source.NewFranzConsumer(ctx, cfg, nil)
+			is.NoErr(err)
+			underTest := Source{consumer: c, config: cfg}
+			defer func() { is.NoErr(underTest.Teardown(ctx)) }()
+
+			readCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			got, err := underTest.Read(readCtx)
+			is.NoErr(err)
+			is.Equal(got.Key, nil)
+			is.True(bytes.Equal(got.Payload.After.Bytes(), want.Bytes())) // don't print 1 MB on failure
+		})
+	}
+}
+
+func TestSource_Read_Partitions(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(2, "test"),
+	)
+	is.NoErr(err)
+	t.Cleanup(cluster.Close)
+
+	cl, err := kgo.NewClient(
+		kgo.SeedBrokers(cluster.ListenAddrs()...),
+		kgo.RecordPartitioner(kgo.ManualPartitioner()),
+	)
+	is.NoErr(err)
+	defer cl.Close()
+	for i := range 4 {
+		for partition := range int32(2) {
+			rec := &kgo.Record{
+				Topic:     "test",
+				Partition: partition,
+				Value:     []byte(strconv.Itoa(int(partition)) + "/" + strconv.Itoa(i)),
+			}
+			is.NoErr(cl.ProduceSync(ctx, rec).FirstErr())
+		}
+	}
+
+	cfg := source.Config{
+		Config:            common.Config{Servers: cluster.ListenAddrs()},
+		Partitions:        []string{"test:0@1-2"},
+		ReadFromBeginning: true,
+		KeyFormat:         source.FormatRaw,
+		ValueFormat:       source.FormatRaw,
+	}
+	is.NoErr(cfg.Validate(ctx))
+
+	underTest := Source{config: cfg}
+	is.NoErr(underTest.Open(ctx, nil))
+
+	got, err := underTest.Read(ctx)
+	is.NoErr(err)
+	is.Equal(got.Payload.After, opencdc.RawData("0/1"))
+	is.NoErr(underTest.Ack(ctx, got.Position))
+	is.NoErr(underTest.Teardown(ctx))
+
+	// resume from the position
+	underTest = Source{config: cfg}
+	is.NoErr(underTest.Open(ctx, got.Position))
+	got, err = underTest.Read(ctx)
+	is.NoErr(err)
+	is.Equal(got.Payload.After, opencdc.RawData("0/2"))
+
+	pos, err := source.ParseSDKPosition(got.Position)
+	is.NoErr(err)
+	is.Equal(pos.Partitions, source.PartitionOffsets{"test": {0: 3}})
+
+	// the end offset was reached, no more records are read
+	readCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = underTest.Read(readCtx)
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	is.NoErr(underTest.Teardown(ctx))
+
+	// positions can't be used with different partitions
+	cfg.Partitions = []string{"test:1"}
+	underTest = Source{config: cfg}
+	is.True(underTest.Open(ctx, got.Position) != nil)
+}
+
+func TestSource_Read_Partitions_ResolveOffsets(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(2, "test"),
+	)
+	is.NoErr(err)
+	t.Cleanup(cluster.Close)
+
+	cl, err := kgo.NewClient(
+		kgo.SeedBrokers(cluster.ListenAddrs()...),
+		kgo.RecordPartitioner(kgo.ManualPartitioner()),
+	)
+	is.NoErr(err)
+	defer cl.Close()
+	for i := range 3 {
+		for partition := range int32(2) {
+			rec := &kgo.Record{
+				Topic:     "test",
+				Partition: partition,
+				Value:     []byte(strconv.Itoa(int(partition)) + "/" + strconv.Itoa(i)),
+			}
+			is.NoErr(cl.ProduceSync(ctx, rec).FirstErr())
+		}
+	}
+
+	cfg := source.Config{
+		Config:      common.Config{Servers: cluster.ListenAddrs()},
+		Partitions:  []string{"test:0@1", "1"},
+		KeyFormat:   source.FormatRaw,
+		ValueFormat: source.FormatRaw,
+	}
+	is.NoErr(cfg.Validate(ctx))
+
+	underTest := Source{config: cfg}
+	is.NoErr(underTest.Open(ctx, nil))
+	got, err := underTest.Read(ctx)
+	is.NoErr(err)
+	is.Equal(got.Payload.After, opencdc.RawData("0/1"))
+	is.NoErr(underTest.Teardown(ctx))
+
+	// partition 1 starts at the end, the position contains its end offset
+	// even though no record of it was read
+	pos, err := source.ParseSDKPosition(got.Position)
+	is.NoErr(err)
+	is.Equal(pos.Partitions, source.PartitionOffsets{"test": {0: 2, 1: 3}})
+
+	// start offsets past the end of the partition fail
+	cfg.Partitions = []string{"test:0@10"}
+	underTest = Source{config: cfg}
+	is.True(underTest.Open(ctx, nil) != nil)
+}
+
+func TestSource_Open_OffsetMismatchPolicy(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	cluster, err := kfake.NewCluster(
+		kfake.NumBrokers(1),
+		kfake.SeedTopics(1, "test"),
+	)
+	is.NoErr(err)
+	t.Cleanup(cluster.Close)
+
+	cl, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...))
+	is.NoErr(err)
+	t.Cleanup(cl.Close)
+	for i := range 5 {
+		rec := &kgo.Record{Topic: "test", Value: []byte(strconv.Itoa(i))}
+		is.NoErr(cl.ProduceSync(ctx, rec).FirstErr())
+	}
+
+	testCases := []struct {
+		policy  string
+		want    opencdc.RawData
+		wantErr bool
+	}{
+		{policy: source.OffsetMismatchPolicyPosition, want: opencdc.RawData("3")},
+		{policy: source.OffsetMismatchPolicyGroup, want: opencdc.RawData("1")},
+		{policy: source.OffsetMismatchPolicyFail, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			is := is.New(t)
+
+			// the group committed offset 1, the position continues at offset 3
+			groupID := "group-" + tc.policy
+			var offsets kadm.Offsets
+			offsets.Add(kadm.Offset{Topic: "test", Partition: 0, At: 1, LeaderEpoch: -1})
+			is.NoErr(kadm.NewClient(cl).CommitAllOffsets(ctx, groupID, offsets))
+			pos := source.Position{
+				GroupID:    groupID,
+				Topic:      "test",
+				Offset:     2,
+				Partitions: source.PartitionOffsets{"test": {0: 3}},
+			}
+
+			cfg := source.Config{
+				Config:               common.Config{Servers: cluster.ListenAddrs()},
+				Topics:               []string{"test"},
+				OffsetMismatchPolicy: tc.policy,
+				KeyFormat:            source.FormatRaw,
+				ValueFormat:          source.FormatRaw,
+			}
+			is.NoErr(cfg.Validate(ctx))
+
+			underTest := Source{config: cfg}
+			is.NoErr(underTest.Open(ctx, pos.ToSDKPosition()))
+			defer func() {
+				is.NoErr(underTest.Teardown(ctx))
+			}()
+
+			readCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			got, err := underTest.Read(readCtx)
+			if tc.wantErr {
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), "offsets in the position differ"))
+				return
+			}
+			is.NoErr(err)
+			is.Equal(got.Payload.After, tc.want)
+
+			gotPos, err := source.ParseSDKPosition(got.Position)
+			is.NoErr(err)
+			is.Equal(gotPos.GroupID, groupID)
+			is.Equal(gotPos.Partitions, source.PartitionOffsets{"test": {0: gotPos.Offset + 1}})
+		})
+	}
+}
+
+func TestSource_Read_MultipleClusters(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	const groupID = "group"
+
+	// both clusters contain the records 0-4, the group committed offset 1
+	servers := make(map[string][]string)
+	for _, name := range []string{"eu", "us"} {
+		cluster, err := kfake.NewCluster(
+			kfake.NumBrokers(1),
+			kfake.SeedTopics(1, "test"),
+		)
+		is.NoErr(err)
+		t.Cleanup(cluster.Close)
+		servers[name] = cluster.ListenAddrs()
+
+		cl, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...))
+		is.NoErr(err)
+		t.Cleanup(cl.Close)
+		for i := range 5 {
+			rec := &kgo.Record{Topic: "test", Value: []byte(name + strconv.Itoa(i))}
+			is.NoErr(cl.ProduceSync(ctx, rec).FirstErr())
+		}
+		var offsets kadm.Offsets
+		offsets.Add(kadm.Offset{Topic: "test", Partition: 0, At: 1, LeaderEpoch: -1})
+		is.NoErr(kadm.NewClient(cl).CommitAllOffsets(ctx, groupID, offsets))
+	}
+
+	cfg := source.Config{
+		Config:               common.Config{Servers: servers["eu"]},
+		Topics:               []string{"test"},
+		OffsetMismatchPolicy: source.OffsetMismatchPolicyPosition,
+		KeyFormat:            source.FormatRaw,
+		ValueFormat:          source.FormatRaw,
+		ClusterName:          "eu",
+		Clusters:             map[string]source.ClusterConfig{"us": {Servers: servers["us"]}},
+	}
+	is.NoErr(cfg.Validate(ctx))
+
+	// the position of a record read from eu contains the offsets of both
+	// clusters, each cluster resumes at its own offset
+	pos := source.Position{
+		GroupID: groupID,
+		Topic:   "test",
+		Offset:  2,
+		Cluster: "eu",
+		Clusters: map[string]source.PartitionOffsets{
+			"eu": {"test": {0: 3}},
+			"us": {"test": {0: 2}},
+		},
+	}
+
+	underTest := Source{config: cfg}
+	is.NoErr(underTest.Open(ctx, pos.ToSDKPosition()))
+	defer func() {
+		is.NoErr(underTest.Teardown(ctx))
+	}()
+
+	readCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	// records are read in a random order across the clusters, the remaining
+	// records are eu3-eu4 and us2-us4
+	first := make(map[string]string)
+	var gotPos source.Position
+	for range 5 {
+		rec, err := underTest.Read(readCtx)
+		is.NoErr(err)
+		cluster := rec.Metadata[MetadataKafkaCluster]
+		if _, ok := first[cluster]; !ok {
+			first[cluster] = string(rec.Payload.After.Bytes())
+		}
+		gotPos, err = source.ParseSDKPosition(rec.Position)
+		is.NoErr(err)
+	}
+	is.Equal(first, map[string]string{"eu": "eu3", "us": "us2"})
+	is.Equal(gotPos.Partitions, nil)
+	is.Equal(gotPos.Clusters, map[string]source.PartitionOffsets{
+		"eu": {"test": {0: 5}},
+		"us": {"test": {0: 5}},
+	})
+}